@@ -0,0 +1,79 @@
+// admin 创建第一个平台管理员。用户已存在时将其设为管理员，否则创建新用户；
+// 已存在管理员时拒绝执行，之后的管理员通过 /api/v1/admin/users/{username}/role 设置。
+//
+// 用法：
+//
+//	go run ./cmd/admin -username root -email root@example.com
+//
+// 新建用户的密码从环境变量 ADMIN_PASSWORD 读取，未设置时从标准输入读取一行。
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/db"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/service"
+	"ddup-apis/internal/utils"
+)
+
+func main() {
+	username := flag.String("username", "", "管理员用户名（必填）")
+	email := flag.String("email", "", "新建用户的邮箱，视为已验证")
+	flag.Parse()
+
+	if *username == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if err := logger.InitLogger(cfg); err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	defer logger.Log.Sync()
+	config.SetConfig(*cfg)
+
+	if err := utils.InitPasswordHasher(cfg); err != nil {
+		log.Fatalf("初始化密码哈希算法失败: %v", err)
+	}
+	if err := utils.InitPasswordPolicy(cfg); err != nil {
+		log.Fatalf("加载密码策略失败: %v", err)
+	}
+	if err := db.InitDB(cfg); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "密码（用户已存在时直接回车）: ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	created, err := service.BootstrapAdmin(context.Background(), db.DB, *username, *email, password)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			for _, f := range appErr.Fields {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", f.Field, f.Message)
+			}
+		}
+		log.Fatalf("设置管理员失败: %v", err)
+	}
+	if created {
+		fmt.Printf("已创建平台管理员 %s\n", *username)
+	} else {
+		fmt.Printf("已将用户 %s 设为平台管理员\n", *username)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"ddup-apis/internal/logger"
 	"ddup-apis/internal/middleware"
 	"ddup-apis/internal/router"
+	"ddup-apis/internal/service"
+	"ddup-apis/internal/storage"
+	"ddup-apis/internal/utils"
 
 	"go.uber.org/zap"
 )
@@ -38,18 +41,46 @@ func main() {
 	logger.Info("加载配置")
 	config.SetConfig(*cfg)
 
+	// 加载 JWT 签名密钥
+	if err := utils.InitKeySet(cfg); err != nil {
+		logger.Fatal("加载 JWT 密钥失败", zap.Error(err))
+	}
+
+	// 初始化密码哈希算法和密码策略
+	if err := utils.InitPasswordHasher(cfg); err != nil {
+		logger.Fatal("初始化密码哈希算法失败", zap.Error(err))
+	}
+	if err := utils.InitPasswordPolicy(cfg); err != nil {
+		logger.Fatal("加载密码策略失败", zap.Error(err))
+	}
+
 	// 初始化数据库
 	logger.Info("初始化数据库")
 	if err := db.InitDB(cfg); err != nil {
 		logger.Fatal("初始化数据库失败", zap.Error(err))
 	}
 
+	// 初始化附件存储
+	store, err := storage.NewStorage(cfg)
+	if err != nil {
+		logger.Fatal("初始化附件存储失败", zap.Error(err))
+	}
+
 	// 初始化路由
-	r := router.SetupRouter()
+	r, err := router.SetupRouter(store)
+	if err != nil {
+		logger.Fatal("初始化路由失败", zap.Error(err))
+	}
 
 	// 启动定期健康检查
 	middleware.PeriodicHealthCheck(cfg.HealthCheck.Interval)
 
+	// 启动过期会话、登录记录、注销账号和已删除附件清理
+	service.PeriodicSessionPurge(db.DB, cfg.Session.PurgeInterval, cfg.Session.Retention)
+	service.PeriodicLoginHistoryPurge(db.DB, cfg.Session.PurgeInterval, cfg.LoginHistory.Retention)
+	service.PeriodicAccountPurge(db.DB, cfg.Session.PurgeInterval, cfg.AccountDeletion.GracePeriod)
+	service.PeriodicAttachmentPurge(db.DB, store, cfg.Session.PurgeInterval)
+
 	// 启动服务
 	logger.Info("启动服务")
 	if err := r.Run(":" + cfg.Server.Port); err != nil {
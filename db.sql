@@ -4,7 +4,8 @@ CREATE TABLE public.users (
     updated_at datetime(3) DEFAULT NULL,
     deleted_at datetime(3) DEFAULT NULL,
     username varchar(50) NOT NULL,
-    email varchar(100) NOT NULL,
+    email varchar(100) DEFAULT NULL,
+    email_verified_at datetime(3) DEFAULT NULL,
     password varchar(100) NOT NULL,
     nickname varchar(50) DEFAULT NULL,
     avatar varchar(255) DEFAULT NULL,
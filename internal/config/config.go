@@ -2,7 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -15,6 +17,10 @@ type Config struct {
 		Mode string `mapstructure:"mode" yaml:"mode" default:"development"`
 	} `mapstructure:"server" yaml:"server"`
 
+	App struct {
+		URL string `mapstructure:"url" yaml:"url" default:"http://localhost:3000"`
+	} `mapstructure:"app" yaml:"app"`
+
 	Database struct {
 		Driver   string `mapstructure:"driver" yaml:"driver" default:"postgres"`
 		Host     string `mapstructure:"host" yaml:"host" default:"localhost"`
@@ -35,10 +41,114 @@ type Config struct {
 	} `mapstructure:"database" yaml:"database"`
 
 	JWT struct {
-		Secret    string        `mapstructure:"secret" yaml:"secret"`
-		ExpiresIn time.Duration `mapstructure:"expires_in" yaml:"expires_in" default:"24h"`
+		Secret               string        `mapstructure:"secret" yaml:"secret"`
+		SigningKeyFile       string        `mapstructure:"signing_key_file" yaml:"signing_key_file"`             // RSA/Ed25519 私钥，配置后不再使用 HS256
+		VerificationKeyFiles []string      `mapstructure:"verification_key_files" yaml:"verification_key_files"` // 轮换前的旧公钥
+		ExpiresIn            time.Duration `mapstructure:"expires_in" yaml:"expires_in" default:"15m"`
+		RefreshExpiresIn     time.Duration `mapstructure:"refresh_expires_in" yaml:"refresh_expires_in" default:"720h"`
+		MaxSessions          int           `mapstructure:"max_sessions" yaml:"max_sessions" default:"5"`
 	} `mapstructure:"jwt" yaml:"jwt"`
 
+	Security struct {
+		LoginMaxAttempts   int           `mapstructure:"login_max_attempts" yaml:"login_max_attempts" default:"5"`
+		LockoutDuration    time.Duration `mapstructure:"lockout_duration" yaml:"lockout_duration" default:"1m"`
+		MaxLockoutDuration time.Duration `mapstructure:"max_lockout_duration" yaml:"max_lockout_duration" default:"24h"`
+		PasswordResetTTL   time.Duration `mapstructure:"password_reset_ttl" yaml:"password_reset_ttl" default:"30m"`
+		EmailVerifyTTL     time.Duration `mapstructure:"email_verify_ttl" yaml:"email_verify_ttl" default:"24h"`
+		ImpersonationTTL   time.Duration `mapstructure:"impersonation_ttl" yaml:"impersonation_ttl" default:"30m"`
+	} `mapstructure:"security" yaml:"security"`
+
+	// MagicLink 邮件登录链接，按邮箱和 IP 分别限制发送频率
+	MagicLink struct {
+		TTL        time.Duration `mapstructure:"ttl" yaml:"ttl" default:"15m"`
+		EmailLimit int           `mapstructure:"email_limit" yaml:"email_limit" default:"3"` // 每个邮箱在 RateWindow 内最多发送次数
+		IPLimit    int           `mapstructure:"ip_limit" yaml:"ip_limit" default:"10"`      // 每个 IP 在 RateWindow 内最多请求次数
+		RateWindow time.Duration `mapstructure:"rate_window" yaml:"rate_window" default:"1h"`
+	} `mapstructure:"magic_link" yaml:"magic_link"`
+
+	Password struct {
+		Hasher            string `mapstructure:"hasher" yaml:"hasher" default:"argon2id"`            // argon2id 或 bcrypt
+		Argon2Memory      uint32 `mapstructure:"argon2_memory" yaml:"argon2_memory" default:"65536"` // KiB
+		Argon2Iterations  uint32 `mapstructure:"argon2_iterations" yaml:"argon2_iterations" default:"3"`
+		Argon2Parallelism uint8  `mapstructure:"argon2_parallelism" yaml:"argon2_parallelism" default:"2"`
+		BcryptCost        int    `mapstructure:"bcrypt_cost" yaml:"bcrypt_cost" default:"12"`
+		MinLength         int    `mapstructure:"min_length" yaml:"min_length" default:"8"`
+		MinCharClasses    int    `mapstructure:"min_char_classes" yaml:"min_char_classes" default:"3"`
+		DisallowUsername  bool   `mapstructure:"disallow_username" yaml:"disallow_username" default:"true"`
+		BreachedDir       string `mapstructure:"breached_dir" yaml:"breached_dir"`
+	} `mapstructure:"password" yaml:"password"`
+
+	Session struct {
+		PurgeInterval time.Duration `mapstructure:"purge_interval" yaml:"purge_interval" default:"1h"`
+		Retention     time.Duration `mapstructure:"retention" yaml:"retention" default:"168h"`
+	} `mapstructure:"session" yaml:"session"`
+
+	// LoginHistory 登录记录，用于识别新设备登录
+	LoginHistory struct {
+		Retention time.Duration `mapstructure:"retention" yaml:"retention" default:"2160h"`
+	} `mapstructure:"login_history" yaml:"login_history"`
+
+	// AccountDeletion 注销账号的宽限期，宽限期内可恢复，到期后由后台任务彻底删除
+	AccountDeletion struct {
+		GracePeriod    time.Duration `mapstructure:"grace_period" yaml:"grace_period" default:"720h"`
+		RestoreOnLogin bool          `mapstructure:"restore_on_login" yaml:"restore_on_login" default:"true"`
+	} `mapstructure:"account_deletion" yaml:"account_deletion"`
+
+	// Notify 安全提醒（如新设备登录）的发送渠道
+	Notify struct {
+		Driver        string `mapstructure:"driver" yaml:"driver" default:"mail"` // mail、webhook 或 none
+		WebhookURL    string `mapstructure:"webhook_url" yaml:"webhook_url"`
+		WebhookSecret string `mapstructure:"webhook_secret" yaml:"webhook_secret"` // 用于签名请求体，为空时不签名
+	} `mapstructure:"notify" yaml:"notify"`
+
+	// Storage 附件存储后端
+	Storage struct {
+		Driver   string `mapstructure:"driver" yaml:"driver" default:"local"` // local 或 s3
+		LocalDir string `mapstructure:"local_dir" yaml:"local_dir" default:"./data/attachments"`
+		S3       struct {
+			Endpoint  string `mapstructure:"endpoint" yaml:"endpoint"` // 如 https://s3.amazonaws.com 或 MinIO 地址
+			Region    string `mapstructure:"region" yaml:"region" default:"us-east-1"`
+			Bucket    string `mapstructure:"bucket" yaml:"bucket"`
+			AccessKey string `mapstructure:"access_key" yaml:"access_key"`
+			SecretKey string `mapstructure:"secret_key" yaml:"secret_key"`
+			PathStyle bool   `mapstructure:"path_style" yaml:"path_style" default:"true"` // MinIO 等需要路径风格的地址
+		} `mapstructure:"s3" yaml:"s3"`
+	} `mapstructure:"storage" yaml:"storage"`
+
+	// Upload 附件上传限制和下载链接签名
+	Upload struct {
+		MaxSize      int64         `mapstructure:"max_size" yaml:"max_size" default:"10485760"` // 单个文件上限，字节
+		AllowedTypes []string      `mapstructure:"allowed_types" yaml:"allowed_types"`          // 按文件内容识别的 MIME 类型
+		UserQuota    int64         `mapstructure:"user_quota" yaml:"user_quota" default:"104857600"`
+		TempDir      string        `mapstructure:"temp_dir" yaml:"temp_dir" default:"./data/uploads"` // 分片上传的暂存目录
+		SessionTTL   time.Duration `mapstructure:"session_ttl" yaml:"session_ttl" default:"24h"`
+		URLTTL       time.Duration `mapstructure:"url_ttl" yaml:"url_ttl" default:"15m"`
+		SigningKey   string        `mapstructure:"signing_key" yaml:"signing_key"` // 下载链接签名密钥，为空时由 JWT 签名密钥派生
+	} `mapstructure:"upload" yaml:"upload"`
+
+	Mail struct {
+		Driver   string `mapstructure:"driver" yaml:"driver" default:"log"`
+		Host     string `mapstructure:"host" yaml:"host"`
+		Port     string `mapstructure:"port" yaml:"port" default:"587"`
+		Username string `mapstructure:"username" yaml:"username"`
+		Password string `mapstructure:"password" yaml:"password"`
+		From     string `mapstructure:"from" yaml:"from"`
+		Dir      string `mapstructure:"dir" yaml:"dir"`
+	} `mapstructure:"mail" yaml:"mail"`
+
+	OAuth struct {
+		Providers   []OAuthProvider `mapstructure:"providers" yaml:"providers"`
+		RedirectURL string          `mapstructure:"redirect_url" yaml:"redirect_url"` // 前端回调页面，默认 APP_URL/oauth/callback
+		StateTTL    time.Duration   `mapstructure:"state_ttl" yaml:"state_ttl" default:"10m"`
+	} `mapstructure:"oauth" yaml:"oauth"`
+
+	// OIDC 作为 OpenID Connect 提供方，供其他应用使用 ddup 账号登录
+	OIDC struct {
+		Issuer       string        `mapstructure:"issuer" yaml:"issuer"`               // 本服务的外部地址，为空时不启用
+		AuthorizeURL string        `mapstructure:"authorize_url" yaml:"authorize_url"` // 前端授权确认页面，默认 APP_URL/oauth/authorize
+		CodeTTL      time.Duration `mapstructure:"code_ttl" yaml:"code_ttl" default:"1m"`
+	} `mapstructure:"oidc" yaml:"oidc"`
+
 	HealthCheck struct {
 		Interval time.Duration `mapstructure:"interval" yaml:"interval" default:"5m"`
 	} `mapstructure:"health_check" yaml:"health_check"`
@@ -58,10 +168,26 @@ type Config struct {
 	} `mapstructure:"swagger" yaml:"swagger"`
 }
 
-var globalConfig Config
+// OAuthProvider 第三方登录提供方，Type 为 github 或 oidc
+type OAuthProvider struct {
+	Name         string   `mapstructure:"name" yaml:"name"`
+	Type         string   `mapstructure:"type" yaml:"type"`
+	ClientID     string   `mapstructure:"client_id" yaml:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret" yaml:"client_secret"`
+	Issuer       string   `mapstructure:"issuer" yaml:"issuer"` // OIDC 提供方的 issuer，用于自动发现端点
+	Scopes       []string `mapstructure:"scopes" yaml:"scopes"`
+}
+
+// uploadSigningKeyPlaceholder 早期示例配置中的 UPLOAD_SIGNING_KEY 占位值，所有复制示例的部署都相同
+const uploadSigningKeyPlaceholder = "your-upload-signing-key-at-least-32-chars"
+
+var (
+	globalConfig Config
+	configured   bool
+)
 
 func GetConfig() *Config {
-	if globalConfig.JWT.Secret == "" {
+	if !configured {
 		log.Fatal("配置未初始化，请确保已调用 SetConfig()")
 	}
 	return &globalConfig
@@ -69,12 +195,25 @@ func GetConfig() *Config {
 
 func SetConfig(cfg Config) {
 	globalConfig = cfg
+	configured = true
 }
 
 func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
 
+	// 附件相关配置都有默认值，升级后未配置的部署可以直接启动
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./data/attachments")
+	viper.SetDefault("STORAGE_S3_REGION", "us-east-1")
+	viper.SetDefault("STORAGE_S3_PATH_STYLE", true)
+	viper.SetDefault("UPLOAD_MAX_SIZE", 10<<20)
+	viper.SetDefault("UPLOAD_ALLOWED_TYPES", []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"})
+	viper.SetDefault("UPLOAD_USER_QUOTA", 100<<20)
+	viper.SetDefault("UPLOAD_TEMP_DIR", "./data/uploads")
+	viper.SetDefault("UPLOAD_SESSION_TTL", 24*time.Hour)
+	viper.SetDefault("UPLOAD_URL_TTL", 15*time.Minute)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
@@ -85,6 +224,9 @@ func LoadConfig() (*Config, error) {
 	config.Server.Port = viper.GetString("SERVER_PORT")
 	config.Server.Mode = viper.GetString("SERVER_MODE")
 
+	// 应用配置
+	config.App.URL = viper.GetString("APP_URL")
+
 	// 数据库配置
 	config.Database.Driver = viper.GetString("DB_DRIVER")
 	config.Database.Host = viper.GetString("DB_HOST")
@@ -95,7 +237,11 @@ func LoadConfig() (*Config, error) {
 
 	// JWT配置
 	config.JWT.Secret = viper.GetString("JWT_SECRET")
+	config.JWT.SigningKeyFile = viper.GetString("JWT_SIGNING_KEY_FILE")
+	config.JWT.VerificationKeyFiles = viper.GetStringSlice("JWT_VERIFICATION_KEY_FILES")
 	config.JWT.ExpiresIn = viper.GetDuration("JWT_EXPIRES_IN")
+	config.JWT.RefreshExpiresIn = viper.GetDuration("JWT_REFRESH_EXPIRES_IN")
+	config.JWT.MaxSessions = viper.GetInt("JWT_MAX_SESSIONS")
 
 	// 数据库连接池配置
 	config.Database.Pool.MaxOpen = viper.GetInt("DB_POOL_MAX_OPEN")
@@ -105,6 +251,93 @@ func LoadConfig() (*Config, error) {
 	config.Database.Retry.Attempts = viper.GetInt("DB_RETRY_ATTEMPTS")
 	config.Database.Retry.Interval = viper.GetDuration("DB_RETRY_INTERVAL")
 
+	// 安全配置
+	config.Security.LoginMaxAttempts = viper.GetInt("LOGIN_MAX_ATTEMPTS")
+	config.Security.LockoutDuration = viper.GetDuration("LOGIN_LOCKOUT_DURATION")
+	config.Security.MaxLockoutDuration = viper.GetDuration("LOGIN_MAX_LOCKOUT_DURATION")
+	config.Security.PasswordResetTTL = viper.GetDuration("PASSWORD_RESET_TTL")
+	config.Security.EmailVerifyTTL = viper.GetDuration("EMAIL_VERIFY_TTL")
+	config.Security.ImpersonationTTL = viper.GetDuration("IMPERSONATION_TTL")
+
+	// 邮件登录链接配置
+	config.MagicLink.TTL = viper.GetDuration("MAGIC_LINK_TTL")
+	config.MagicLink.EmailLimit = viper.GetInt("MAGIC_LINK_EMAIL_LIMIT")
+	config.MagicLink.IPLimit = viper.GetInt("MAGIC_LINK_IP_LIMIT")
+	config.MagicLink.RateWindow = viper.GetDuration("MAGIC_LINK_RATE_WINDOW")
+
+	// 密码哈希配置
+	config.Password.Hasher = viper.GetString("PASSWORD_HASHER")
+	config.Password.Argon2Memory = viper.GetUint32("PASSWORD_ARGON2_MEMORY")
+	config.Password.Argon2Iterations = viper.GetUint32("PASSWORD_ARGON2_ITERATIONS")
+	config.Password.Argon2Parallelism = uint8(viper.GetUint("PASSWORD_ARGON2_PARALLELISM"))
+	config.Password.BcryptCost = viper.GetInt("PASSWORD_BCRYPT_COST")
+	config.Password.MinLength = viper.GetInt("PASSWORD_MIN_LENGTH")
+	config.Password.MinCharClasses = viper.GetInt("PASSWORD_MIN_CHAR_CLASSES")
+	config.Password.DisallowUsername = viper.GetBool("PASSWORD_DISALLOW_USERNAME")
+	config.Password.BreachedDir = viper.GetString("PASSWORD_BREACHED_DIR")
+
+	// 会话清理配置
+	config.Session.PurgeInterval = viper.GetDuration("SESSION_PURGE_INTERVAL")
+	config.Session.Retention = viper.GetDuration("SESSION_RETENTION")
+
+	// 登录记录配置
+	config.LoginHistory.Retention = viper.GetDuration("LOGIN_HISTORY_RETENTION")
+
+	// 注销账号配置
+	config.AccountDeletion.GracePeriod = viper.GetDuration("ACCOUNT_DELETION_GRACE_PERIOD")
+	config.AccountDeletion.RestoreOnLogin = viper.GetBool("ACCOUNT_RESTORE_ON_LOGIN")
+
+	// 通知配置
+	config.Notify.Driver = viper.GetString("NOTIFY_DRIVER")
+	config.Notify.WebhookURL = viper.GetString("NOTIFY_WEBHOOK_URL")
+	config.Notify.WebhookSecret = viper.GetString("NOTIFY_WEBHOOK_SECRET")
+
+	// 附件存储配置
+	config.Storage.Driver = viper.GetString("STORAGE_DRIVER")
+	config.Storage.LocalDir = viper.GetString("STORAGE_LOCAL_DIR")
+	config.Storage.S3.Endpoint = strings.TrimRight(viper.GetString("STORAGE_S3_ENDPOINT"), "/")
+	config.Storage.S3.Region = viper.GetString("STORAGE_S3_REGION")
+	config.Storage.S3.Bucket = viper.GetString("STORAGE_S3_BUCKET")
+	config.Storage.S3.AccessKey = viper.GetString("STORAGE_S3_ACCESS_KEY")
+	config.Storage.S3.SecretKey = viper.GetString("STORAGE_S3_SECRET_KEY")
+	config.Storage.S3.PathStyle = viper.GetBool("STORAGE_S3_PATH_STYLE")
+
+	// 附件上传配置
+	config.Upload.MaxSize = viper.GetInt64("UPLOAD_MAX_SIZE")
+	config.Upload.AllowedTypes = viper.GetStringSlice("UPLOAD_ALLOWED_TYPES")
+	config.Upload.UserQuota = viper.GetInt64("UPLOAD_USER_QUOTA")
+	config.Upload.TempDir = viper.GetString("UPLOAD_TEMP_DIR")
+	config.Upload.SessionTTL = viper.GetDuration("UPLOAD_SESSION_TTL")
+	config.Upload.URLTTL = viper.GetDuration("UPLOAD_URL_TTL")
+	config.Upload.SigningKey = viper.GetString("UPLOAD_SIGNING_KEY")
+
+	// 邮件配置
+	config.Mail.Driver = viper.GetString("MAIL_DRIVER")
+	config.Mail.Host = viper.GetString("MAIL_HOST")
+	config.Mail.Port = viper.GetString("MAIL_PORT")
+	config.Mail.Username = viper.GetString("MAIL_USERNAME")
+	config.Mail.Password = viper.GetString("MAIL_PASSWORD")
+	config.Mail.From = viper.GetString("MAIL_FROM")
+	config.Mail.Dir = viper.GetString("MAIL_DIR")
+
+	// 第三方登录配置
+	config.OAuth.RedirectURL = viper.GetString("OAUTH_REDIRECT_URL")
+	if config.OAuth.RedirectURL == "" {
+		config.OAuth.RedirectURL = strings.TrimRight(config.App.URL, "/") + "/oauth/callback"
+	}
+	config.OAuth.StateTTL = viper.GetDuration("OAUTH_STATE_TTL")
+	for _, name := range viper.GetStringSlice("OAUTH_PROVIDERS") {
+		config.OAuth.Providers = append(config.OAuth.Providers, loadOAuthProvider(strings.ToLower(name)))
+	}
+
+	// OIDC 提供方配置
+	config.OIDC.Issuer = strings.TrimRight(viper.GetString("OIDC_ISSUER"), "/")
+	config.OIDC.AuthorizeURL = viper.GetString("OIDC_AUTHORIZE_URL")
+	if config.OIDC.AuthorizeURL == "" {
+		config.OIDC.AuthorizeURL = strings.TrimRight(config.App.URL, "/") + "/oauth/authorize"
+	}
+	config.OIDC.CodeTTL = viper.GetDuration("OIDC_CODE_TTL")
+
 	// 健康检查配置
 	config.HealthCheck.Interval = viper.GetDuration("HEALTH_CHECK_INTERVAL")
 
@@ -128,13 +361,142 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
+// loadOAuthProvider 读取 OAUTH_<NAME>_* 配置，github 和 google 可以省略 type 和 issuer
+func loadOAuthProvider(name string) OAuthProvider {
+	prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+	provider := OAuthProvider{
+		Name:         name,
+		Type:         viper.GetString(prefix + "TYPE"),
+		ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+		ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+		Issuer:       viper.GetString(prefix + "ISSUER"),
+		Scopes:       viper.GetStringSlice(prefix + "SCOPES"),
+	}
+
+	if provider.Type == "" {
+		provider.Type = "oidc"
+		if name == "github" {
+			provider.Type = "github"
+		}
+	}
+	if provider.Issuer == "" && name == "google" {
+		provider.Issuer = "https://accounts.google.com"
+	}
+	return provider
+}
+
 func validateConfig(cfg *Config) error {
-	if cfg.JWT.Secret == "" {
-		return errors.New("JWT secret is required")
+	if cfg.JWT.Secret == "" && cfg.JWT.SigningKeyFile == "" {
+		return errors.New("JWT secret or signing key file is required")
 	}
 	if cfg.JWT.ExpiresIn <= 0 {
 		return errors.New("JWT expiration time must be positive")
 	}
+	if cfg.JWT.RefreshExpiresIn <= cfg.JWT.ExpiresIn {
+		return errors.New("JWT refresh expiration time must be longer than access token expiration time")
+	}
+	if cfg.JWT.MaxSessions <= 0 {
+		return errors.New("JWT max sessions must be positive")
+	}
+	if cfg.Security.LoginMaxAttempts <= 0 {
+		return errors.New("login max attempts must be positive")
+	}
+	if cfg.Security.LockoutDuration <= 0 || cfg.Security.MaxLockoutDuration < cfg.Security.LockoutDuration {
+		return errors.New("lockout duration must be positive and not exceed max lockout duration")
+	}
+	if cfg.Security.PasswordResetTTL <= 0 || cfg.Security.EmailVerifyTTL <= 0 {
+		return errors.New("password reset and email verification TTL must be positive")
+	}
+	if cfg.Security.ImpersonationTTL <= 0 {
+		return errors.New("impersonation ttl must be positive")
+	}
+	if cfg.MagicLink.TTL <= 0 || cfg.MagicLink.RateWindow <= 0 {
+		return errors.New("magic link ttl and rate window must be positive")
+	}
+	if cfg.MagicLink.EmailLimit <= 0 || cfg.MagicLink.IPLimit <= 0 {
+		return errors.New("magic link email and ip limits must be positive")
+	}
+	switch cfg.Password.Hasher {
+	case "argon2id":
+		if cfg.Password.Argon2Memory == 0 || cfg.Password.Argon2Iterations == 0 || cfg.Password.Argon2Parallelism == 0 {
+			return errors.New("argon2 memory, iterations and parallelism must be positive")
+		}
+	case "bcrypt":
+		if cfg.Password.BcryptCost < 4 || cfg.Password.BcryptCost > 31 {
+			return errors.New("bcrypt cost must be between 4 and 31")
+		}
+	default:
+		return errors.New("password hasher must be argon2id or bcrypt")
+	}
+	if cfg.Password.MinLength <= 0 || cfg.Password.MinLength > 128 {
+		return errors.New("password min length must be between 1 and 128")
+	}
+	if cfg.Password.MinCharClasses < 0 || cfg.Password.MinCharClasses > 4 {
+		return errors.New("password min char classes must be between 0 and 4")
+	}
+	if cfg.Session.PurgeInterval <= 0 || cfg.Session.Retention <= 0 {
+		return errors.New("session purge interval and retention must be positive")
+	}
+	for _, p := range cfg.OAuth.Providers {
+		if p.ClientID == "" {
+			return fmt.Errorf("oauth provider %s: client id is required", p.Name)
+		}
+		if p.Type != "github" && p.Type != "oidc" {
+			return fmt.Errorf("oauth provider %s: type must be github or oidc", p.Name)
+		}
+		if p.Type == "oidc" && p.Issuer == "" {
+			return fmt.Errorf("oauth provider %s: issuer is required for oidc", p.Name)
+		}
+	}
+	if len(cfg.OAuth.Providers) > 0 && cfg.OAuth.StateTTL <= 0 {
+		return errors.New("oauth state ttl must be positive")
+	}
+	if cfg.OIDC.Issuer != "" {
+		// HS256 签名的 ID token 无法被客户端验证
+		if cfg.JWT.SigningKeyFile == "" {
+			return errors.New("jwt signing key file is required when oidc issuer is set")
+		}
+		if cfg.OIDC.CodeTTL <= 0 {
+			return errors.New("oidc code ttl must be positive")
+		}
+	}
+	if cfg.LoginHistory.Retention <= 0 {
+		return errors.New("login history retention must be positive")
+	}
+	if cfg.AccountDeletion.GracePeriod < 0 {
+		return errors.New("account deletion grace period must not be negative")
+	}
+	if cfg.Notify.Driver == "webhook" && cfg.Notify.WebhookURL == "" {
+		return errors.New("notify webhook url is required for webhook driver")
+	}
+	switch cfg.Storage.Driver {
+	case "local":
+		if cfg.Storage.LocalDir == "" {
+			return errors.New("storage local dir is required for local driver")
+		}
+	case "s3":
+		if cfg.Storage.S3.Endpoint == "" || cfg.Storage.S3.Bucket == "" || cfg.Storage.S3.AccessKey == "" || cfg.Storage.S3.SecretKey == "" {
+			return errors.New("storage s3 endpoint, bucket, access key and secret key are required for s3 driver")
+		}
+	default:
+		return errors.New("storage driver must be local or s3")
+	}
+	if cfg.Upload.MaxSize <= 0 || cfg.Upload.UserQuota < cfg.Upload.MaxSize {
+		return errors.New("upload max size must be positive and not exceed user quota")
+	}
+	if len(cfg.Upload.AllowedTypes) == 0 {
+		return errors.New("upload allowed types are required")
+	}
+	if cfg.Upload.TempDir == "" || cfg.Upload.SessionTTL <= 0 || cfg.Upload.URLTTL <= 0 {
+		return errors.New("upload temp dir, session ttl and url ttl are required")
+	}
+	// 未配置时由 JWT 签名密钥派生；单独配置时不能太短，也不能沿用示例中的占位值
+	if cfg.Upload.SigningKey != "" && (len(cfg.Upload.SigningKey) < 32 || cfg.Upload.SigningKey == uploadSigningKeyPlaceholder) {
+		return errors.New("upload signing key must be a random string of at least 32 characters")
+	}
+	if cfg.Mail.Driver == "smtp" && (cfg.Mail.Host == "" || cfg.Mail.From == "") {
+		return errors.New("mail host and from address are required for smtp driver")
+	}
 	if cfg.Database.Host == "" || cfg.Database.Port == "" {
 		return errors.New("database host and port are required")
 	}
@@ -7,6 +7,7 @@ import (
 	"ddup-apis/internal/config"
 	"ddup-apis/internal/db/driver"
 	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
 
 	"gorm.io/gorm"
 )
@@ -51,13 +52,42 @@ func InitDB(cfg *config.Config) error {
 	sqlDB.SetConnMaxLifetime(cfg.Database.Pool.Lifetime)
 	sqlDB.SetConnMaxIdleTime(cfg.Database.Pool.IdleTime)
 
+	// 迁移前的数据修正
+	if err := migrateUserEmails(db); err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if err := migrateSessionTokens(db); err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if err := migrateSessionRefreshExpiry(db); err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if err := migrateProfileVisibility(db); err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+
 	// 自动迁移表结构
 	if err := db.AutoMigrate(
 		&model.User{},
 		&model.Session{},
+		&model.PasswordResetToken{},
+		&model.MagicLinkToken{},
+		&model.EmailVerificationToken{},
+		&model.RecoveryCode{},
+		&model.PersonalAccessToken{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.OIDCClient{},
+		&model.OIDCConsent{},
+		&model.OIDCAuthorizationCode{},
 		&model.Profile{},
+		&model.ProfileAudience{},
+		&model.ProfileAttachment{},
+		&model.AttachmentUpload{},
 		&model.Organization{},
 		&model.OrganizationMember{},
+		&model.AuditEvent{},
+		&model.LoginHistory{},
 	); err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
 	}
@@ -66,6 +96,17 @@ func InitDB(cfg *config.Config) error {
 	return nil
 }
 
+// migrateUserEmails 邮箱建立唯一索引前，将空字符串邮箱置为 NULL 并统一转为小写
+func migrateUserEmails(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.User{}) {
+		return nil
+	}
+	if err := db.Exec("UPDATE users SET email = NULL WHERE email = ''").Error; err != nil {
+		return err
+	}
+	return db.Exec("UPDATE users SET email = LOWER(TRIM(email)) WHERE email IS NOT NULL").Error
+}
+
 // 添加健康检查方法
 func Ping() error {
 	sqlDB, err := DB.DB()
@@ -74,3 +115,72 @@ func Ping() error {
 	}
 	return sqlDB.Ping()
 }
+
+// migrateSessionTokens 旧版本在 sessions.token 中明文保存 JWT，迁移为 SHA-256 摘要后删除明文列
+func migrateSessionTokens(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Session{}) || !migrator.HasColumn(&model.Session{}, "token") {
+		return nil
+	}
+	if !migrator.HasColumn(&model.Session{}, "token_hash") {
+		if err := migrator.AddColumn(&model.Session{}, "TokenHash"); err != nil {
+			return err
+		}
+	}
+
+	type sessionToken struct {
+		ID    uint
+		Token string
+	}
+	var rows []sessionToken
+	err := db.Table("sessions").Select("id, token").
+		Where("token_hash IS NULL OR token_hash = ''").
+		FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				if err := db.Table("sessions").Where("id = ?", row.ID).
+					Update("token_hash", utils.HashToken(row.Token)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return migrator.DropColumn(&model.Session{}, "token")
+}
+
+// legacySession 用于给已有的 sessions 表添加可为空的 refresh_expired_at 列
+type legacySession struct {
+	RefreshExpiredAt *time.Time
+}
+
+func (legacySession) TableName() string {
+	return "sessions"
+}
+
+// migrateSessionRefreshExpiry 旧版本的会话没有 refresh token，直接添加 NOT NULL 列会因已有数据失败：
+// 先添加可为空的列，以 access token 的过期时间回填（这些会话本来就无法刷新），再改为 NOT NULL
+func migrateSessionRefreshExpiry(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Session{}) || migrator.HasColumn(&model.Session{}, "refresh_expired_at") {
+		return nil
+	}
+	if err := migrator.AddColumn(&legacySession{}, "RefreshExpiredAt"); err != nil {
+		return err
+	}
+	if err := db.Exec("UPDATE sessions SET refresh_expired_at = expired_at WHERE refresh_expired_at IS NULL").Error; err != nil {
+		return err
+	}
+	return migrator.AlterColumn(&model.Session{}, "RefreshExpiredAt")
+}
+
+// migrateProfileVisibility 可见性新增取值后删除旧的 check 约束，新约束 chk_profiles_visibility_levels 由 AutoMigrate 创建
+func migrateProfileVisibility(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Profile{}) || !migrator.HasConstraint(&model.Profile{}, "chk_profiles_visibility") {
+		return nil
+	}
+	return migrator.DropConstraint(&model.Profile{}, "chk_profiles_visibility")
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=profile:read profile:write org:read org:admin"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"omitempty,min=1,max=365"` // 不填表示永不过期
+}
+
+type AccessTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	TokenHint  string     `json:"tokenHint"`
+	Scopes     []string   `json:"scopes"`
+	ExpiredAt  *time.Time `json:"expiredAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIp"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAccessTokenResponse 创建令牌的响应，明文令牌只返回这一次
+type CreateAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
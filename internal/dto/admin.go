@@ -0,0 +1,73 @@
+package dto
+
+import "time"
+
+// AdminUserQueryRequest 管理员查询用户的条件
+type AdminUserQueryRequest struct {
+	PageRequest
+	Query  string `form:"q"`      // 匹配用户名、昵称或邮箱
+	Status string `form:"status"` // active/pending/suspended/disabled/deleted
+	Role   string `form:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// AdminUserResponse 管理员看到的用户信息，包含登录安全状态
+type AdminUserResponse struct {
+	ID uint `json:"id"`
+	UserResponse
+	LoginAttempts int        `json:"loginAttempts"`
+	LockedUntil   *time.Time `json:"lockedUntil"`
+	CreatedAt     time.Time  `json:"createdAt"`
+
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty"` // 已注销账号计划彻底删除的时间
+}
+
+type AdminUserListResponse struct {
+	Items    []AdminUserResponse `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+type AdminOrganizationQueryRequest struct {
+	PageRequest
+	Query string `form:"q"` // 匹配组织名称或显示名称
+}
+
+type AdminOrganizationResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+	Email       string    `json:"email"`
+	Website     string    `json:"website"`
+	MemberCount int64     `json:"memberCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AdminOrganizationListResponse struct {
+	Items    []AdminOrganizationResponse `json:"items"`
+	Total    int64                       `json:"total"`
+	Page     int                         `json:"page"`
+	PageSize int                         `json:"pageSize"`
+}
+
+// TransferOrganizationRequest 将组织转交给指定用户，该用户成为唯一的组织管理员
+type TransferOrganizationRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// ImpersonateRequest 管理员代登录需要说明原因，记入审计日志
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonationResponse 代登录令牌，不附带 refresh token，过期后需重新申请
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	Username  string    `json:"username"`
+	ExpiresIn int64     `json:"expiresIn"`
+	ExpiredAt time.Time `json:"expiredAt"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+// AttachmentResponse 资料项附件
+type AttachmentResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"作品集.pdf"`
+	MimeType  string    `json:"mime_type" example:"application/pdf"`
+	Size      int64     `json:"size" example:"102400"`
+	URL       string    `json:"url" example:"/api/v1/attachments/1?expires=1700000000&signature=xxxx"` // 带签名的下载地址，相对于 API 地址，有效期见 UPLOAD_URL_TTL
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAttachmentUploadRequest 发起分片上传
+type CreateAttachmentUploadRequest struct {
+	Name string `json:"name" binding:"required,max=255" example:"作品集.pdf"`
+	Size int64  `json:"size" binding:"required,min=1" example:"10485760"`
+}
+
+// AttachmentUploadResponse 分片上传的进度，完成后 Attachment 为创建的附件
+type AttachmentUploadResponse struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name" example:"作品集.pdf"`
+	Size       int64               `json:"size" example:"10485760"`
+	Offset     int64               `json:"offset" example:"0"` // 已接收的字节数，下一个分片从这里开始
+	ExpiresAt  time.Time           `json:"expires_at"`
+	Completed  bool                `json:"completed"`
+	Attachment *AttachmentResponse `json:"attachment,omitempty"`
+}
+
+// AttachmentDownloadQuery 附件下载链接的签名参数
+type AttachmentDownloadQuery struct {
+	Expires   int64  `form:"expires" binding:"required"`
+	Viewer    uint   `form:"viewer"`
+	Shared    bool   `form:"shared"`
+	Signature string `form:"signature" binding:"required"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import "time"
+
+// PageRequest 分页参数，page 从 1 开始
+type PageRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+// AuditEventQueryRequest 管理员查询审计事件的过滤条件，均为可选
+type AuditEventQueryRequest struct {
+	PageRequest
+	UserID         uint       `form:"userId"`  // 受影响的用户
+	ActorID        uint       `form:"actorId"` // 执行操作的用户
+	OrganizationID uint       `form:"organizationId"`
+	ImpersonatorID uint       `form:"impersonatorId"` // 代登录的管理员
+	Action         string     `form:"action"`
+	IP             string     `form:"ip"`
+	RequestID      string     `form:"requestId"`
+	Since          *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // RFC 3339，包含
+	Until          *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // RFC 3339，不包含
+}
+
+type AuditEventResponse struct {
+	ID             uint                   `json:"id"`
+	Action         string                 `json:"action"`
+	ActorID        uint                   `json:"actorId"`
+	UserID         uint                   `json:"userId"`
+	OrganizationID uint                   `json:"organizationId,omitempty"`
+	ImpersonatorID uint                   `json:"impersonatorId,omitempty"`
+	IP             string                 `json:"ip"`
+	UserAgent      string                 `json:"userAgent"`
+	RequestID      string                 `json:"requestId"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt      time.Time              `json:"createdAt"`
+}
+
+type AuditEventListResponse struct {
+	Items    []AuditEventResponse `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"pageSize"`
+}
+
+type LoginHistoryResponse struct {
+	ID          uint      `json:"id"`
+	Success     bool      `json:"success"`
+	Method      string    `json:"method"`
+	Reason      string    `json:"reason,omitempty"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	DeviceLabel string    `json:"deviceLabel"`
+	NewDevice   bool      `json:"newDevice"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type LoginHistoryListResponse struct {
+	Items    []LoginHistoryResponse `json:"items"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"pageSize"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type OAuthCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"omitempty,max=100"`
+}
+
+type OAuthAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"` // 前端跳转到该地址完成授权
+	State            string `json:"state"`
+}
+
+type OAuthProviderResponse struct {
+	Name string `json:"name"`
+}
+
+type IdentityResponse struct {
+	ID        uint      `json:"id"`
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+}
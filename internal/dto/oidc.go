@@ -0,0 +1,86 @@
+package dto
+
+import "time"
+
+// OIDCAuthorizeRequest 应用发起的授权请求，参数与 OpenID Connect 授权端点一致
+type OIDCAuthorizeRequest struct {
+	ClientID            string `form:"client_id" json:"clientId" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirectUri" binding:"required"`
+	ResponseType        string `form:"response_type" json:"responseType" binding:"required"`
+	Scope               string `form:"scope" json:"scope" binding:"required"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce" binding:"max=255"`
+	CodeChallenge       string `form:"code_challenge" json:"codeChallenge" binding:"max=128"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"codeChallengeMethod"`
+}
+
+// OIDCApproveRequest 用户确认或拒绝授权
+type OIDCApproveRequest struct {
+	OIDCAuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+type OIDCClientInfo struct {
+	ClientID string `json:"clientId"`
+	Name     string `json:"name"`
+}
+
+// OIDCAuthorizeResponse 授权确认页面需要展示的信息
+type OIDCAuthorizeResponse struct {
+	Client          OIDCClientInfo `json:"client"`
+	Scopes          []string       `json:"scopes"`
+	ConsentRequired bool           `json:"consentRequired"` // 为 false 时用户已授权过这些 scope，可直接确认
+}
+
+type OIDCRedirectResponse struct {
+	RedirectURL string `json:"redirectUrl"` // 携带授权码或错误信息的应用回调地址
+}
+
+// OIDCTokenRequest 令牌端点请求，表单格式
+type OIDCTokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// OIDCTokenResponse 令牌端点响应，字段名遵循 OAuth 2.0 规范
+type OIDCTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type CreateOIDCClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,dive,url"`
+	Public       bool     `json:"public"` // 公开客户端（SPA、移动端）没有 client secret，必须使用 PKCE
+}
+
+type OIDCClientResponse struct {
+	ClientID     string    `json:"clientId"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CreateOIDCClientResponse client secret 只在创建时返回一次
+type CreateOIDCClientResponse struct {
+	OIDCClientResponse
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// OIDCConsentResponse 用户已授权的应用
+type OIDCConsentResponse struct {
+	ClientID   string    `json:"clientId"`
+	ClientName string    `json:"clientName"`
+	Scopes     []string  `json:"scopes"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
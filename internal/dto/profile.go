@@ -17,7 +17,7 @@ type CreateProfileRequest struct {
 	URL          string          `json:"url" example:"https://example.com"`
 	Description  string          `json:"description" example:"这是一段描述"`
 	Metadata     json.RawMessage `json:"metadata" swaggertype:"string" example:"{\"degree\":\"学士\"}"`
-	Visibility   string          `json:"visibility" example:"public"`
+	Visibility   string          `json:"visibility" binding:"omitempty,oneof=public private unlisted organization audience" example:"public"`
 }
 
 // UpdateProfileRequest 更新个人资料请求
@@ -31,7 +31,22 @@ type UpdateProfileRequest struct {
 	URL          string          `json:"url"`
 	Description  string          `json:"description"`
 	Metadata     json.RawMessage `json:"metadata"`
-	Visibility   string          `json:"visibility"`
+	Visibility   string          `json:"visibility" binding:"omitempty,oneof=public private unlisted organization audience"`
+}
+
+// PatchProfileRequest 按 RFC 7396 JSON Merge Patch 修改资料项：省略的字段保持不变，值为 null 的字段被清空，
+// metadata 按对象逐层合并。title 和 visibility 不能清空
+type PatchProfileRequest struct {
+	Title        string          `json:"title" example:"测试大学"`
+	Year         *int            `json:"year" example:"2020"`
+	StartDate    *time.Time      `json:"start_date" example:"2020-09-01T00:00:00Z"`
+	EndDate      *time.Time      `json:"end_date" example:"2024-06-30T00:00:00Z"`
+	Organization string          `json:"organization" example:"测试大学"`
+	Location     string          `json:"location" example:"北京"`
+	URL          string          `json:"url" example:"https://example.com"`
+	Description  string          `json:"description" example:"这是一段描述"`
+	Metadata     json.RawMessage `json:"metadata" swaggertype:"object"`
+	Visibility   string          `json:"visibility" example:"public"`
 }
 
 // UpdateDisplayOrderRequest 更新显示顺序请求
@@ -59,4 +74,62 @@ type ProfileResponse struct {
 	Visibility   string          `json:"visibility" example:"public"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	// 元数据所符合的 schema 版本，0 表示保存时还没有 schema
+	SchemaVersion int                  `json:"schema_version" example:"1"`
+	Attachments   []AttachmentResponse `json:"attachments"`
+}
+
+// PublicProfileResponse 用户公开主页，本人访问时包含私密资料项
+type PublicProfileResponse struct {
+	Username string                   `json:"username" example:"alice"`
+	Nickname string                   `json:"nickname" example:"Alice"`
+	Avatar   string                   `json:"avatar"`
+	Bio      string                   `json:"bio"`
+	General  *ProfileResponse         `json:"general"` // general 类型的资料项，没有时为 null
+	Sections []ProfileSectionResponse `json:"sections"`
+	IsOwner  bool                     `json:"is_owner"`
+}
+
+// ProfileSectionResponse 同一类型的资料项，按显示顺序排列
+type ProfileSectionResponse struct {
+	Type  string            `json:"type" example:"education"`
+	Items []ProfileResponse `json:"items"`
+}
+
+// CreateProfileShareRequest 生成分享链接请求
+type CreateProfileShareRequest struct {
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-01-01T00:00:00Z"` // 为空时永久有效
+}
+
+// ProfileShareResponse 分享链接，令牌只在生成时返回一次
+type ProfileShareResponse struct {
+	Token     string     `json:"token"`
+	URL       string     `json:"url" example:"https://ddup.example.com/s/xxxx"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// SharedProfileResponse 通过分享链接访问的资料项
+type SharedProfileResponse struct {
+	Username string          `json:"username" example:"alice"`
+	Nickname string          `json:"nickname" example:"Alice"`
+	Avatar   string          `json:"avatar"`
+	Item     ProfileResponse `json:"item"`
+}
+
+// ProfileAudienceRequest 设置资料项的可见用户，替换原有名单
+type ProfileAudienceRequest struct {
+	Usernames []string `json:"usernames" binding:"max=100" example:"bob,carol"`
+}
+
+// ProfileAudienceResponse 资料项的可见用户，仅在可见性为 audience 时生效
+type ProfileAudienceResponse struct {
+	Usernames []string `json:"usernames"`
+}
+
+// ProfileSchemaResponse 资料类型的元数据 schema
+type ProfileSchemaResponse struct {
+	Type    string          `json:"type" example:"education"`
+	Version int             `json:"version" example:"1"`
+	Latest  bool            `json:"latest"` // 是否为当前用于校验的版本
+	Schema  json.RawMessage `json:"schema" swaggertype:"object"`
 }
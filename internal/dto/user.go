@@ -5,17 +5,19 @@ import "time"
 // 请求DTO
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50,alphanum"`
-	Password string `json:"password" binding:"required,min=8,max=50"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Password string `json:"password" binding:"required,max=128"` // 长度和复杂度由密码策略检查
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"` // 用户名或已验证的邮箱
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"omitempty,max=100"`
 }
 
 type UpdateUserRequest struct {
 	Nickname string `json:"nickname" binding:"required,min=2,max=20"`
-	Email    string `json:"email,omitempty"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email,max=100"`
 	Mobile   string `json:"mobile,omitempty"`
 	Location string `json:"location,omitempty"`
 	Bio      string `json:"bio,omitempty"`
@@ -24,30 +26,129 @@ type UpdateUserRequest struct {
 	Language string `json:"language" binding:"omitempty,oneof=zh-CN en-US"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkLoginRequest struct {
+	Token      string `json:"token" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"omitempty,max=100"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,max=128"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"` // 6 位 TOTP 验证码或恢复码
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"` // 已设置密码的账号需要验证密码
+	Code     string `json:"code"`     // 未设置密码的账号使用验证码或恢复码
+}
+
+type DisablePasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
 type ChangePasswordRequest struct {
-	OldPassword string `json:"oldPassword" binding:"required,min=6"`
-	NewPassword string `json:"newPassword" binding:"required,min=6"`
+	OldPassword string `json:"oldPassword" binding:"omitempty,max=128"` // 未设置过密码的账号可留空
+	NewPassword string `json:"newPassword" binding:"required,max=128"`
+}
+
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active pending suspended disabled deleted"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ClientInfo 发起请求的客户端信息，随会话一起记录
+type ClientInfo struct {
+	UserAgent  string
+	ClientIP   string
+	DeviceName string
 }
 
 // 响应DTO
 type UserResponse struct {
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	Mobile    string     `json:"mobile"`
-	Location  string     `json:"location"`
-	Nickname  string     `json:"nickname"`
-	Bio       string     `json:"bio"`
-	Gender    string     `json:"gender"`
-	Birthday  *time.Time `json:"birthday"`
-	Avatar    string     `json:"avatar"`
-	LastLogin *time.Time `json:"lastLogin"`
-	Language  string     `json:"language"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	EmailVerified    bool       `json:"emailVerified"`
+	Mobile           string     `json:"mobile"`
+	Location         string     `json:"location"`
+	Nickname         string     `json:"nickname"`
+	Bio              string     `json:"bio"`
+	Gender           string     `json:"gender"`
+	Birthday         *time.Time `json:"birthday"`
+	Avatar           string     `json:"avatar"`
+	LastLogin        *time.Time `json:"lastLogin"`
+	Language         string     `json:"language"`
+	Status           string     `json:"status"`
+	Role             string     `json:"role"` // 平台角色：user 或 admin
+	TwoFactorEnabled bool       `json:"twoFactorEnabled"`
+	PasswordEnabled  bool       `json:"passwordEnabled"` // 为 false 时只能通过邮件链接或第三方账号登录
+}
+
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refreshToken"`
+	CreatedAt        time.Time `json:"createdAt"`
+	ExpiresIn        int64     `json:"expiresIn"`
+	ExpiredAt        time.Time `json:"expiredAt"`
+	RefreshExpiredAt time.Time `json:"refreshExpiredAt"`
 }
 
+// LoginResponse 登录响应。开启两步验证时只返回 challengeToken，
+// 需要携带它和验证码调用 /auth/login/2fa 完成登录
 type LoginResponse struct {
-	Token     string       `json:"token"`
-	CreatedAt time.Time    `json:"createdAt"`
-	ExpiresIn int64        `json:"expiresIn"`
-	ExpiredAt time.Time    `json:"expiredAt"`
-	User      UserResponse `json:"user"`
+	*TokenResponse
+	User               *UserResponse `json:"user,omitempty"`
+	TwoFactorRequired  bool          `json:"twoFactorRequired"`
+	ChallengeToken     string        `json:"challengeToken,omitempty"`
+	ChallengeExpiredAt *time.Time    `json:"challengeExpiredAt,omitempty"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+	QRCode     string `json:"qrCode"` // PNG 图片的 data URI
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type SessionResponse struct {
+	ID          uint       `json:"id"`
+	DeviceLabel string     `json:"deviceLabel"`
+	UserAgent   string     `json:"userAgent"`
+	ClientIP    string     `json:"clientIp"`
+	SignedInAt  time.Time  `json:"signedInAt"`
+	LastSeenAt  *time.Time `json:"lastSeenAt"`
+	ExpiredAt   time.Time  `json:"expiredAt"`
+	Current     bool       `json:"current"`
+}
+
+// AccountDeletionResponse 注销账号后返回计划彻底删除的时间，此前重新登录可恢复账号
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletionScheduledAt"`
+	RestoreOnLogin      bool      `json:"restoreOnLogin"`
 }
@@ -7,9 +7,17 @@ import (
 
 // AppError 自定义错误类型
 type AppError struct {
-	Code    int    // HTTP 状态码
-	Message string // 错误信息
-	Err     error  // 原始错误
+	Code    int          // HTTP 状态码
+	Message string       // 错误信息
+	Err     error        // 原始错误
+	Fields  []FieldError // 字段级错误，作为响应的 data 返回
+}
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 请求中的字段名
+	Code    string `json:"code"`    // 错误代码，便于前端处理
+	Message string `json:"message"` // 错误信息
 }
 
 func (e *AppError) Error() string {
@@ -56,6 +64,15 @@ func New(code int, message string, err error) *AppError {
 	}
 }
 
+// Validation 创建带字段级错误的参数错误
+func Validation(message string, fields []FieldError) *AppError {
+	return &AppError{
+		Code:    http.StatusBadRequest,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
 // Wrap 包装已有错误
 func Wrap(err error, message string) *AppError {
 	if appErr, ok := err.(*AppError); ok {
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags 用户
+// @Summary 创建个人访问令牌
+// @Description 创建带权限范围的个人访问令牌，供脚本和 CI 使用，令牌只在创建时显示一次
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.CreateAccessTokenRequest true "令牌信息"
+// @Success 200 {object} Response{data=dto.CreateAccessTokenResponse} "创建成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/tokens [post]
+func (h *UserHandler) CreateAccessToken(c *gin.Context) {
+	var req dto.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.CreateAccessToken(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "创建成功", resp)
+}
+
+// @Tags 用户
+// @Summary 获取个人访问令牌列表
+// @Description 获取当前用户的个人访问令牌，不包含令牌明文
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]dto.AccessTokenResponse} "获取成功"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/tokens [get]
+func (h *UserHandler) GetAccessTokens(c *gin.Context) {
+	tokens, err := h.userService.ListAccessTokens(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", tokens)
+}
+
+// @Tags 用户
+// @Summary 删除个人访问令牌
+// @Description 删除后该令牌立即失效
+// @Produce json
+// @Security Bearer
+// @Param id path int true "令牌ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 401 {object} Response "未授权"
+// @Failure 404 {object} Response "访问令牌不存在"
+// @Router /api/v1/users/tokens/{id} [delete]
+func (h *UserHandler) DeleteAccessToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的令牌ID")
+		return
+	}
+
+	if err := h.userService.DeleteAccessToken(c.Request.Context(), c.GetUint("userID"), uint(id)); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "删除成功", nil)
+}
@@ -0,0 +1,364 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	userService         service.IUserService
+	organizationService *service.OrganizationService
+}
+
+func NewAdminHandler(userService service.IUserService, organizationService *service.OrganizationService) *AdminHandler {
+	return &AdminHandler{
+		userService:         userService,
+		organizationService: organizationService,
+	}
+}
+
+// @Tags 管理
+// @Summary 解锁账号
+// @Description 清除用户的登录失败次数并解除锁定（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Success 200 {object} Response "解锁成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	if err := h.userService.UnlockUser(c.Request.Context(), c.Param("username")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "解锁成功", nil)
+}
+
+// @Tags 管理
+// @Summary 变更用户状态
+// @Description 变更用户状态（active/pending/suspended/disabled/deleted），停用类状态会立即结束该用户的所有会话，不能暂停、停用或注销最后一个可登录的平台管理员（仅平台管理员可操作）
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Param request body dto.UpdateUserStatusRequest true "状态信息"
+// @Success 200 {object} Response "更新成功"
+// @Failure 400 {object} Response "无效的状态变更或为最后一个平台管理员"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/status [put]
+func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
+	var req dto.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.ChangeUserStatus(c.Request.Context(), c.Param("username"), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "更新用户状态成功", nil)
+}
+
+// @Tags 管理
+// @Summary 查询用户
+// @Description 按用户名、昵称或邮箱搜索用户，可按状态和平台角色过滤（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param q query string false "搜索关键字"
+// @Param status query string false "用户状态" Enums(active, pending, suspended, disabled, deleted)
+// @Param role query string false "平台角色" Enums(user, admin)
+// @Param page query int false "页码，从 1 开始"
+// @Param pageSize query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} Response{data=dto.AdminUserListResponse} "获取成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 403 {object} Response "禁止访问"
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) GetUsers(c *gin.Context) {
+	var req dto.AdminUserQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.ListUsers(c.Request.Context(), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 管理
+// @Summary 查看用户
+// @Description 查看用户信息及登录安全状态（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Success 200 {object} Response{data=dto.AdminUserResponse} "获取成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	resp, err := h.userService.GetUserDetail(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 管理
+// @Summary 变更平台角色
+// @Description 将用户设为平台管理员或普通用户，不能撤销最后一个管理员（仅平台管理员可操作）
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Param request body dto.UpdateUserRoleRequest true "角色信息"
+// @Success 200 {object} Response "更新成功"
+// @Failure 400 {object} Response "无效的角色"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	var req dto.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.ChangeUserRole(c.Request.Context(), c.Param("username"), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "更新用户角色成功", nil)
+}
+
+// @Tags 管理
+// @Summary 强制退出
+// @Description 结束用户在所有设备上的会话，个人访问令牌不受影响（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Success 200 {object} Response "操作成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	if err := h.userService.ForceLogout(c.Request.Context(), c.Param("username")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "已结束用户的所有会话", nil)
+}
+
+// @Tags 管理
+// @Summary 重置两步验证
+// @Description 为丢失认证器的用户关闭两步验证并删除恢复码（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Success 200 {object} Response "重置成功"
+// @Failure 400 {object} Response "用户未开启两步验证"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/2fa [delete]
+func (h *AdminHandler) ResetTwoFactor(c *gin.Context) {
+	if err := h.userService.ResetTwoFactor(c.Request.Context(), c.Param("username")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "重置成功", nil)
+}
+
+// @Tags 管理
+// @Summary 查询组织
+// @Description 按名称搜索全部组织（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param q query string false "搜索关键字"
+// @Param page query int false "页码，从 1 开始"
+// @Param pageSize query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} Response{data=dto.AdminOrganizationListResponse} "获取成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 403 {object} Response "禁止访问"
+// @Router /api/v1/admin/organizations [get]
+func (h *AdminHandler) GetOrganizations(c *gin.Context) {
+	var req dto.AdminOrganizationQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.organizationService.ListOrganizations(c.Request.Context(), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 管理
+// @Summary 转交组织
+// @Description 指定用户成为组织唯一的管理员（不是成员时自动加入），原有管理员降为普通成员（仅平台管理员可操作）
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param org_name path string true "组织名称"
+// @Param request body dto.TransferOrganizationRequest true "接收组织的用户"
+// @Success 200 {object} Response "转交成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "组织或用户不存在"
+// @Router /api/v1/admin/organizations/{org_name}/transfer [post]
+func (h *AdminHandler) TransferOrganization(c *gin.Context) {
+	var req dto.TransferOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	org, err := h.organizationService.GetOrgByName(c.Request.Context(), c.Param("org_name"))
+	if err != nil {
+		SendError(c, http.StatusNotFound, "组织不存在")
+		return
+	}
+
+	if err := h.organizationService.TransferOrganization(c.Request.Context(), org.ID, req.Username); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "转交成功", nil)
+}
+
+// @Tags 管理
+// @Summary 删除组织
+// @Description 删除任意组织（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param org_name path string true "组织名称"
+// @Success 200 {object} Response "删除成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "组织不存在"
+// @Router /api/v1/admin/organizations/{org_name} [delete]
+func (h *AdminHandler) DeleteOrganization(c *gin.Context) {
+	org, err := h.organizationService.GetOrgByName(c.Request.Context(), c.Param("org_name"))
+	if err != nil {
+		SendError(c, http.StatusNotFound, "组织不存在")
+		return
+	}
+
+	if err := h.organizationService.DeleteOrganization(c.Request.Context(), org.ID); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "删除成功", nil)
+}
+
+// @Tags 管理
+// @Summary 登记 OIDC 应用
+// @Description 登记使用 ddup 登录的应用，client secret 只在创建时返回一次（仅平台管理员可操作）
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.CreateOIDCClientRequest true "应用信息"
+// @Success 200 {object} Response{data=dto.CreateOIDCClientResponse} "创建成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 403 {object} Response "禁止访问"
+// @Router /api/v1/admin/oidc/clients [post]
+func (h *AdminHandler) CreateOIDCClient(c *gin.Context) {
+	var req dto.CreateOIDCClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.CreateOIDCClient(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "创建成功", resp)
+}
+
+// @Tags 管理
+// @Summary 获取 OIDC 应用列表
+// @Description 获取已登记的应用（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]dto.OIDCClientResponse} "获取成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Router /api/v1/admin/oidc/clients [get]
+func (h *AdminHandler) GetOIDCClients(c *gin.Context) {
+	clients, err := h.userService.ListOIDCClients(c.Request.Context())
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", clients)
+}
+
+// @Tags 管理
+// @Summary 删除 OIDC 应用
+// @Description 删除后所有用户对该应用的授权及其持有的令牌立即失效（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param client_id path string true "应用 client id"
+// @Success 200 {object} Response "删除成功"
+// @Failure 403 {object} Response "禁止访问"
+// @Failure 404 {object} Response "应用不存在"
+// @Router /api/v1/admin/oidc/clients/{client_id} [delete]
+func (h *AdminHandler) DeleteOIDCClient(c *gin.Context) {
+	if err := h.userService.DeleteOIDCClient(c.Request.Context(), c.Param("client_id")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "删除成功", nil)
+}
+
+// @Tags 管理
+// @Summary 代登录
+// @Description 以目标用户的身份签发短时效令牌，用于排查用户遇到的问题。令牌带有 act 声明，期间的每个请求都会记入审计日志，且不能修改密码、两步验证和令牌（仅平台管理员可操作）
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param username path string true "用户名"
+// @Param request body dto.ImpersonateRequest true "代登录原因"
+// @Success 200 {object} Response{data=dto.ImpersonationResponse} "代登录成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 403 {object} Response "不能代登录平台管理员"
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/admin/users/{username}/impersonate [post]
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	var req dto.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.Impersonate(c.Request.Context(), c.GetUint("userID"), c.Param("username"), &req, clientInfo(c))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "代登录成功", resp)
+}
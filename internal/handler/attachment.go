@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	stderrors "errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead multipart 请求中文件内容以外的部分（边界、字段头等）允许的大小
+const multipartOverhead = 1 << 20
+
+// @Tags 个人资料
+// @Summary 获取附件列表
+// @Description 获取自己资料项的附件，下载地址带签名，过期后需重新获取
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Success 200 {object} Response{data=[]dto.AttachmentResponse}
+// @Failure 404 {object} Response "资料不存在"
+// @Router /api/v1/profiles/{id}/attachments [get]
+func (h *ProfileHandler) ListAttachments(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	attachments, err := h.service.ListAttachments(c.Request.Context(), c.GetUint("userID"), uint(profileID))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", attachments)
+}
+
+// @Tags 个人资料
+// @Summary 上传附件
+// @Description 以 multipart/form-data 上传附件，适合较小的文件，大文件请使用分片上传。文件类型按内容识别，只接受 UPLOAD_ALLOWED_TYPES 中的类型
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param file formData file true "附件"
+// @Success 200 {object} Response{data=dto.AttachmentResponse}
+// @Failure 404 {object} Response "资料不存在"
+// @Failure 413 {object} Response "文件过大或附件空间不足"
+// @Failure 415 {object} Response "不支持的文件类型"
+// @Router /api/v1/profiles/{id}/attachments [post]
+func (h *ProfileHandler) UploadAttachment(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	maxSize := config.GetConfig().Upload.MaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			SendError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("文件大小不能超过 %d 字节", maxSize))
+			return
+		}
+		SendError(c, http.StatusBadRequest, "请通过 file 字段上传文件")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.UploadAttachment(c.Request.Context(), c.GetUint("userID"), uint(profileID), header.Filename, header.Size, file)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "上传成功", attachment)
+}
+
+// @Tags 个人资料
+// @Summary 删除附件
+// @Description 删除自己资料项的附件，已发出的下载地址随之失效
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param attachment_id path uint true "附件ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "资料或附件不存在"
+// @Router /api/v1/profiles/{id}/attachments/{attachment_id} [delete]
+func (h *ProfileHandler) DeleteAttachment(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	if err := h.service.DeleteAttachment(c.Request.Context(), c.GetUint("userID"), uint(profileID), uint(attachmentID)); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "删除成功", nil)
+}
+
+// @Tags 个人资料
+// @Summary 发起分片上传
+// @Description 声明文件名和大小，返回上传 ID。随后按顺序用 PUT 上传各个分片，上传中断后可查询进度从 offset 处继续。未完成的上传在 UPLOAD_SESSION_TTL 后过期，期间文件大小计入附件空间
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param request body dto.CreateAttachmentUploadRequest true "文件信息"
+// @Success 200 {object} Response{data=dto.AttachmentUploadResponse}
+// @Failure 404 {object} Response "资料不存在"
+// @Failure 413 {object} Response "文件过大或附件空间不足"
+// @Router /api/v1/profiles/{id}/attachments/uploads [post]
+func (h *ProfileHandler) CreateUpload(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	var req dto.CreateAttachmentUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	upload, err := h.service.CreateUpload(c.Request.Context(), c.GetUint("userID"), uint(profileID), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "创建成功", upload)
+}
+
+// @Tags 个人资料
+// @Summary 查询分片上传进度
+// @Description 获取已接收的字节数，下一个分片从 offset 开始
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param upload_id path string true "上传ID"
+// @Success 200 {object} Response{data=dto.AttachmentUploadResponse}
+// @Failure 404 {object} Response "上传不存在或已过期"
+// @Router /api/v1/profiles/{id}/attachments/uploads/{upload_id} [get]
+func (h *ProfileHandler) GetUpload(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	upload, err := h.service.GetUpload(c.Request.Context(), c.GetUint("userID"), uint(profileID), c.Param("upload_id"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", upload)
+}
+
+// @Tags 个人资料
+// @Summary 上传分片
+// @Description 请求体为分片内容，Content-Range 格式为 bytes 起始-结束/文件大小（结束位置包含在内），分片必须从当前 offset 开始。第一个分片不少于 512 字节时即检查文件类型，最后一个分片上传完成后识别文件类型并创建附件，响应中 completed 为 true。类型不允许时上传随之删除，需要重新发起
+// @Accept application/octet-stream
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param upload_id path string true "上传ID"
+// @Param Content-Range header string true "分片范围，如 bytes 0-5242879/10485760"
+// @Success 200 {object} Response{data=dto.AttachmentUploadResponse}
+// @Failure 400 {object} Response "Content-Range 无效或与请求体长度不符"
+// @Failure 404 {object} Response "上传不存在或已过期"
+// @Failure 409 {object} Response "分片起始位置与 offset 不符"
+// @Failure 415 {object} Response "不支持的文件类型"
+// @Router /api/v1/profiles/{id}/attachments/uploads/{upload_id} [put]
+func (h *ProfileHandler) UploadChunk(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	start, end, total, ok := parseContentRange(c.GetHeader("Content-Range"))
+	if !ok {
+		SendError(c, http.StatusBadRequest, "无效的 Content-Range")
+		return
+	}
+	length := end - start + 1
+	if c.Request.ContentLength >= 0 && c.Request.ContentLength != length {
+		SendError(c, http.StatusBadRequest, "请求体长度与 Content-Range 不符")
+		return
+	}
+
+	upload, err := h.service.WriteUploadChunk(c.Request.Context(), c.GetUint("userID"), uint(profileID), c.Param("upload_id"), start, length, total, c.Request.Body)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "上传成功", upload)
+}
+
+// @Tags 个人资料
+// @Summary 取消分片上传
+// @Description 取消上传并删除已接收的内容，释放占用的附件空间
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param upload_id path string true "上传ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "上传不存在或已过期"
+// @Router /api/v1/profiles/{id}/attachments/uploads/{upload_id} [delete]
+func (h *ProfileHandler) CancelUpload(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	if err := h.service.CancelUpload(c.Request.Context(), c.GetUint("userID"), uint(profileID), c.Param("upload_id")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "取消成功", nil)
+}
+
+// @Tags 个人资料
+// @Summary 下载附件
+// @Description 使用资料项响应中带签名的地址下载附件，无需登录。地址只对获取它的访问者有效，过期或资料项对该访问者不再可见后失效
+// @Produce octet-stream
+// @Param id path uint true "附件ID"
+// @Param expires query int true "过期时间（Unix 秒）"
+// @Param viewer query int false "访问者用户ID"
+// @Param shared query bool false "是否通过分享链接获取"
+// @Param signature query string true "签名"
+// @Success 200 {file} file
+// @Failure 403 {object} Response "签名无效或已过期"
+// @Failure 404 {object} Response "附件不存在"
+// @Router /api/v1/attachments/{id} [get]
+func (h *ProfileHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	var query dto.AttachmentDownloadQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		SendError(c, http.StatusForbidden, "无效的下载链接")
+		return
+	}
+
+	attachment, body, err := h.service.OpenAttachment(c.Request.Context(), uint(attachmentID), &query)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer body.Close()
+
+	// 图片直接在浏览器中显示，其他类型一律作为下载，避免上传的文件在 API 域名下被当作页面打开
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.MimeType, "image/") {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-cache",
+	})
+}
+
+// parseContentRange 解析 bytes start-end/total 格式的 Content-Range
+func parseContentRange(header string) (start, end, total int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	startPart, endPart, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	var err error
+	if start, err = strconv.ParseInt(startPart, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if end, err = strconv.ParseInt(endPart, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if start < 0 || end < start || end >= total {
+		return 0, 0, 0, false
+	}
+	return start, end, total, true
+}
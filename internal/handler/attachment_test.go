@@ -0,0 +1,31 @@
+package handler
+
+import "testing"
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header            string
+		start, end, total int64
+		ok                bool
+	}{
+		{"bytes 0-99/1000", 0, 99, 1000, true},
+		{"bytes 900-999/1000", 900, 999, 1000, true},
+		{"bytes 0-0/1", 0, 0, 1, true},
+		{"bytes 0-1000/1000", 0, 0, 0, false}, // 结束位置包含在内，不能等于文件大小
+		{"bytes 100-99/1000", 0, 0, 0, false},
+		{"bytes -1-99/1000", 0, 0, 0, false},
+		{"bytes 0-99/*", 0, 0, 0, false},
+		{"bytes */1000", 0, 0, 0, false},
+		{"bytes 0-99", 0, 0, 0, false},
+		{"items 0-99/1000", 0, 0, 0, false},
+		{"0-99/1000", 0, 0, 0, false},
+		{"", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, total, ok := parseContentRange(tt.header)
+		if start != tt.start || end != tt.end || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %d, %v, want %d, %d, %d, %v",
+				tt.header, start, end, total, ok, tt.start, tt.end, tt.total, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags 用户
+// @Summary 获取安全日志
+// @Description 获取与当前用户相关的安全事件（登录、退出、密码修改、令牌撤销、两步验证变更、组织成员变更等），最新的在前
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码，从 1 开始"
+// @Param pageSize query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} Response{data=dto.AuditEventListResponse} "获取成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/security-log [get]
+func (h *UserHandler) GetSecurityLog(c *gin.Context) {
+	var req dto.PageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.ListSecurityLog(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 管理
+// @Summary 查询审计日志
+// @Description 按条件查询全平台的安全审计事件，最新的在前（仅平台管理员可操作）
+// @Produce json
+// @Security Bearer
+// @Param userId query int false "受影响的用户 ID"
+// @Param actorId query int false "执行操作的用户 ID"
+// @Param organizationId query int false "组织 ID"
+// @Param action query string false "事件类型，如 login.failure"
+// @Param ip query string false "客户端 IP"
+// @Param requestId query string false "请求 ID"
+// @Param since query string false "起始时间（RFC 3339，包含）"
+// @Param until query string false "结束时间（RFC 3339，不包含）"
+// @Param page query int false "页码，从 1 开始"
+// @Param pageSize query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} Response{data=dto.AuditEventListResponse} "获取成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 403 {object} Response "禁止访问"
+// @Router /api/v1/admin/audit-events [get]
+func (h *AdminHandler) GetAuditEvents(c *gin.Context) {
+	var req dto.AuditEventQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.ListAuditEvents(c.Request.Context(), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 用户
+// @Summary 获取登录记录
+// @Description 获取当前用户的登录记录（时间、IP、设备、是否成功、是否为新设备），最新的在前
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码，从 1 开始"
+// @Param pageSize query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} Response{data=dto.LoginHistoryListResponse} "获取成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/login-history [get]
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	var req dto.PageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.ListLoginHistory(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
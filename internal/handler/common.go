@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +31,30 @@ func SendError(c *gin.Context, status int, message string) {
 	})
 }
 
+// SendAppError 应用错误按其自带的状态码响应，其他错误使用 status，字段级错误放在 data 中返回
+func SendAppError(c *gin.Context, status int, err error) {
+	if appErr, ok := err.(*errors.AppError); ok {
+		if len(appErr.Fields) > 0 {
+			c.JSON(appErr.Code, Response{
+				Code:    appErr.Code,
+				Message: appErr.Message,
+				Data:    appErr.Fields,
+			})
+			return
+		}
+		status = appErr.Code
+	}
+	SendError(c, status, err.Error())
+}
+
+// clientInfo 从请求中提取客户端信息
+func clientInfo(c *gin.Context) *dto.ClientInfo {
+	return &dto.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		ClientIP:  c.ClientIP(),
+	}
+}
+
 // TokenInfo Token详细信息
 type TokenInfo struct {
 	Token     string    `json:"token"`     // JWT token
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"ddup-apis/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct{}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// GetJWKS godoc
+// @Summary JWT 验证公钥
+// @Description 以 JWKS 格式返回验证 access token 所需的公钥，其他服务可据此按 kid 验证令牌，无需共享密钥
+// @Tags 系统
+// @Produce json
+// @Success 200 {object} utils.JSONWebKeySet "公钥列表"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags 认证
+// @Summary 发送登录链接
+// @Description 向已验证的邮箱发送一次性登录链接，无论邮箱是否注册都返回成功。按邮箱和 IP 限制发送频率
+// @Accept json
+// @Produce json
+// @Param request body dto.MagicLinkRequest true "邮箱"
+// @Success 200 {object} Response "发送成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 429 {object} Response "请求过于频繁"
+// @Router /api/v1/auth/magic-link [post]
+func (h *UserHandler) SendMagicLink(c *gin.Context) {
+	var req dto.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.SendMagicLink(c.Request.Context(), &req, clientInfo(c)); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "如果该邮箱已注册并验证，登录链接已发送", nil)
+}
+
+// @Tags 认证
+// @Summary 邮件链接登录
+// @Description 使用邮件中的登录令牌登录，令牌只能使用一次。开启两步验证的账号返回 challengeToken
+// @Accept json
+// @Produce json
+// @Param request body dto.MagicLinkLoginRequest true "登录令牌"
+// @Success 200 {object} Response{data=dto.LoginResponse} "登录成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "登录链接无效或已过期"
+// @Router /api/v1/auth/login/magic-link [post]
+func (h *UserHandler) LoginMagicLink(c *gin.Context) {
+	var req dto.MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	client := clientInfo(c)
+	client.DeviceName = req.DeviceName
+
+	resp, err := h.userService.LoginWithMagicLink(c.Request.Context(), &req, client)
+	if err != nil {
+		SendAppError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	SendSuccess(c, "登录成功", resp)
+}
+
+// @Tags 用户
+// @Summary 关闭密码登录
+// @Description 关闭后只能通过邮件链接或第三方账号登录，需要已验证的邮箱。重新设置密码即可恢复
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.DisablePasswordRequest true "当前密码"
+// @Success 200 {object} Response "关闭成功"
+// @Failure 400 {object} Response "密码错误或邮箱未验证"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/password [delete]
+func (h *UserHandler) DisablePassword(c *gin.Context) {
+	var req dto.DisablePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.DisablePassword(c.Request.Context(), c.GetUint("userID"), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "已关闭密码登录", nil)
+}
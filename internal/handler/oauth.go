@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags 认证
+// @Summary 获取第三方登录方式
+// @Description 获取已配置的第三方登录提供方
+// @Produce json
+// @Success 200 {object} Response{data=[]dto.OAuthProviderResponse} "获取成功"
+// @Router /api/v1/auth/oauth/providers [get]
+func (h *UserHandler) GetOAuthProviders(c *gin.Context) {
+	SendSuccess(c, "获取成功", h.userService.ListOAuthProviders())
+}
+
+// @Tags 认证
+// @Summary 发起第三方登录
+// @Description 返回第三方授权地址，前端跳转授权后携带 code 和 state 调用回调接口
+// @Produce json
+// @Param provider path string true "登录提供方"
+// @Success 200 {object} Response{data=dto.OAuthAuthorizeResponse} "获取成功"
+// @Failure 404 {object} Response "不支持的登录方式"
+// @Router /api/v1/auth/oauth/{provider}/authorize [get]
+func (h *UserHandler) OAuthAuthorize(c *gin.Context) {
+	resp, err := h.userService.OAuthAuthorize(c.Request.Context(), c.Param("provider"), model.OAuthPurposeLogin, 0)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 认证
+// @Summary 第三方登录回调
+// @Description 使用授权码完成第三方登录，首次登录时自动创建账号。开启两步验证的账号返回 challengeToken
+// @Accept json
+// @Produce json
+// @Param provider path string true "登录提供方"
+// @Param request body dto.OAuthCallbackRequest true "授权结果"
+// @Success 200 {object} Response{data=dto.LoginResponse} "登录成功"
+// @Failure 400 {object} Response "授权请求无效或已过期"
+// @Failure 401 {object} Response "第三方登录验证失败"
+// @Failure 409 {object} Response "邮箱已被其他账号使用"
+// @Router /api/v1/auth/oauth/{provider}/callback [post]
+func (h *UserHandler) OAuthCallback(c *gin.Context) {
+	var req dto.OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	client := clientInfo(c)
+	client.DeviceName = req.DeviceName
+
+	resp, err := h.userService.OAuthLogin(c.Request.Context(), c.Param("provider"), &req, client)
+	if err != nil {
+		SendAppError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	SendSuccess(c, "登录成功", resp)
+}
+
+// @Tags 用户
+// @Summary 获取绑定的第三方账号
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]dto.IdentityResponse} "获取成功"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/identities [get]
+func (h *UserHandler) GetIdentities(c *gin.Context) {
+	identities, err := h.userService.ListIdentities(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", identities)
+}
+
+// @Tags 用户
+// @Summary 发起绑定第三方账号
+// @Description 返回第三方授权地址，授权后调用绑定回调接口完成绑定
+// @Produce json
+// @Security Bearer
+// @Param provider path string true "登录提供方"
+// @Success 200 {object} Response{data=dto.OAuthAuthorizeResponse} "获取成功"
+// @Failure 401 {object} Response "未授权"
+// @Failure 404 {object} Response "不支持的登录方式"
+// @Router /api/v1/users/identities/{provider}/authorize [post]
+func (h *UserHandler) LinkIdentityAuthorize(c *gin.Context) {
+	resp, err := h.userService.OAuthAuthorize(c.Request.Context(), c.Param("provider"), model.OAuthPurposeLink, c.GetUint("userID"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 用户
+// @Summary 绑定第三方账号
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param provider path string true "登录提供方"
+// @Param request body dto.OAuthCallbackRequest true "授权结果"
+// @Success 200 {object} Response{data=dto.IdentityResponse} "绑定成功"
+// @Failure 400 {object} Response "授权请求无效或已过期"
+// @Failure 401 {object} Response "未授权"
+// @Failure 409 {object} Response "该第三方账号已绑定其他用户"
+// @Router /api/v1/users/identities/{provider}/callback [post]
+func (h *UserHandler) LinkIdentityCallback(c *gin.Context) {
+	var req dto.OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.LinkIdentity(c.Request.Context(), c.GetUint("userID"), c.Param("provider"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "绑定成功", resp)
+}
+
+// @Tags 用户
+// @Summary 解除绑定第三方账号
+// @Description 既没有密码也没有已验证邮箱的账号不能解除最后一个绑定
+// @Produce json
+// @Security Bearer
+// @Param id path int true "绑定ID"
+// @Success 200 {object} Response "解除成功"
+// @Failure 400 {object} Response "请先设置密码或验证邮箱"
+// @Failure 401 {object} Response "未授权"
+// @Failure 404 {object} Response "绑定账号不存在"
+// @Router /api/v1/users/identities/{id} [delete]
+func (h *UserHandler) UnlinkIdentity(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的绑定ID")
+		return
+	}
+
+	if err := h.userService.UnlinkIdentity(c.Request.Context(), c.GetUint("userID"), uint(id)); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "解除成功", nil)
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/service"
+	"ddup-apis/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCHandler ddup 作为 OpenID Connect 提供方的端点。发现文档、令牌和 userinfo 端点供其他应用调用，
+// 响应格式遵循 OAuth 2.0 / OIDC 规范而不是统一的 Response 结构
+type OIDCHandler struct {
+	userService service.IUserService
+}
+
+func NewOIDCHandler(userService service.IUserService) *OIDCHandler {
+	return &OIDCHandler{userService: userService}
+}
+
+// @Tags OIDC
+// @Summary OIDC 发现文档
+// @Description 返回授权、令牌、userinfo 端点和公钥地址，应用据此接入 ddup 登录
+// @Produce json
+// @Success 200 {object} map[string]interface{} "发现文档"
+// @Router /.well-known/openid-configuration [get]
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	cfg := config.GetConfig()
+	issuer := cfg.OIDC.Issuer
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                issuer,
+		"authorization_endpoint":                cfg.OIDC.AuthorizeURL,
+		"token_endpoint":                        issuer + "/api/v1/oidc/token",
+		"userinfo_endpoint":                     issuer + "/api/v1/oidc/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{utils.SigningAlgorithm()},
+		"scopes_supported":                      model.OIDCScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "azp",
+			"preferred_username", "nickname", "name", "picture", "gender", "birthdate", "locale", "website", "updated_at",
+			"email", "email_verified",
+		},
+	})
+}
+
+// @Tags OIDC
+// @Summary 校验授权请求
+// @Description 前端授权确认页面收到应用的授权请求后，携带原始参数调用此接口获取应用信息和是否需要用户确认
+// @Produce json
+// @Security Bearer
+// @Param client_id query string true "应用 client id"
+// @Param redirect_uri query string true "回调地址"
+// @Param response_type query string true "固定为 code"
+// @Param scope query string true "申请的 scope，必须包含 openid"
+// @Param state query string false "state"
+// @Param nonce query string false "nonce"
+// @Param code_challenge query string false "PKCE challenge"
+// @Param code_challenge_method query string false "固定为 S256"
+// @Success 200 {object} Response{data=dto.OIDCAuthorizeResponse} "获取成功"
+// @Failure 400 {object} Response "无效的授权请求"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/oidc/authorize [get]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req dto.OIDCAuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的授权请求")
+		return
+	}
+
+	resp, err := h.userService.OIDCAuthorize(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags OIDC
+// @Summary 确认授权
+// @Description 用户确认或拒绝授权，前端随后跳转到返回的回调地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.OIDCApproveRequest true "授权请求及用户的选择"
+// @Success 200 {object} Response{data=dto.OIDCRedirectResponse} "操作成功"
+// @Failure 400 {object} Response "无效的授权请求"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/oidc/authorize [post]
+func (h *OIDCHandler) Approve(c *gin.Context) {
+	var req dto.OIDCApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的授权请求")
+		return
+	}
+
+	resp, err := h.userService.OIDCApprove(c.Request.Context(), c.GetUint("userID"), c.GetUint("sessionID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	SendSuccess(c, "操作成功", resp)
+}
+
+// @Tags OIDC
+// @Summary 令牌端点
+// @Description 应用使用授权码或 refresh token 换取令牌，客户端可通过 HTTP Basic 或表单参数认证
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code 或 refresh_token"
+// @Param code formData string false "授权码"
+// @Param redirect_uri formData string false "授权时使用的回调地址"
+// @Param code_verifier formData string false "PKCE verifier"
+// @Param refresh_token formData string false "refresh token"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200 {object} dto.OIDCTokenResponse "令牌"
+// @Failure 400 {object} map[string]string "error/error_description"
+// @Failure 401 {object} map[string]string "error/error_description"
+// @Router /api/v1/oidc/token [post]
+func (h *OIDCHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var req dto.OIDCTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		sendOIDCError(c, &service.OIDCError{Status: http.StatusBadRequest, Code: "invalid_request", Description: "缺少 grant_type"})
+		return
+	}
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+
+	resp, err := h.userService.OIDCToken(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		sendOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Tags OIDC
+// @Summary userinfo 端点
+// @Description 应用使用 access token 获取用户信息，返回的 claims 取决于授权的 scope
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} map[string]interface{} "用户信息"
+// @Failure 401 {object} map[string]string "error/error_description"
+// @Router /api/v1/oidc/userinfo [get]
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+
+	claims, err := h.userService.OIDCUserInfo(c.Request.Context(), token)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		sendOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
+
+func sendOIDCError(c *gin.Context, err error) {
+	oidcErr, ok := err.(*service.OIDCError)
+	if !ok {
+		oidcErr = &service.OIDCError{Status: http.StatusInternalServerError, Code: "server_error", Description: "服务器内部错误"}
+	}
+	c.JSON(oidcErr.Status, gin.H{
+		"error":             oidcErr.Code,
+		"error_description": oidcErr.Description,
+	})
+}
+
+// @Tags 用户
+// @Summary 获取已授权的应用
+// @Description 获取当前用户通过 ddup 登录授权过的应用
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]dto.OIDCConsentResponse} "获取成功"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/oidc/consents [get]
+func (h *UserHandler) GetOIDCConsents(c *gin.Context) {
+	consents, err := h.userService.ListOIDCConsents(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", consents)
+}
+
+// @Tags 用户
+// @Summary 撤销应用授权
+// @Description 撤销后该应用持有的令牌立即失效，下次登录需要重新确认授权
+// @Produce json
+// @Security Bearer
+// @Param client_id path string true "应用 client id"
+// @Success 200 {object} Response "撤销成功"
+// @Failure 401 {object} Response "未授权"
+// @Failure 404 {object} Response "未授权该应用"
+// @Router /api/v1/users/oidc/consents/{client_id} [delete]
+func (h *UserHandler) RevokeOIDCConsent(c *gin.Context) {
+	if err := h.userService.RevokeOIDCConsent(c.Request.Context(), c.GetUint("userID"), c.Param("client_id")); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "撤销成功", nil)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
 	"ddup-apis/internal/service"
 	"net/http"
 	"strconv"
@@ -25,6 +26,7 @@ func NewProfileHandler(service *service.ProfileService) *ProfileHandler {
 // @Security Bearer
 // @Param request body dto.CreateProfileRequest true "个人资料信息"
 // @Success 200 {object} Response
+// @Failure 400 {object} Response{data=[]errors.FieldError} "资料类型不支持或元数据不符合 schema"
 // @Router /api/v1/profiles [post]
 func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 	var req dto.CreateProfileRequest
@@ -35,7 +37,7 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 
 	userID := c.GetUint("userID")
 	if err := h.service.Create(c.Request.Context(), userID, &req); err != nil {
-		SendError(c, http.StatusInternalServerError, err.Error())
+		SendAppError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -72,6 +74,8 @@ func (h *ProfileHandler) GetProfiles(c *gin.Context) {
 // @Param id path uint true "资料ID"
 // @Param request body dto.UpdateProfileRequest true "更新信息"
 // @Success 200 {object} Response
+// @Failure 400 {object} Response{data=[]errors.FieldError} "元数据不符合 schema"
+// @Failure 404 {object} Response "资料不存在"
 // @Router /api/v1/profiles/{id} [put]
 func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	var req dto.UpdateProfileRequest
@@ -88,13 +92,52 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	if err := h.service.Update(c.Request.Context(), userID, uint(profileID), &req); err != nil {
-		SendError(c, http.StatusInternalServerError, err.Error())
+		SendAppError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	SendSuccess(c, "更新成功", nil)
 }
 
+// @Tags 个人资料
+// @Summary 部分更新个人资料
+// @Description 按 RFC 7396 JSON Merge Patch 修改资料项：省略的字段保持不变，值为 null 的字段被清空，metadata 按对象逐层合并（其中值为 null 的键被删除）。title 和 visibility 不能清空
+// @Accept application/merge-patch+json
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param request body dto.PatchProfileRequest true "合并补丁"
+// @Success 200 {object} Response{data=dto.ProfileResponse}
+// @Failure 400 {object} Response{data=[]errors.FieldError} "字段类型错误、不存在或不能清空，或元数据不符合 schema"
+// @Failure 404 {object} Response "资料不存在"
+// @Failure 415 {object} Response "不支持的 Content-Type"
+// @Router /api/v1/profiles/{id} [patch]
+func (h *ProfileHandler) PatchProfile(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		SendError(c, http.StatusUnsupportedMediaType, "请使用 application/merge-patch+json")
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	profile, err := h.service.Patch(c.Request.Context(), c.GetUint("userID"), uint(profileID), patch)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "更新成功", profile)
+}
+
 // @Tags 个人资料
 // @Summary 删除个人资料
 // @Description 删除指定的个人资料项
@@ -143,3 +186,188 @@ func (h *ProfileHandler) UpdateDisplayOrder(c *gin.Context) {
 
 	SendSuccess(c, "更新显示顺序成功", nil)
 }
+
+// @Tags 个人资料
+// @Summary 获取公开主页
+// @Description 获取用户的基本信息和访问者可见的资料项，按资料类型分组并按显示顺序排列，无需登录。未登录时只返回公开资料项；登录后还包括同组织成员可见和指定访问者可见的资料项；本人访问时（登录会话或带 profile:read 权限的访问令牌）返回所有资料项。unlisted 资料项只能通过分享链接访问
+// @Produce json
+// @Param username path string true "用户名"
+// @Success 200 {object} Response{data=dto.PublicProfileResponse}
+// @Failure 404 {object} Response "用户不存在"
+// @Router /api/v1/u/{username} [get]
+func (h *ProfileHandler) GetPublicProfile(c *gin.Context) {
+	viewerID := c.GetUint("userID")
+	// 没有 profile:read 权限的访问令牌按匿名访问处理
+	if scopes, ok := c.Get("scopes"); ok && !model.HasScope(scopes.([]model.TokenScope), model.ScopeProfileRead) {
+		viewerID = 0
+	}
+
+	profile, err := h.service.GetPublicProfile(c.Request.Context(), c.Param("username"), viewerID)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", profile)
+}
+
+// @Tags 个人资料
+// @Summary 生成分享链接
+// @Description 为可见性为 unlisted 的资料项生成分享链接，旧链接随之失效。令牌只在本次响应中返回
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param request body dto.CreateProfileShareRequest false "过期时间"
+// @Success 200 {object} Response{data=dto.ProfileShareResponse}
+// @Failure 400 {object} Response "资料项不是 unlisted"
+// @Failure 404 {object} Response "资料不存在"
+// @Router /api/v1/profiles/{id}/share [post]
+func (h *ProfileHandler) CreateShareLink(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	var req dto.CreateProfileShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			SendError(c, http.StatusBadRequest, "无效的请求参数")
+			return
+		}
+	}
+
+	share, err := h.service.CreateShareLink(c.Request.Context(), c.GetUint("userID"), uint(profileID), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "生成成功", share)
+}
+
+// @Tags 个人资料
+// @Summary 撤销分享链接
+// @Description 撤销资料项的分享链接
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "资料不存在"
+// @Router /api/v1/profiles/{id}/share [delete]
+func (h *ProfileHandler) RevokeShareLink(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	if err := h.service.RevokeShareLink(c.Request.Context(), c.GetUint("userID"), uint(profileID)); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "撤销成功", nil)
+}
+
+// @Tags 个人资料
+// @Summary 通过分享链接查看资料
+// @Description 通过分享令牌查看 unlisted 资料项，无需登录
+// @Produce json
+// @Param token path string true "分享令牌"
+// @Success 200 {object} Response{data=dto.SharedProfileResponse}
+// @Failure 404 {object} Response "分享链接无效或已过期"
+// @Router /api/v1/shared/{token} [get]
+func (h *ProfileHandler) GetSharedProfile(c *gin.Context) {
+	profile, err := h.service.GetShared(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", profile)
+}
+
+// @Tags 个人资料
+// @Summary 获取可见用户
+// @Description 获取资料项的可见用户名单，名单只在可见性为 audience 时生效
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Success 200 {object} Response{data=dto.ProfileAudienceResponse}
+// @Failure 404 {object} Response "资料不存在"
+// @Router /api/v1/profiles/{id}/audience [get]
+func (h *ProfileHandler) GetAudience(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	audience, err := h.service.GetAudience(c.Request.Context(), c.GetUint("userID"), uint(profileID))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", audience)
+}
+
+// @Tags 个人资料
+// @Summary 设置可见用户
+// @Description 用给定的用户名替换资料项的可见用户名单，最多 100 人；用户名不存在时返回字段级错误
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "资料ID"
+// @Param request body dto.ProfileAudienceRequest true "可见用户"
+// @Success 200 {object} Response{data=dto.ProfileAudienceResponse}
+// @Failure 400 {object} Response{data=[]errors.FieldError} "用户不存在"
+// @Failure 404 {object} Response "资料不存在"
+// @Router /api/v1/profiles/{id}/audience [put]
+func (h *ProfileHandler) SetAudience(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+	var req dto.ProfileAudienceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	audience, err := h.service.SetAudience(c.Request.Context(), c.GetUint("userID"), uint(profileID), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "设置成功", audience)
+}
+
+// @Tags 个人资料
+// @Summary 获取元数据 schema
+// @Description 获取各资料类型 metadata 的 JSON Schema，供前端渲染表单。不指定类型时返回每种类型当前使用的版本；指定类型时返回该类型的所有版本
+// @Produce json
+// @Security Bearer
+// @Param type query string false "资料类型"
+// @Param version query int false "schema 版本，需同时指定类型"
+// @Success 200 {object} Response{data=[]dto.ProfileSchemaResponse}
+// @Failure 404 {object} Response "schema 不存在"
+// @Router /api/v1/profiles/schemas [get]
+func (h *ProfileHandler) GetSchemas(c *gin.Context) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil || version < 0 || (version != 0 && c.Query("type") == "") {
+		SendError(c, http.StatusBadRequest, "无效的版本参数")
+		return
+	}
+
+	schemas, err := h.service.ListSchemas(c.Query("type"), version)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", schemas)
+}
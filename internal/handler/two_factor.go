@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"ddup-apis/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags 认证
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的 challenge_token 和 TOTP 验证码（或恢复码）完成登录
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginTwoFactorRequest true "两步验证信息"
+// @Success 200 {object} Response{data=dto.LoginResponse} "登录成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "验证码错误或验证已过期"
+// @Failure 423 {object} Response "账号已锁定"
+// @Router /api/v1/auth/login/2fa [post]
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var req dto.LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.LoginTwoFactor(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		SendAppError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	SendSuccess(c, "登录成功", resp)
+}
+
+// @Tags 用户
+// @Summary 获取两步验证密钥
+// @Description 生成 TOTP 密钥和二维码，使用认证器扫码后调用确认接口开启两步验证
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=dto.TwoFactorSetupResponse} "获取成功"
+// @Failure 400 {object} Response "已开启两步验证"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/2fa/setup [post]
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	resp, err := h.userService.SetupTwoFactor(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "获取成功", resp)
+}
+
+// @Tags 用户
+// @Summary 开启两步验证
+// @Description 提交认证器生成的验证码确认绑定，返回的恢复码只显示一次
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.TwoFactorConfirmRequest true "验证码"
+// @Success 200 {object} Response{data=dto.RecoveryCodesResponse} "开启成功"
+// @Failure 400 {object} Response "验证码错误"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/2fa/confirm [post]
+func (h *UserHandler) ConfirmTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.ConfirmTwoFactor(c.Request.Context(), c.GetUint("userID"), &req)
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "开启成功", resp)
+}
+
+// @Tags 用户
+// @Summary 关闭两步验证
+// @Description 验证密码后关闭两步验证，同时删除全部恢复码。未设置密码的账号改为验证验证码或恢复码
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body dto.TwoFactorDisableRequest true "当前密码或验证码"
+// @Success 200 {object} Response "关闭成功"
+// @Failure 400 {object} Response "密码或验证码错误"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(c.Request.Context(), c.GetUint("userID"), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "关闭成功", nil)
+}
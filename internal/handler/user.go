@@ -4,6 +4,7 @@ import (
 	"ddup-apis/internal/dto"
 	"ddup-apis/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +24,7 @@ func NewUserHandler(userService service.IUserService) *UserHandler {
 // @Produce json
 // @Param request body dto.RegisterRequest true "注册信息"
 // @Success 200 {object} Response "注册成功"
-// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 400 {object} Response{data=[]errors.FieldError} "无效的请求参数或密码不符合安全要求"
 // @Router /api/v1/auth/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
@@ -33,7 +34,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 	}
 
 	if err := h.userService.Register(c.Request.Context(), &req); err != nil {
-		SendError(c, http.StatusInternalServerError, err.Error())
+		SendAppError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -48,6 +49,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Param request body dto.LoginRequest true "登录信息"
 // @Success 200 {object} Response{data=dto.LoginResponse} "登录成功"
 // @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "用户名或密码错误"
+// @Failure 423 {object} Response "账号已锁定"
 // @Router /api/v1/auth/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req dto.LoginRequest
@@ -56,15 +59,116 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userService.Login(c.Request.Context(), &req)
+	client := clientInfo(c)
+	client.DeviceName = req.DeviceName
+
+	resp, err := h.userService.Login(c.Request.Context(), &req, client)
 	if err != nil {
-		SendError(c, http.StatusUnauthorized, err.Error())
+		SendAppError(c, http.StatusUnauthorized, err)
 		return
 	}
 
 	SendSuccess(c, "登录成功", resp)
 }
 
+// @Tags 认证
+// @Summary 刷新Token
+// @Description 使用 refresh token 换取新的 access token 和 refresh token，旧的 refresh token 随即失效
+// @Accept json
+// @Produce json
+// @Param request body dto.RefreshTokenRequest true "refresh token"
+// @Success 200 {object} Response{data=dto.TokenResponse} "刷新成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 401 {object} Response "refresh token 无效或已失效"
+// @Router /api/v1/auth/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	resp, err := h.userService.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		SendError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	SendSuccess(c, "刷新成功", resp)
+}
+
+// @Tags 认证
+// @Summary 忘记密码
+// @Description 向账号邮箱发送密码重置链接，无论邮箱是否注册都返回成功
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "邮箱"
+// @Success 200 {object} Response "发送成功"
+// @Failure 400 {object} Response "无效的请求参数"
+// @Router /api/v1/auth/password/forgot [post]
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.ForgotPassword(c.Request.Context(), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "如果该邮箱已注册，重置链接已发送", nil)
+}
+
+// @Tags 认证
+// @Summary 重置密码
+// @Description 使用邮件中的重置令牌设置新密码，成功后该账号所有设备需要重新登录
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "重置信息"
+// @Success 200 {object} Response "重置成功"
+// @Failure 400 {object} Response{data=[]errors.FieldError} "重置链接无效或已过期，或密码不符合安全要求"
+// @Router /api/v1/auth/password/reset [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "密码重置成功", nil)
+}
+
+// @Tags 认证
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的令牌确认邮箱，待验证的账号随即恢复正常
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "验证令牌"
+// @Success 200 {object} Response "验证成功"
+// @Failure 400 {object} Response "验证链接无效或已过期"
+// @Router /api/v1/auth/email/verify [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		SendError(c, http.StatusBadRequest, "无效的请求参数")
+		return
+	}
+
+	if err := h.userService.VerifyEmail(c.Request.Context(), &req); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "邮箱验证成功", nil)
+}
+
 // @Tags 认证
 // @Summary 退出登录
 // @Description 用户退出登录
@@ -114,7 +218,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 // @Tags 用户
 // @Summary 更新用户信息
-// @Description 更新用户基本信息
+// @Description 更新用户基本信息，修改邮箱后需要重新验证
 // @Accept json
 // @Produce json
 // @Security Bearer
@@ -137,7 +241,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	if err := h.userService.UpdateUser(c.Request.Context(), userID.(uint), &req); err != nil {
-		SendError(c, http.StatusInternalServerError, err.Error())
+		SendAppError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -146,13 +250,13 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 // @Tags 用户
 // @Summary 修改密码
-// @Description 修改用户密码
+// @Description 修改用户密码，未设置密码的账号（第三方登录创建或已关闭密码）无需提供旧密码
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param request body dto.ChangePasswordRequest true "密码信息"
 // @Success 200 {object} Response "修改成功"
-// @Failure 400 {object} Response "无效的请求参数"
+// @Failure 400 {object} Response{data=[]errors.FieldError} "旧密码错误或新密码不符合安全要求"
 // @Failure 401 {object} Response "未授权"
 // @Router /api/v1/users/password [put]
 func (h *UserHandler) ChangePassword(c *gin.Context) {
@@ -169,7 +273,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.userService.ChangePassword(c.Request.Context(), userID.(uint), &req); err != nil {
-		SendError(c, http.StatusInternalServerError, err.Error())
+		SendAppError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -177,11 +281,32 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 }
 
 // @Tags 用户
-// @Summary 删除用户
-// @Description 删除用户账号
+// @Summary 重新发送验证邮件
+// @Description 向当前用户的邮箱重新发送验证邮件
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response "发送成功"
+// @Failure 400 {object} Response "邮箱已验证或未设置"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/email/verification [post]
+func (h *UserHandler) SendEmailVerification(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	if err := h.userService.SendEmailVerification(c.Request.Context(), userID); err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "验证邮件已发送", nil)
+}
+
+// @Tags 用户
+// @Summary 注销账号
+// @Description 注销账号并立即结束所有会话、删除访问令牌。宽限期结束后彻底删除账号、个人资料和组织成员关系并释放用户名，宽限期内重新登录可恢复账号（取决于配置）
 // @Produce json
 // @Security Bearer
-// @Success 200 {object} Response "删除成功"
+// @Success 200 {object} Response{data=dto.AccountDeletionResponse} "注销成功"
+// @Failure 400 {object} Response "账号已注销或为最后一个平台管理员"
 // @Failure 401 {object} Response "未授权"
 // @Router /api/v1/users [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
@@ -191,10 +316,78 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(c.Request.Context(), userID.(uint)); err != nil {
+	resp, err := h.userService.DeleteUser(c.Request.Context(), userID.(uint))
+	if err != nil {
+		SendAppError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	SendSuccess(c, "注销成功", resp)
+}
+
+// @Tags 用户
+// @Summary 获取登录设备列表
+// @Description 获取当前用户所有有效的登录会话
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]dto.SessionResponse} "获取成功"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/sessions [get]
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID := c.GetUint("userID")
+	sessionID := c.GetUint("sessionID")
+
+	sessions, err := h.userService.ListSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		SendError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	SendSuccess(c, "获取成功", sessions)
+}
+
+// @Tags 用户
+// @Summary 注销指定会话
+// @Description 注销当前用户的某个登录会话，该设备需要重新登录
+// @Produce json
+// @Security Bearer
+// @Param id path uint true "会话ID"
+// @Success 200 {object} Response "注销成功"
+// @Failure 401 {object} Response "未授权"
+// @Failure 404 {object} Response "会话不存在"
+// @Router /api/v1/users/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetUint("userID")
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		SendError(c, http.StatusBadRequest, "无效的ID参数")
+		return
+	}
+
+	if err := h.userService.RevokeSession(c.Request.Context(), userID, uint(sessionID)); err != nil {
+		SendError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	SendSuccess(c, "注销成功", nil)
+}
+
+// @Tags 用户
+// @Summary 注销其他会话
+// @Description 注销当前会话以外的所有登录会话
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response "注销成功"
+// @Failure 401 {object} Response "未授权"
+// @Router /api/v1/users/sessions [delete]
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.GetUint("userID")
+	sessionID := c.GetUint("sessionID")
+
+	if err := h.userService.RevokeOtherSessions(c.Request.Context(), userID, sessionID); err != nil {
 		SendError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	SendSuccess(c, "删除成功", nil)
+	SendSuccess(c, "注销成功", nil)
 }
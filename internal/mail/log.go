@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ddup-apis/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer 不真正发送邮件，而是写入日志；配置了目录时同时把邮件保存为文件，
+// 用于开发和测试环境
+type LogMailer struct {
+	dir string
+}
+
+func NewLogMailer(dir string) *LogMailer {
+	return &LogMailer{dir: dir}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	logger.Info("发送邮件",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.txt", time.Now().Format("20060102T150405.000000000"), msg.To)
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("保存邮件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"ddup-apis/internal/config"
+)
+
+// Message 邮件内容
+type Message struct {
+	To      string
+	Subject string
+	Body    string // 纯文本正文
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer 根据配置创建邮件发送器
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From), nil
+	case "log", "":
+		return NewLogMailer(cfg.Mail.Dir), nil
+	default:
+		return nil, fmt.Errorf("邮件驱动 %s 不支持", cfg.Mail.Driver)
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth 只允许平台管理员访问，需在 JWTAuth 之后使用
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := c.Get("userRole"); role != model.UserRoleAdmin {
+			c.Error(errors.ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
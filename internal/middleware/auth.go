@@ -1,14 +1,20 @@
 package middleware
 
 import (
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/requestctx"
 	"ddup-apis/internal/service"
 	"net/http"
 
 	"ddup-apis/internal/errors"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// JWTAuth 校验 JWT 或个人访问令牌，个人访问令牌的权限范围由 RequireScope 检查。
+// 管理员代登录期间的每个请求都会写入审计日志
 func JWTAuth(userService service.IUserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractToken(c)
@@ -18,7 +24,13 @@ func JWTAuth(userService service.IUserService) gin.HandlerFunc {
 			return
 		}
 
-		result, err := userService.ValidateToken(token)
+		var result *service.TokenValidationResult
+		var err error
+		if service.IsAccessToken(token) {
+			result, err = userService.ValidateAccessToken(c.Request.Context(), token, c.ClientIP())
+		} else {
+			result, err = userService.ValidateToken(token)
+		}
 		if err != nil {
 			c.Error(errors.Wrap(err, "Token 验证失败"))
 			c.Abort()
@@ -31,11 +43,81 @@ func JWTAuth(userService service.IUserService) gin.HandlerFunc {
 			return
 		}
 
+		if result.AccessTokenID == 0 {
+			if err := userService.TouchSession(c.Request.Context(), result.SessionID, c.ClientIP()); err != nil {
+				logger.Warn("更新会话活跃时间失败", zap.Uint("session_id", result.SessionID), zap.Error(err))
+			}
+		} else {
+			c.Set("accessTokenID", result.AccessTokenID)
+			c.Set("scopes", result.Scopes)
+		}
+
+		info := requestctx.From(c.Request.Context())
+		info.ActorID = result.UserID
+		info.ImpersonatorID = result.ImpersonatorID
+		if result.ImpersonatorID != 0 {
+			c.Set("impersonatorID", result.ImpersonatorID)
+		}
+
 		c.Set("userID", result.UserID)
 		c.Set("username", result.Username)
+		c.Set("sessionID", result.SessionID)
+		c.Set("userStatus", result.Status)
+		c.Set("userRole", result.Role)
 		c.Set("Valid", result.Valid)
 		c.Next()
+
+		if result.ImpersonatorID != 0 {
+			userService.RecordImpersonatedRequest(c.Request.Context(), result.UserID, c.Request.Method, c.Request.URL.Path, responseStatus(c))
+		}
+	}
+}
+
+// RequireNoImpersonation 拒绝管理员代登录的请求，用于修改密码、两步验证、令牌等只能由用户本人执行的操作
+func RequireNoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonatorID"); ok {
+			c.Error(errors.New(http.StatusForbidden, "代登录期间不能执行该操作", nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 用于允许匿名访问的接口：带令牌时与 JWTAuth 相同，无效令牌同样被拒绝；不带令牌时直接放行
+func OptionalJWTAuth(userService service.IUserService) gin.HandlerFunc {
+	auth := JWTAuth(userService)
+	return func(c *gin.Context) {
+		if extractToken(c) == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// RequireActiveUser 只允许状态正常的用户访问，待验证邮箱等受限账号会被拒绝，需在 JWTAuth 之后使用
+func RequireActiveUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if status, _ := c.Get("userStatus"); status != model.UserStatusActive {
+			c.Error(errors.New(http.StatusForbidden, "账号功能受限，请先验证邮箱", nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// responseStatus 返回请求的响应状态码，通过 c.Error 返回的错误此时尚未由 ErrorHandler 写入响应
+func responseStatus(c *gin.Context) int {
+	if c.Writer.Written() || len(c.Errors) == 0 {
+		return c.Writer.Status()
+	}
+	if appErr, ok := c.Errors.Last().Err.(*errors.AppError); ok {
+		return appErr.Code
 	}
+	return http.StatusInternalServerError
 }
 
 func sendError(c *gin.Context, status int, message string) {
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"ddup-apis/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRequireNoImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+	tests := []struct {
+		name           string
+		impersonatorID uint // 0 表示用户本人登录
+		allowed        bool
+	}{
+		{"用户本人", 0, true},
+		{"管理员代登录", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			r := gin.New()
+			r.Use(ErrorHandler(), func(c *gin.Context) {
+				if tt.impersonatorID != 0 {
+					c.Set("impersonatorID", tt.impersonatorID)
+				}
+			}, RequireNoImpersonation())
+			r.PUT("/account/password", func(c *gin.Context) { reached = true })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/account/password", nil))
+			if reached != tt.allowed {
+				t.Errorf("允许访问 = %v, want %v", reached, tt.allowed)
+			}
+			if !tt.allowed && w.Code != http.StatusForbidden {
+				t.Errorf("状态码 = %d, want 403", w.Code)
+			}
+		})
+	}
+}
@@ -27,18 +27,27 @@ func Logger() gin.HandlerFunc {
 			path = fmt.Sprintf("%s?%s", path, query)
 		}
 
-		logger.Info("HTTP Request",
+		fields := []zap.Field{
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.Int("status", statusCode),
 			zap.Duration("latency", latency),
 			zap.String("ip", clientIP),
 			zap.Int("size", c.Writer.Size()),
-		)
+			zap.String("request_id", c.GetString("requestID")),
+		}
+		if impersonatorID, ok := c.Get("impersonatorID"); ok {
+			fields = append(fields,
+				zap.Uint("user_id", c.GetUint("userID")),
+				zap.Uint("impersonator_id", impersonatorID.(uint)),
+			)
+		}
+		logger.Info("HTTP Request", fields...)
 
 		if len(c.Errors) > 0 {
 			logger.Error("HTTP Request Error",
 				zap.String("errors", c.Errors.String()),
+				zap.String("request_id", c.GetString("requestID")),
 			)
 		}
 	}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"ddup-apis/internal/requestctx"
+	"ddup-apis/internal/utils"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern 接受上游代理传入的请求 ID 的格式，其余情况重新生成
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestID 为每个请求分配请求 ID 并写入响应头，同时将请求元信息放入 request context
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			id, err := utils.GenerateRandomToken(16)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			requestID = id
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(requestctx.With(c.Request.Context(), &requestctx.Info{
+			RequestID: requestID,
+			ClientIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope 检查个人访问令牌的权限范围：GET/HEAD 请求需要 read，其他请求需要 write。
+// 通过 JWT 登录的请求不受限制，需在 JWTAuth 之后使用
+func RequireScope(read, write model.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get("scopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		required := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			required = read
+		}
+
+		if !model.HasScope(value.([]model.TokenScope), required) {
+			c.Error(errors.New(http.StatusForbidden, "访问令牌缺少权限: "+string(required), nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession 只允许通过 JWT 登录的请求访问，拒绝个人访问令牌，用于修改密码、管理令牌等敏感操作
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("accessTokenID"); ok {
+			c.Error(errors.New(http.StatusForbidden, "该操作不支持使用访问令牌", nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"ddup-apis/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		method  string
+		scopes  []model.TokenScope // nil 表示通过 JWT 登录
+		allowed bool
+	}{
+		{"JWT 登录不受限制", http.MethodPut, nil, true},
+		{"读请求需要 read", http.MethodGet, []model.TokenScope{model.ScopeProfileRead}, true},
+		{"写请求需要 write", http.MethodPut, []model.TokenScope{model.ScopeProfileRead}, false},
+		{"write 隐含 read", http.MethodGet, []model.TokenScope{model.ScopeProfileWrite}, true},
+		{"HEAD 按读请求处理", http.MethodHead, []model.TokenScope{model.ScopeProfileRead}, true},
+		{"其他资源的权限", http.MethodGet, []model.TokenScope{model.ScopeOrgAdmin}, false},
+		{"没有权限", http.MethodGet, []model.TokenScope{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.scopes != nil {
+					c.Set("scopes", tt.scopes)
+				}
+			}, RequireScope(model.ScopeProfileRead, model.ScopeProfileWrite))
+			r.Handle(tt.method, "/profiles", func(c *gin.Context) { reached = true })
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/profiles", nil))
+			if reached != tt.allowed {
+				t.Errorf("允许访问 = %v, want %v", reached, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TokenScope 个人访问令牌的权限范围
+type TokenScope string
+
+const (
+	ScopeProfileRead  TokenScope = "profile:read"  // 读取用户信息和个人资料
+	ScopeProfileWrite TokenScope = "profile:write" // 修改用户信息和个人资料
+	ScopeOrgRead      TokenScope = "org:read"      // 读取组织及成员
+	ScopeOrgAdmin     TokenScope = "org:admin"     // 管理组织及成员
+)
+
+// TokenScopes 所有可用的权限范围
+var TokenScopes = []TokenScope{ScopeProfileRead, ScopeProfileWrite, ScopeOrgRead, ScopeOrgAdmin}
+
+// impliedScopes 写权限隐含对应的读权限
+var impliedScopes = map[TokenScope]TokenScope{
+	ScopeProfileWrite: ScopeProfileRead,
+	ScopeOrgAdmin:     ScopeOrgRead,
+}
+
+func (s TokenScope) Valid() bool {
+	for _, scope := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken 个人访问令牌，供脚本和 CI 使用，只保存令牌的 SHA-256 摘要
+type PersonalAccessToken struct {
+	ID         uint       `gorm:"primaryKey"`
+	UserID     uint       `gorm:"not null;index"`
+	Name       string     `gorm:"type:varchar(100);not null"`
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	TokenHint  string     `gorm:"type:varchar(20);not null"`  // 令牌前几位，便于用户辨认
+	Scopes     string     `gorm:"type:varchar(255);not null"` // 空格分隔的权限范围
+	ExpiredAt  *time.Time // 为空表示永不过期
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"type:varchar(45)"`
+	gorm.Model
+}
+
+func (t *PersonalAccessToken) ScopeList() []TokenScope {
+	fields := strings.Fields(t.Scopes)
+	scopes := make([]TokenScope, 0, len(fields))
+	for _, f := range fields {
+		scopes = append(scopes, TokenScope(f))
+	}
+	return scopes
+}
+
+// HasScope 判断令牌是否拥有指定权限，写权限包含对应的读权限
+func (t *PersonalAccessToken) HasScope(scope TokenScope) bool {
+	return HasScope(t.ScopeList(), scope)
+}
+
+func (t *PersonalAccessToken) Expired() bool {
+	return t.ExpiredAt != nil && time.Now().After(*t.ExpiredAt)
+}
+
+// HasScope 判断权限列表是否包含指定权限，写权限包含对应的读权限
+func HasScope(scopes []TokenScope, scope TokenScope) bool {
+	for _, s := range scopes {
+		if s == scope || impliedScopes[s] == scope {
+			return true
+		}
+	}
+	return false
+}
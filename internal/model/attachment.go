@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProfileAttachment 上传到本服务的资料项附件，文件内容保存在存储后端的 StorageKey 下。
+// 删除时只做软删除，由后台任务删除存储中的文件后再删除记录
+type ProfileAttachment struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserID     uint   `json:"user_id" gorm:"not null;index"`
+	ProfileID  uint   `json:"profile_id" gorm:"not null;index"`
+	Name       string `json:"name" gorm:"type:varchar(255);not null"`
+	MimeType   string `json:"mime_type" gorm:"type:varchar(100);not null"` // 按文件内容识别的类型
+	Size       int64  `json:"size" gorm:"not null"`
+	StorageKey string `json:"-" gorm:"type:varchar(255);not null"`
+	gorm.Model
+}
+
+// AttachmentUpload 未完成的分片上传，已接收的内容暂存在本地 UPLOAD_TEMP_DIR 下以 ID 命名的文件中
+type AttachmentUpload struct {
+	ID        string    `gorm:"type:varchar(64);primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	ProfileID uint      `gorm:"not null;index"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Size      int64     `gorm:"not null"`
+	Received  int64     `gorm:"not null;default:0"` // 已接收的字节数
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
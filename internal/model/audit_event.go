@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction 审计事件类型
+type AuditAction string
+
+const (
+	AuditLoginSuccess        AuditAction = "login.success"
+	AuditLoginFailure        AuditAction = "login.failure"
+	AuditLogout              AuditAction = "logout"
+	AuditPasswordChange      AuditAction = "password.change"
+	AuditPasswordReset       AuditAction = "password.reset"
+	AuditPasswordDisable     AuditAction = "password.disable"
+	AuditSessionRevoke       AuditAction = "session.revoke"
+	AuditAccessTokenCreate   AuditAction = "access_token.create"
+	AuditAccessTokenRevoke   AuditAction = "access_token.revoke"
+	AuditOIDCConsentRevoke   AuditAction = "oidc_consent.revoke"
+	AuditTwoFactorEnable     AuditAction = "2fa.enable"
+	AuditTwoFactorDisable    AuditAction = "2fa.disable"
+	AuditIdentityLink        AuditAction = "identity.link"
+	AuditIdentityUnlink      AuditAction = "identity.unlink"
+	AuditUserUnlock          AuditAction = "user.unlock"
+	AuditUserStatusChange    AuditAction = "user.status_change"
+	AuditUserRoleChange      AuditAction = "user.role_change"
+	AuditUserDelete          AuditAction = "user.delete"
+	AuditUserRestore         AuditAction = "user.restore"
+	AuditUserPurge           AuditAction = "user.purge"
+	AuditUserForceLogout     AuditAction = "user.force_logout"
+	AuditTwoFactorReset      AuditAction = "2fa.reset"
+	AuditOrgMemberAdd        AuditAction = "org.member_add"
+	AuditOrgMemberRemove     AuditAction = "org.member_remove"
+	AuditOrgMemberRoleChange AuditAction = "org.member_role_change"
+	AuditOrgTransfer         AuditAction = "org.transfer"
+	AuditOrgDelete           AuditAction = "org.delete"
+	AuditImpersonationStart  AuditAction = "impersonation.start"
+	AuditImpersonationAction AuditAction = "impersonation.request"
+)
+
+// AuditEvent 安全相关操作的审计记录，只追加不修改，因此不使用 gorm.Model 的更新和软删除字段
+type AuditEvent struct {
+	ID             uint            `gorm:"primaryKey"`
+	Action         AuditAction     `gorm:"type:varchar(50);not null;index"`
+	ActorID        uint            `gorm:"not null;default:0;index"` // 执行操作的用户，0 表示未登录（如登录失败）
+	UserID         uint            `gorm:"not null;default:0;index"` // 受影响的用户，0 表示无法对应到用户
+	OrganizationID uint            `gorm:"not null;default:0;index"` // 组织相关事件所属的组织
+	ImpersonatorID uint            `gorm:"not null;default:0;index"` // 管理员代登录期间发生的事件记录管理员 ID
+	IP             string          `gorm:"type:varchar(45)"`
+	UserAgent      string          `gorm:"type:varchar(500)"`
+	RequestID      string          `gorm:"type:varchar(64);index"`
+	Metadata       json.RawMessage `gorm:"type:json"` // 事件相关的补充信息，如失败原因、角色变化
+	CreatedAt      time.Time       `gorm:"not null;index"`
+}
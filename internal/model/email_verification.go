@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailVerificationToken 邮箱验证令牌，只保存令牌的 SHA-256 摘要
+type EmailVerificationToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	Email     string     `gorm:"size:100;not null"` // 待验证的邮箱，用户修改邮箱后旧令牌不再生效
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiredAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已使用的令牌不能再次使用
+	gorm.Model
+}
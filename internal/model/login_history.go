@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// LoginHistory 一次登录尝试的记录，成功的记录同时作为识别新设备的依据
+type LoginHistory struct {
+	ID                uint      `gorm:"primaryKey"`
+	UserID            uint      `gorm:"not null;index"`
+	Success           bool      `gorm:"not null"`
+	Method            string    `gorm:"type:varchar(50);not null"` // password、2fa、magic_link、oauth:<provider>
+	Reason            string    `gorm:"type:varchar(50)"`          // 失败原因
+	IP                string    `gorm:"type:varchar(45)"`
+	UserAgent         string    `gorm:"type:varchar(500)"`
+	DeviceLabel       string    `gorm:"type:varchar(100)"`
+	DeviceFingerprint string    `gorm:"type:varchar(64);index"`
+	NewDevice         bool      `gorm:"not null;default:false"` // 登录成功且设备和 IP 都未曾出现过
+	CreatedAt         time.Time `gorm:"not null;index"`
+}
+
+// TableName 指定表名
+func (LoginHistory) TableName() string {
+	return "login_history"
+}
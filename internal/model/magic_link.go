@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MagicLinkToken 邮件登录链接令牌，只保存令牌的 SHA-256 摘要
+type MagicLinkToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	Email     string     `gorm:"type:varchar(100);not null"` // 发送时的邮箱，邮箱变更后令牌失效
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	RequestIP string     `gorm:"type:varchar(45)"`
+	ExpiredAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已使用的令牌不能再次使用
+	gorm.Model
+}
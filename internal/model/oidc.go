@@ -0,0 +1,89 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OIDC 客户端可申请的 scope
+const (
+	OIDCScopeOpenID  = "openid"
+	OIDCScopeProfile = "profile"
+	OIDCScopeEmail   = "email"
+)
+
+// OIDCScopes 所有支持的 scope
+var OIDCScopes = []string{OIDCScopeOpenID, OIDCScopeProfile, OIDCScopeEmail}
+
+// OIDCClient 使用 ddup 登录的应用。SecretHash 为空的是公开客户端（SPA、移动端），必须使用 PKCE
+type OIDCClient struct {
+	ID           uint   `gorm:"primaryKey"`
+	ClientID     string `gorm:"type:varchar(64);uniqueIndex;not null"`
+	SecretHash   string `gorm:"type:varchar(64)"` // client secret 的 SHA-256 摘要
+	Name         string `gorm:"type:varchar(100);not null"`
+	RedirectURIs string `gorm:"type:text;not null"` // 空格分隔的回调地址白名单，必须完全匹配
+	CreatedBy    uint
+	gorm.Model
+}
+
+func (c *OIDCClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+func (c *OIDCClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+// AllowsRedirectURI 回调地址是否在白名单内
+func (c *OIDCClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIList() {
+		if uri == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// OIDCConsent 用户对应用的授权记录，已授权的 scope 再次登录时无需确认
+type OIDCConsent struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"not null;uniqueIndex:idx_oidc_consents_user_client"`
+	ClientID string `gorm:"type:varchar(64);not null;uniqueIndex:idx_oidc_consents_user_client"`
+	Scopes   string `gorm:"type:varchar(255);not null"` // 空格分隔
+	gorm.Model
+}
+
+// Covers 已授权的 scope 是否包含 scopes
+func (c *OIDCConsent) Covers(scopes []string) bool {
+	granted := strings.Fields(c.Scopes)
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// OIDCAuthorizationCode 授权码，只保存摘要，兑换后立即删除
+type OIDCAuthorizationCode struct {
+	ID            uint      `gorm:"primaryKey"`
+	CodeHash      string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ClientID      string    `gorm:"type:varchar(64);not null"`
+	UserID        uint      `gorm:"not null"`
+	RedirectURI   string    `gorm:"type:text;not null"`
+	Scopes        string    `gorm:"type:varchar(255);not null"`
+	Nonce         string    `gorm:"type:varchar(255)"`
+	CodeChallenge string    `gorm:"type:varchar(128)"` // PKCE S256 challenge
+	AuthTime      time.Time `gorm:"not null"`          // 用户本次登录的时间，写入 ID token 的 auth_time
+	ExpiredAt     time.Time `gorm:"not null"`
+	CreatedAt     time.Time
+}
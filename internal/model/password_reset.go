@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken 密码重置令牌，只保存令牌的 SHA-256 摘要
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiredAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已使用的令牌不能再次使用
+	gorm.Model
+}
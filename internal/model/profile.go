@@ -27,6 +27,21 @@ const (
 	Team          ProfileType = "team"
 )
 
+// ProfileTypes 所有资料类型，按公开主页上的展示顺序排列
+var ProfileTypes = []ProfileType{
+	General, Work, Project, SideProject, Education, Certification, Award, Feature,
+	Exhibition, Speaking, Writing, Volunteering, Team, Contact,
+}
+
+// 资料可见性，判断逻辑统一在 repository.VisibleTo 中
+const (
+	VisibilityPublic       = "public"       // 所有人可见，包括未登录的访客
+	VisibilityPrivate      = "private"      // 仅本人可见
+	VisibilityUnlisted     = "unlisted"     // 不出现在主页上，只能通过分享链接访问
+	VisibilityOrganization = "organization" // 与本人同属一个组织的用户可见
+	VisibilityAudience     = "audience"     // 仅指定的用户可见，见 ProfileAudience
+)
+
 // Profile 基础模型
 type Profile struct {
 	ID           uint            `json:"id" gorm:"primaryKey"`
@@ -42,10 +57,23 @@ type Profile struct {
 	Description  string          `json:"description" gorm:"type:text"`
 	Metadata     json.RawMessage `json:"metadata" gorm:"type:json"`
 	DisplayOrder int             `json:"display_order" gorm:"default:0"`
-	Visibility   string          `json:"visibility" gorm:"type:varchar(10);default:public;check:visibility in ('public','private')"`
+	Visibility   string          `json:"visibility" gorm:"type:varchar(20);default:public;check:chk_profiles_visibility_levels,visibility in ('public','private','unlisted','organization','audience')"`
+	// 元数据最近一次校验所用的 schema 版本，0 表示引入 schema 之前保存的资料项
+	SchemaVersion int `json:"schema_version" gorm:"not null;default:0"`
+	// 分享链接只保存令牌摘要，仅对 unlisted 资料项生效
+	ShareTokenHash string     `json:"-" gorm:"type:varchar(64);index"`
+	ShareExpiresAt *time.Time `json:"-"`
 	gorm.Model
 }
 
+// ProfileAudience audience 可见性的资料项允许查看的用户
+type ProfileAudience struct {
+	ID        uint `gorm:"primaryKey"`
+	ProfileID uint `gorm:"not null;uniqueIndex:idx_profile_audiences_profile_user"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_profile_audiences_profile_user;index"`
+	CreatedAt time.Time
+}
+
 // ProfileMetadata 元数据结构
 type ProfileMetadata struct {
 	// General
@@ -77,7 +105,7 @@ type ProfileMetadata struct {
 	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
-// Attachment 附件结构
+// Attachment 元数据中引用的外部附件，上传到本服务的文件见 ProfileAttachment
 type Attachment struct {
 	Type     string `json:"type"`      // page/media
 	URL      string `json:"url"`       // 文件链接
@@ -89,7 +117,7 @@ type Attachment struct {
 // Profile 添加钩子方法
 func (p *Profile) BeforeCreate(tx *gorm.DB) error {
 	if p.Visibility == "" {
-		p.Visibility = "public"
+		p.Visibility = VisibilityPublic
 	}
 	if p.DisplayOrder == 0 {
 		var maxOrder int
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode 两步验证恢复码，每个只能使用一次，只保存 SHA-256 摘要
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"type:varchar(64);not null;index"`
+	UsedAt   *time.Time
+	gorm.Model
+}
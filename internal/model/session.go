@@ -7,10 +7,22 @@ import (
 )
 
 type Session struct {
-	ID        uint      `gorm:"primaryKey"`
-	UserID    uint      `gorm:"not null;index"`
-	Token     string    `gorm:"type:varchar(500);not null"`
-	IsValid   bool      `gorm:"not null;default:true"`
-	ExpiredAt time.Time `gorm:"not null"`
+	ID               uint       `gorm:"primaryKey"`
+	UserID           uint       `gorm:"not null;index"`
+	TokenHash        string     `gorm:"type:varchar(64);index"` // access token 的 SHA-256 摘要，不保存明文
+	RefreshToken     string     `gorm:"type:varchar(64);index"` // refresh token 的 SHA-256 摘要
+	FamilyID         string     `gorm:"type:varchar(64);index"` // 同一次登录轮换出的会话共用一个 family
+	IsValid          bool       `gorm:"not null;default:true"`
+	ExpiredAt        time.Time  `gorm:"not null"`
+	RefreshExpiredAt time.Time  `gorm:"not null"`
+	RotatedAt        *time.Time // 被轮换的时间，轮换后的 refresh token 再次出现即视为重用
+	UserAgent        string     `gorm:"type:varchar(500)"`
+	ClientIP         string     `gorm:"type:varchar(45)"`
+	DeviceLabel      string     `gorm:"type:varchar(100)"`
+	ClientID         string     `gorm:"type:varchar(64);not null;default:'';index"` // 通过 OIDC 授权给应用的会话，为空表示 ddup 自身的登录会话
+	Scope            string     `gorm:"type:varchar(255);not null;default:''"`      // 授权给应用的 scope，空格分隔
+	ImpersonatorID   *uint      `gorm:"index"`                                      // 管理员代登录的会话记录管理员 ID，此类会话没有 refresh token
+	SignedInAt       time.Time  // 本次登录的时间，轮换时沿用
+	LastSeenAt       *time.Time
 	gorm.Model
 }
@@ -6,26 +6,127 @@ import (
 	"gorm.io/gorm"
 )
 
+// UserStatus 用户状态
+type UserStatus int
+
+const (
+	UserStatusActive    UserStatus = 1 // 正常
+	UserStatusPending   UserStatus = 2 // 待验证，可登录但功能受限
+	UserStatusSuspended UserStatus = 3 // 暂停使用，可恢复
+	UserStatusDisabled  UserStatus = 4 // 已停用
+	UserStatusDeleted   UserStatus = 5 // 已注销，宽限期结束后彻底删除
+)
+
+var userStatusNames = map[UserStatus]string{
+	UserStatusActive:    "active",
+	UserStatusPending:   "pending",
+	UserStatusSuspended: "suspended",
+	UserStatusDisabled:  "disabled",
+	UserStatusDeleted:   "deleted",
+}
+
+// userStatusTransitions 允许的状态流转
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:   {UserStatusActive, UserStatusSuspended, UserStatusDisabled, UserStatusDeleted},
+	UserStatusActive:    {UserStatusPending, UserStatusSuspended, UserStatusDisabled, UserStatusDeleted},
+	UserStatusSuspended: {UserStatusActive, UserStatusDisabled, UserStatusDeleted},
+	UserStatusDisabled:  {UserStatusActive, UserStatusDeleted},
+	UserStatusDeleted:   {UserStatusActive, UserStatusPending}, // 宽限期内恢复
+}
+
+func (s UserStatus) String() string {
+	if name, ok := userStatusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseUserStatus 将状态名解析为 UserStatus
+func ParseUserStatus(name string) (UserStatus, bool) {
+	for status, n := range userStatusNames {
+		if n == name {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// CanLogin 该状态下是否允许登录和使用已有会话
+func (s UserStatus) CanLogin() bool {
+	return s == UserStatusActive || s == UserStatusPending
+}
+
+// CanTransitionTo 是否允许从当前状态变更为 to
+func (s UserStatus) CanTransitionTo(to UserStatus) bool {
+	for _, next := range userStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRole 平台角色，与组织内的角色无关
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin" // 平台管理员，可访问 /api/v1/admin 接口
+)
+
+// Valid 是否为已定义的角色
+func (r UserRole) Valid() bool {
+	return r == UserRoleUser || r == UserRoleAdmin
+}
+
 type User struct {
-	ID            uint       `gorm:"primarykey"`
-	Username      string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	Password      string     `gorm:"type:varchar(100);not null" json:"-"`
-	Nickname      string     `gorm:"type:varchar(50);not null" json:"nickname"`
-	Gender        string     `gorm:"size:10;default:'unknown'" json:"gender"`
-	Birthday      *time.Time `json:"birthday"`
-	Avatar        string     `gorm:"type:varchar(255)" json:"avatar"`
-	Email         string     `gorm:"size:100;null" json:"email"`
-	Mobile        string     `gorm:"size:20;null" json:"mobile"`
-	Location      string     `gorm:"size:100;null" json:"location"`
-	Language      string     `gorm:"type:varchar(10);default:zh-CN" json:"language"`
-	Bio           string     `gorm:"size:500" json:"bio"`
-	Status        int        `gorm:"default:1;not null" json:"status"`
-	LastLogin     *time.Time `json:"last_login"`
-	LoginAttempts int        `gorm:"default:0" json:"-"`
-	LockedUntil   *time.Time `json:"-"`
+	ID              uint       `gorm:"primarykey"`
+	Username        string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
+	Password        string     `gorm:"type:varchar(255);not null" json:"-"`
+	Nickname        string     `gorm:"type:varchar(50);not null" json:"nickname"`
+	Gender          string     `gorm:"size:10;default:'unknown'" json:"gender"`
+	Birthday        *time.Time `json:"birthday"`
+	Avatar          string     `gorm:"type:varchar(255)" json:"avatar"`
+	Email           *string    `gorm:"size:100;null;uniqueIndex" json:"email"` // 统一存储为小写，未设置时为 NULL
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Mobile          string     `gorm:"size:20;null" json:"mobile"`
+	Location        string     `gorm:"size:100;null" json:"location"`
+	Language        string     `gorm:"type:varchar(10);default:zh-CN" json:"language"`
+	Bio             string     `gorm:"size:500" json:"bio"`
+	Status          UserStatus `gorm:"default:1;not null" json:"status"`
+	Role            UserRole   `gorm:"type:varchar(20);not null;default:'user';index" json:"role"`
+	LastLogin       *time.Time `json:"last_login"`
+	LoginAttempts   int        `gorm:"default:0" json:"-"`
+	LockedUntil     *time.Time `json:"-"`
+
+	// DeletionScheduledAt 注销后计划彻底删除的时间，此前账号可以恢复
+	DeletionScheduledAt *time.Time `gorm:"index" json:"-"`
+
+	// 两步验证：TwoFactorSecret 在确认绑定前即已保存，TwoFactorEnabledAt 非空才表示已开启
+	TwoFactorSecret    string     `gorm:"type:varchar(64)" json:"-"`
+	TwoFactorEnabledAt *time.Time `json:"-"`
+	TwoFactorLastStep  int64      `gorm:"default:0" json:"-"` // 最近一次使用的 TOTP 时间步，防止验证码重放
 	gorm.Model
 }
 
+// EmailAddress 返回邮箱地址，未设置时返回空字符串
+func (u *User) EmailAddress() string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+// EmailVerified 邮箱是否已验证
+func (u *User) EmailVerified() bool {
+	return u.Email != nil && u.EmailVerifiedAt != nil
+}
+
+// TwoFactorEnabled 是否已开启两步验证
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactorEnabledAt != nil
+}
+
 // TableName 指定表名
 func (User) TableName() string {
 	return "users"
@@ -39,5 +140,8 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.Gender == "" {
 		u.Gender = "unknown"
 	}
+	if u.Role == "" {
+		u.Role = UserRoleUser
+	}
 	return nil
 }
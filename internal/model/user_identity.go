@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity 用户绑定的第三方账号，同一提供方的同一账号只能绑定一个用户
+type UserIdentity struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"not null;index"`
+	Provider string `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject  string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email    string `gorm:"type:varchar(100)"`
+	Username string `gorm:"type:varchar(100)"` // 第三方账号的用户名，便于用户辨认
+	gorm.Model
+}
+
+// OAuthState 发起第三方登录或绑定时保存的授权请求，回调时取出 PKCE verifier，只能使用一次
+type OAuthState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Provider     string    `gorm:"type:varchar(50);not null"`
+	Purpose      string    `gorm:"type:varchar(20);not null"` // login 或 link
+	UserID       uint      // 绑定时发起请求的用户
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	ExpiredAt    time.Time `gorm:"not null"`
+	gorm.Model
+}
+
+const (
+	OAuthPurposeLogin = "login"
+	OAuthPurposeLink  = "link"
+)
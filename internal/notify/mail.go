@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+
+	"ddup-apis/internal/mail"
+)
+
+// MailNotifier 通过邮件发送通知，用户没有已验证的邮箱时跳过
+type MailNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewMailNotifier(mailer mail.Mailer) *MailNotifier {
+	return &MailNotifier{mailer: mailer}
+}
+
+func (m *MailNotifier) Notify(ctx context.Context, n *Notification) error {
+	if n.Email == "" {
+		return nil
+	}
+	return m.mailer.Send(ctx, &mail.Message{
+		To:      n.Email,
+		Subject: n.Subject,
+		Body:    n.Body,
+	})
+}
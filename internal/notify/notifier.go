@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/mail"
+)
+
+// 通知事件类型
+const (
+	EventNewDeviceLogin = "new_device_login"
+)
+
+// Notification 发给用户的安全提醒
+type Notification struct {
+	Event   string
+	UserID  uint
+	Email   string // 收件邮箱，用户没有已验证的邮箱时为空
+	Subject string
+	Body    string                 // 纯文本正文
+	Data    map[string]interface{} // 结构化内容，供 webhook 等渠道使用
+}
+
+// Notifier 通知发送接口
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// NewNotifier 根据配置创建通知发送器
+func NewNotifier(cfg *config.Config, mailer mail.Mailer) (Notifier, error) {
+	switch cfg.Notify.Driver {
+	case "mail", "":
+		return NewMailNotifier(mailer), nil
+	case "webhook":
+		return NewWebhookNotifier(cfg.Notify.WebhookURL, cfg.Notify.WebhookSecret), nil
+	case "none":
+		return NopNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("通知驱动 %s 不支持", cfg.Notify.Driver)
+	}
+}
+
+// NopNotifier 不发送任何通知
+type NopNotifier struct{}
+
+func (NopNotifier) Notify(ctx context.Context, n *Notification) error {
+	return nil
+}
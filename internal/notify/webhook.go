@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader 配置了密钥时，请求体的 HMAC-SHA256 签名放在该请求头中，格式为 sha256=<hex>
+const SignatureHeader = "X-DDUP-Signature"
+
+// WebhookNotifier 将通知以 JSON 格式 POST 到指定地址，由接收方决定推送渠道
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type webhookPayload struct {
+	Event     string                 `json:"event"`
+	UserID    uint                   `json:"userId"`
+	Email     string                 `json:"email,omitempty"`
+	Subject   string                 `json:"subject"`
+	Body      string                 `json:"body"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(&webhookPayload{
+		Event:     n.Event,
+		UserID:    n.UserID,
+		Email:     n.Email,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		Data:      n.Data,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建通知请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送通知失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("通知接收方返回 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		body      []byte
+		signature string
+		status    = http.StatusNoContent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n := &Notification{
+		Event:   EventNewDeviceLogin,
+		UserID:  1,
+		Email:   "alice@example.com",
+		Subject: "新设备登录",
+		Body:    "正文",
+		Data:    map[string]interface{}{"ip": "192.0.2.1"},
+	}
+	if err := NewWebhookNotifier(server.URL, "secret").Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != n.Event || payload.UserID != n.UserID || payload.Email != n.Email || payload.Data["ip"] != "192.0.2.1" {
+		t.Errorf("payload = %+v", payload)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("%s = %s, want %s", SignatureHeader, signature, want)
+	}
+
+	// 未配置密钥时不签名
+	if err := NewWebhookNotifier(server.URL, "").Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if signature != "" {
+		t.Errorf("未配置密钥时 %s = %s", SignatureHeader, signature)
+	}
+
+	status = http.StatusInternalServerError
+	if err := NewWebhookNotifier(server.URL, "secret").Notify(context.Background(), n); err == nil {
+		t.Error("接收方返回错误状态码时应返回错误")
+	}
+}
@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// GitHubProvider GitHub 不支持 OIDC 登录，用户信息通过 REST API 获取
+type GitHubProvider struct {
+	cfg    config.OAuthProvider
+	config *oauth2.Config
+}
+
+func NewGitHubProvider(cfg config.OAuthProvider, redirectURL string) *GitHubProvider {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHubProvider{
+		cfg: cfg,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     github.Endpoint,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+	}
+}
+
+func (p *GitHubProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	ctx = withHTTPClient(ctx)
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("获取令牌失败: %w", err)
+	}
+	client := p.config.Client(ctx, token)
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(client, githubAPIURL+"/user", &user); err != nil {
+		return nil, err
+	}
+
+	// 公开邮箱可能为空或未验证，以邮箱列表中的主邮箱为准
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(client, githubAPIURL+"/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Username:  user.Login,
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = e.Email
+			identity.EmailVerified = e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
+
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 失败: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
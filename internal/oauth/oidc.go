@@ -0,0 +1,117 @@
+package oauth
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider 通用 OpenID Connect 提供方，端点通过 issuer 的发现文档获取
+type OIDCProvider struct {
+	cfg         config.OAuthProvider
+	redirectURL string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCProvider(cfg config.OAuthProvider, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{cfg: cfg, redirectURL: redirectURL}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// discover 获取并缓存发现文档，失败时下次请求会重试
+func (p *OIDCProvider) discover() (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		// 发现文档中的公钥会在之后的验证中按需刷新，不能使用请求的 context
+		provider, err := oidc.NewProvider(withHTTPClient(context.Background()), p.cfg.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 的 OIDC 配置失败: %w", p.cfg.Name, err)
+		}
+		p.provider = provider
+	}
+	return p.provider, nil
+}
+
+func (p *OIDCProvider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	}
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	provider, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2Config(provider).AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	provider, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withHTTPClient(ctx)
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("获取令牌失败: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("响应中缺少 id_token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("id_token 验证失败: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce 不匹配")
+	}
+
+	var claims struct {
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"` // 部分提供方以字符串返回
+		PreferredUsername string      `json:"preferred_username"`
+		Nickname          string      `json:"nickname"`
+		Name              string      `json:"name"`
+		Picture           string      `json:"picture"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("解析 id_token 失败: %w", err)
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Nickname
+	}
+	return &Identity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Username:      username,
+		Name:          claims.Name,
+		AvatarURL:     claims.Picture,
+	}, nil
+}
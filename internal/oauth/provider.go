@@ -0,0 +1,53 @@
+package oauth
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Identity 第三方提供方返回的用户身份
+type Identity struct {
+	Subject       string // 提供方内的用户唯一标识
+	Email         string
+	EmailVerified bool
+	Username      string // 提供方的用户名，创建账号时作为用户名的参考
+	Name          string
+	AvatarURL     string
+}
+
+// Provider 第三方登录提供方，使用授权码模式和 PKCE
+type Provider interface {
+	Name() string
+	// AuthCodeURL 生成跳转到提供方的授权地址
+	AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error)
+	// Exchange 用授权码换取令牌并获取用户身份
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error)
+}
+
+// httpClient 访问提供方时使用的 HTTP 客户端
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewProviders 按配置创建所有提供方，OIDC 提供方在第一次使用时才请求发现端点
+func NewProviders(cfg *config.Config) (map[string]Provider, error) {
+	providers := make(map[string]Provider, len(cfg.OAuth.Providers))
+	for _, p := range cfg.OAuth.Providers {
+		switch p.Type {
+		case "github":
+			providers[p.Name] = NewGitHubProvider(p, cfg.OAuth.RedirectURL)
+		case "oidc":
+			providers[p.Name] = NewOIDCProvider(p, cfg.OAuth.RedirectURL)
+		default:
+			return nil, fmt.Errorf("不支持的第三方登录类型: %s", p.Type)
+		}
+	}
+	return providers, nil
+}
+
+func withHTTPClient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	GetUserTokens(ctx context.Context, userID uint) ([]model.PersonalAccessToken, error)
+	Touch(ctx context.Context, id uint, clientIP string, interval time.Duration) error
+	Delete(ctx context.Context, userID uint, id uint) (bool, error)
+	DeleteUserTokens(ctx context.Context, userID uint) error
+}
+
+type AccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenRepository(db *gorm.DB) IAccessTokenRepository {
+	return &AccessTokenRepository{db: db}
+}
+
+func (r *AccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByTokenHash 令牌不存在时返回 nil, nil
+func (r *AccessTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *AccessTokenRepository) GetUserTokens(ctx context.Context, userID uint) ([]model.PersonalAccessToken, error) {
+	var tokens []model.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Touch 更新令牌的最后使用时间和 IP，距上次更新不足 interval 时跳过以减少写入
+func (r *AccessTokenRepository) Touch(ctx context.Context, id uint, clientIP string, interval time.Duration) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-interval)).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}).Error
+}
+
+// Delete 删除用户自己的令牌，返回 false 表示令牌不存在
+func (r *AccessTokenRepository) Delete(ctx context.Context, userID uint, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.PersonalAccessToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteUserTokens 删除用户的所有访问令牌
+func (r *AccessTokenRepository) DeleteUserTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.PersonalAccessToken{}).Error
+}
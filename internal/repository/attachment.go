@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IAttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.ProfileAttachment) error
+	GetByID(ctx context.Context, id uint) (*model.ProfileAttachment, error)
+	ListByProfiles(ctx context.Context, profileIDs []uint) ([]model.ProfileAttachment, error)
+	Delete(ctx context.Context, id uint) error
+	ListDeleted(ctx context.Context, limit int) ([]model.ProfileAttachment, error)
+	Purge(ctx context.Context, id uint) error
+
+	ReserveUpload(ctx context.Context, upload *model.AttachmentUpload, quota int64) (int64, bool, error)
+	GetUpload(ctx context.Context, id string) (*model.AttachmentUpload, error)
+	AdvanceUpload(ctx context.Context, id string, from, to int64) (bool, error)
+	DeleteUpload(ctx context.Context, id string) error
+	ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]model.AttachmentUpload, error)
+}
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) IAttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *model.ProfileAttachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+// GetByID 获取附件，不存在或已删除时返回 nil
+func (r *AttachmentRepository) GetByID(ctx context.Context, id uint) (*model.ProfileAttachment, error) {
+	var attachment model.ProfileAttachment
+	err := r.db.WithContext(ctx).First(&attachment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListByProfiles 获取多个资料项的附件，按上传顺序排列
+func (r *AttachmentRepository) ListByProfiles(ctx context.Context, profileIDs []uint) ([]model.ProfileAttachment, error) {
+	var attachments []model.ProfileAttachment
+	if len(profileIDs) == 0 {
+		return attachments, nil
+	}
+	err := r.db.WithContext(ctx).Where("profile_id IN ?", profileIDs).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+// Delete 软删除附件，存储中的文件由 PeriodicAttachmentPurge 删除
+func (r *AttachmentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.ProfileAttachment{}, id).Error
+}
+
+// usedBytes 用户已占用的附件空间，包括未过期的分片上传预留的大小
+func usedBytes(db *gorm.DB, userID uint, now time.Time) (int64, error) {
+	var stored, reserved int64
+	if err := db.Model(&model.ProfileAttachment{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").Scan(&stored).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&model.AttachmentUpload{}).Where("user_id = ? AND expires_at > ?", userID, now).
+		Select("COALESCE(SUM(size), 0)").Scan(&reserved).Error; err != nil {
+		return 0, err
+	}
+	return stored + reserved, nil
+}
+
+// ListDeleted 获取已软删除、等待删除文件的附件
+func (r *AttachmentRepository) ListDeleted(ctx context.Context, limit int) ([]model.ProfileAttachment, error) {
+	var attachments []model.ProfileAttachment
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").
+		Order("id").Limit(limit).Find(&attachments).Error
+	return attachments, err
+}
+
+// Purge 文件删除后彻底删除附件记录
+func (r *AttachmentRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&model.ProfileAttachment{}, id).Error
+}
+
+// ReserveUpload 检查用户的附件空间并创建分片上传预留 upload.Size 字节。检查和创建在锁定用户记录的
+// 同一事务中完成，同一用户的并发上传依次检查，不会一起超出配额。空间不足时返回 false 和已使用的空间
+func (r *AttachmentRepository) ReserveUpload(ctx context.Context, upload *model.AttachmentUpload, quota int64) (int64, bool, error) {
+	var used int64
+	var ok bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&model.User{}, upload.UserID).Error; err != nil {
+			return err
+		}
+		var err error
+		if used, err = usedBytes(tx, upload.UserID, time.Now()); err != nil {
+			return err
+		}
+		if used+upload.Size > quota {
+			return nil
+		}
+		ok = true
+		return tx.Create(upload).Error
+	})
+	return used, ok, err
+}
+
+// GetUpload 获取分片上传，不存在时返回 nil
+func (r *AttachmentRepository) GetUpload(ctx context.Context, id string) (*model.AttachmentUpload, error) {
+	var upload model.AttachmentUpload
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// AdvanceUpload 将已接收字节数从 from 更新为 to，返回 false 表示期间已被其他请求更新
+func (r *AttachmentRepository) AdvanceUpload(ctx context.Context, id string, from, to int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.AttachmentUpload{}).
+		Where("id = ? AND received = ?", id, from).Update("received", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *AttachmentRepository) DeleteUpload(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.AttachmentUpload{}).Error
+}
+
+// ListExpiredUploads 获取已过期的分片上传
+func (r *AttachmentRepository) ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]model.AttachmentUpload, error) {
+	var uploads []model.AttachmentUpload
+	err := r.db.WithContext(ctx).Where("expires_at <= ?", now).Order("expires_at").Limit(limit).Find(&uploads).Error
+	return uploads, err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEventFilter 审计事件的查询条件，零值字段不参与过滤
+type AuditEventFilter struct {
+	UserID         uint
+	ActorID        uint
+	OrganizationID uint
+	ImpersonatorID uint
+	Action         model.AuditAction
+	IP             string
+	RequestID      string
+	Since          *time.Time
+	Until          *time.Time
+	Offset         int
+	Limit          int
+}
+
+// IAuditRepository 审计事件只提供追加和查询，不提供修改和删除
+type IAuditRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter *AuditEventFilter) ([]model.AuditEvent, int64, error)
+}
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) IAuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List 按条件查询审计事件，最新的在前，同时返回符合条件的总数
+func (r *AuditRepository) List(ctx context.Context, filter *AuditEventFilter) ([]model.AuditEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.AuditEvent{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.ImpersonatorID != 0 {
+		query = query.Where("impersonator_id = ?", filter.ImpersonatorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []model.AuditEvent
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&events).Error
+	return events, total, err
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IEmailVerificationRepository interface {
+	Create(ctx context.Context, token *model.EmailVerificationToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateUserTokens(ctx context.Context, userID uint) error
+}
+
+type EmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) IEmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+func (r *EmailVerificationRepository) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed 将令牌标记为已使用，返回 false 表示令牌已被使用
+func (r *EmailVerificationRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateUserTokens 使用户所有未使用的令牌失效
+func (r *EmailVerificationRepository) InvalidateUserTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ILoginHistoryRepository interface {
+	Create(ctx context.Context, record *model.LoginHistory) error
+	List(ctx context.Context, userID uint, offset, limit int) ([]model.LoginHistory, int64, error)
+	HasSuccessfulLogin(ctx context.Context, userID uint) (bool, error)
+	IsKnownDevice(ctx context.Context, userID uint, fingerprint, ip string) (bool, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type LoginHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginHistoryRepository(db *gorm.DB) ILoginHistoryRepository {
+	return &LoginHistoryRepository{db: db}
+}
+
+func (r *LoginHistoryRepository) Create(ctx context.Context, record *model.LoginHistory) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// List 获取用户的登录记录，最新的在前，同时返回总数
+func (r *LoginHistoryRepository) List(ctx context.Context, userID uint, offset, limit int) ([]model.LoginHistory, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.LoginHistory{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var records []model.LoginHistory
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error
+	return records, total, err
+}
+
+// HasSuccessfulLogin 用户是否有成功登录的记录
+func (r *LoginHistoryRepository) HasSuccessfulLogin(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.LoginHistory{}).
+		Where("user_id = ? AND success = ?", userID, true).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// IsKnownDevice 用户是否曾经在该 IP 上用该设备成功登录过，设备或 IP 任一变化都视为新设备
+func (r *LoginHistoryRepository) IsKnownDevice(ctx context.Context, userID uint, fingerprint, ip string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.LoginHistory{}).
+		Where("user_id = ? AND success = ?", userID, true).
+		Where("device_fingerprint = ? AND ip = ?", fingerprint, ip).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// Purge 删除 before 之前的登录记录
+func (r *LoginHistoryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&model.LoginHistory{})
+	return result.RowsAffected, result.Error
+}
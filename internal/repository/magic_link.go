@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IMagicLinkRepository interface {
+	Create(ctx context.Context, token *model.MagicLinkToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateUserTokens(ctx context.Context, userID uint) error
+}
+
+type MagicLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkRepository(db *gorm.DB) IMagicLinkRepository {
+	return &MagicLinkRepository{db: db}
+}
+
+func (r *MagicLinkRepository) Create(ctx context.Context, token *model.MagicLinkToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *MagicLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	var token model.MagicLinkToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed 将令牌标记为已使用，返回 false 表示令牌已被使用
+func (r *MagicLinkRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateUserTokens 使用户所有未使用的令牌失效
+func (r *MagicLinkRepository) InvalidateUserTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
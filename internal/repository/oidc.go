@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IOIDCRepository interface {
+	CreateClient(ctx context.Context, client *model.OIDCClient) error
+	GetClient(ctx context.Context, clientID string) (*model.OIDCClient, error)
+	ListClients(ctx context.Context) ([]model.OIDCClient, error)
+	DeleteClient(ctx context.Context, clientID string) (bool, error)
+	GetConsent(ctx context.Context, userID uint, clientID string) (*model.OIDCConsent, error)
+	SaveConsent(ctx context.Context, userID uint, clientID string, scopes string) error
+	GetUserConsents(ctx context.Context, userID uint) ([]model.OIDCConsent, error)
+	DeleteConsent(ctx context.Context, userID uint, clientID string) (bool, error)
+	CreateCode(ctx context.Context, code *model.OIDCAuthorizationCode) error
+	ConsumeCode(ctx context.Context, codeHash string) (*model.OIDCAuthorizationCode, error)
+}
+
+type OIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) IOIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) CreateClient(ctx context.Context, client *model.OIDCClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// GetClient 客户端不存在时返回 nil, nil
+func (r *OIDCRepository) GetClient(ctx context.Context, clientID string) (*model.OIDCClient, error) {
+	var client model.OIDCClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *OIDCRepository) ListClients(ctx context.Context) ([]model.OIDCClient, error) {
+	var clients []model.OIDCClient
+	err := r.db.WithContext(ctx).Order("id").Find(&clients).Error
+	return clients, err
+}
+
+// DeleteClient 删除客户端及所有用户对它的授权记录，返回 false 表示客户端不存在
+func (r *OIDCRepository) DeleteClient(ctx context.Context, clientID string) (bool, error) {
+	var deleted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("client_id = ?", clientID).Delete(&model.OIDCClient{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected > 0
+		if err := tx.Unscoped().Where("client_id = ?", clientID).Delete(&model.OIDCConsent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("client_id = ?", clientID).Delete(&model.OIDCAuthorizationCode{}).Error
+	})
+	return deleted, err
+}
+
+// GetConsent 未授权时返回 nil, nil
+func (r *OIDCRepository) GetConsent(ctx context.Context, userID uint, clientID string) (*model.OIDCConsent, error) {
+	var consent model.OIDCConsent
+	err := r.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// SaveConsent 新建或覆盖用户对客户端的授权
+func (r *OIDCRepository) SaveConsent(ctx context.Context, userID uint, clientID string, scopes string) error {
+	consent, err := r.GetConsent(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+	if consent == nil {
+		return r.db.WithContext(ctx).Create(&model.OIDCConsent{UserID: userID, ClientID: clientID, Scopes: scopes}).Error
+	}
+	return r.db.WithContext(ctx).Model(consent).Update("scopes", scopes).Error
+}
+
+func (r *OIDCRepository) GetUserConsents(ctx context.Context, userID uint) ([]model.OIDCConsent, error) {
+	var consents []model.OIDCConsent
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&consents).Error
+	return consents, err
+}
+
+// DeleteConsent 撤销授权，物理删除以便之后重新授权，返回 false 表示未授权过
+func (r *OIDCRepository) DeleteConsent(ctx context.Context, userID uint, clientID string) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&model.OIDCConsent{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateCode 保存授权码，同时清理已过期的授权码
+func (r *OIDCRepository) CreateCode(ctx context.Context, code *model.OIDCAuthorizationCode) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("expired_at < ?", time.Now()).Delete(&model.OIDCAuthorizationCode{}).Error; err != nil {
+		return err
+	}
+	return db.Create(code).Error
+}
+
+// ConsumeCode 取出并删除授权码，不存在或已被使用时返回 nil, nil
+func (r *OIDCRepository) ConsumeCode(ctx context.Context, codeHash string) (*model.OIDCAuthorizationCode, error) {
+	var code model.OIDCAuthorizationCode
+	err := r.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.OIDCAuthorizationCode{}, code.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &code, nil
+}
@@ -5,6 +5,7 @@ import (
 	"ddup-apis/internal/errors"
 	"ddup-apis/internal/model"
 	stderrors "errors"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -149,6 +150,46 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
 	return orgs, err
 }
 
+// List 按名称或显示名称搜索组织，按创建时间倒序，同时返回总数
+func (r *OrganizationRepository) List(ctx context.Context, query string, offset, limit int) ([]model.Organization, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Organization{})
+	if query != "" {
+		like := "%" + strings.ToLower(query) + "%"
+		db = db.Where("name LIKE ? OR LOWER(display_name) LIKE ?", like, like)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orgs []model.Organization
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&orgs).Error
+	return orgs, total, err
+}
+
+// CountMembers 统计多个组织的成员数
+func (r *OrganizationRepository) CountMembers(ctx context.Context, orgIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		OrganizationID uint
+		Count          int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.OrganizationMember{}).
+		Select("organization_id, COUNT(*) AS count").
+		Where("organization_id IN ?", orgIDs).
+		Group("organization_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.OrganizationID] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *OrganizationRepository) WithTransaction(tx *gorm.DB) *OrganizationRepository {
 	return &OrganizationRepository{db: tx}
 }
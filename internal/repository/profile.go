@@ -3,10 +3,44 @@ package repository
 import (
 	"context"
 	"ddup-apis/internal/model"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ProfileViewer 查看资料项的访问者，零值表示未登录且没有分享令牌的访客
+type ProfileViewer struct {
+	UserID         uint   // 访问者的用户 ID，未登录时为 0
+	ShareTokenHash string // 通过分享链接访问时的令牌摘要
+}
+
+// VisibleTo 资料项可见性的统一判断，所有读取他人资料的查询都应使用该条件：
+// 本人可见全部资料项；public 所有人可见；organization 与本人同属一个组织的用户可见；
+// audience 仅指定的用户可见；unlisted 只能通过未过期的分享链接访问
+func VisibleTo(viewer ProfileViewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond := db.Session(&gorm.Session{NewDB: true}).Where("profiles.visibility = ?", model.VisibilityPublic)
+		if viewer.UserID != 0 {
+			cond = cond.
+				Or("profiles.user_id = ?", viewer.UserID).
+				Or(`profiles.visibility = ? AND EXISTS (
+					SELECT 1 FROM organization_members om
+					JOIN organization_members vm ON vm.organization_id = om.organization_id
+					WHERE om.user_id = profiles.user_id AND vm.user_id = ?
+					AND om.deleted_at IS NULL AND vm.deleted_at IS NULL)`, model.VisibilityOrganization, viewer.UserID).
+				Or(`profiles.visibility = ? AND EXISTS (
+					SELECT 1 FROM profile_audiences pa
+					WHERE pa.profile_id = profiles.id AND pa.user_id = ?)`, model.VisibilityAudience, viewer.UserID)
+		}
+		if viewer.ShareTokenHash != "" {
+			cond = cond.Or("profiles.visibility = ? AND profiles.share_token_hash = ? AND (profiles.share_expires_at IS NULL OR profiles.share_expires_at > ?)",
+				model.VisibilityUnlisted, viewer.ShareTokenHash, time.Now())
+		}
+		return db.Where(cond)
+	}
+}
+
 type ProfileRepository struct {
 	db *gorm.DB
 }
@@ -25,17 +59,41 @@ func (r *ProfileRepository) GetByID(ctx context.Context, id uint) (*model.Profil
 	return &profile, err
 }
 
-func (r *ProfileRepository) GetByUserID(ctx context.Context, userID uint) ([]model.Profile, error) {
-	var profiles []model.Profile
-	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
-		Order("display_order asc").Find(&profiles).Error
-	return profiles, err
+// GetVisible 获取访问者可见的资料项，不存在或不可见时返回 nil
+func (r *ProfileRepository) GetVisible(ctx context.Context, id uint, viewer ProfileViewer) (*model.Profile, error) {
+	var profile model.Profile
+	err := r.db.WithContext(ctx).Scopes(VisibleTo(viewer)).First(&profile, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
 }
 
-func (r *ProfileRepository) GetByType(ctx context.Context, userID uint, profileType string) ([]model.Profile, error) {
+// GetShared 通过分享令牌摘要获取 unlisted 资料项，令牌无效或已过期时返回 nil
+func (r *ProfileRepository) GetShared(ctx context.Context, tokenHash string) (*model.Profile, error) {
+	var profile model.Profile
+	err := r.db.WithContext(ctx).Scopes(VisibleTo(ProfileViewer{ShareTokenHash: tokenHash})).
+		Where("profiles.share_token_hash = ?", tokenHash).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListVisible 获取用户对访问者可见的资料项，profileType 为空时返回所有类型，按显示顺序排列
+func (r *ProfileRepository) ListVisible(ctx context.Context, ownerID uint, profileType string, viewer ProfileViewer) ([]model.Profile, error) {
 	var profiles []model.Profile
-	err := r.db.WithContext(ctx).Where("user_id = ? AND type = ?", userID, profileType).
-		Order("display_order asc").Find(&profiles).Error
+	query := r.db.WithContext(ctx).Scopes(VisibleTo(viewer)).Where("profiles.user_id = ?", ownerID)
+	if profileType != "" {
+		query = query.Where("profiles.type = ?", profileType)
+	}
+	err := query.Order("display_order asc, id asc").Find(&profiles).Error
 	return profiles, err
 }
 
@@ -43,8 +101,54 @@ func (r *ProfileRepository) Update(ctx context.Context, profile *model.Profile)
 	return r.db.WithContext(ctx).Save(profile).Error
 }
 
+// Delete 删除资料项及其可见名单和附件，未完成的分片上传标记为过期，文件由后台任务清理
 func (r *ProfileRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Profile{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("profile_id = ?", id).Delete(&model.ProfileAudience{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("profile_id = ?", id).Delete(&model.ProfileAttachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.AttachmentUpload{}).Where("profile_id = ?", id).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Profile{}, id).Error
+	})
+}
+
+// SetShareToken 设置或清除（tokenHash 为空）资料项的分享令牌
+func (r *ProfileRepository) SetShareToken(ctx context.Context, id uint, tokenHash string, expiresAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Profile{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"share_token_hash": tokenHash, "share_expires_at": expiresAt}).Error
+}
+
+// GetAudience 获取资料项的可见用户，按添加顺序排列
+func (r *ProfileRepository) GetAudience(ctx context.Context, profileID uint) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Joins("JOIN profile_audiences ON profile_audiences.user_id = users.id").
+		Where("profile_audiences.profile_id = ?", profileID).
+		Order("profile_audiences.id").Find(&users).Error
+	return users, err
+}
+
+// ReplaceAudience 用 userIDs 替换资料项的可见用户
+func (r *ProfileRepository) ReplaceAudience(ctx context.Context, profileID uint, userIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("profile_id = ?", profileID).Delete(&model.ProfileAudience{}).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+		audience := make([]model.ProfileAudience, 0, len(userIDs))
+		for _, id := range userIDs {
+			audience = append(audience, model.ProfileAudience{ProfileID: profileID, UserID: id})
+		}
+		return tx.Create(&audience).Error
+	})
 }
 
 func (r *ProfileRepository) UpdateDisplayOrder(ctx context.Context, items []struct {
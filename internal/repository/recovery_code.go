@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IRecoveryCodeRepository interface {
+	ReplaceUserCodes(ctx context.Context, userID uint, codeHashes []string) error
+	UseCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	DeleteUserCodes(ctx context.Context, userID uint) error
+}
+
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) IRecoveryCodeRepository {
+	return &RecoveryCodeRepository{db: db}
+}
+
+// ReplaceUserCodes 删除用户原有的恢复码并保存新的一组
+func (r *RecoveryCodeRepository) ReplaceUserCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseCode 使用一个恢复码，返回 false 表示恢复码不存在或已使用
+func (r *RecoveryCodeRepository) UseCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RecoveryCodeRepository) DeleteUserCodes(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -10,9 +10,19 @@ import (
 
 type ISessionRepository interface {
 	CreateSession(ctx context.Context, session *model.Session) error
-	GetSessionByToken(ctx context.Context, token string) (*model.Session, error)
-	InvalidateSession(ctx context.Context, token string) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	GetSessionByID(ctx context.Context, id uint) (*model.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*model.Session, error)
+	GetUserSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	RotateSession(ctx context.Context, id uint) (bool, error)
+	TouchSession(ctx context.Context, id uint, clientIP string, interval time.Duration) error
+	InvalidateSession(ctx context.Context, tokenHash string) error
+	InvalidateSessionFamily(ctx context.Context, familyID string) error
 	InvalidateUserSessions(ctx context.Context, userID uint) error
+	InvalidateUserSessionsExcept(ctx context.Context, userID uint, keepID uint) error
+	InvalidateExcessSessions(ctx context.Context, userID uint, clientID string, keep int) error
+	InvalidateClientSessions(ctx context.Context, clientID string, userID uint) error
+	PurgeSessions(ctx context.Context, invalidBefore time.Time) (int64, error)
 }
 
 type SessionRepository struct {
@@ -27,16 +37,74 @@ func (r *SessionRepository) CreateSession(ctx context.Context, session *model.Se
 	return r.db.WithContext(ctx).Create(session).Error
 }
 
-func (r *SessionRepository) GetSessionByToken(ctx context.Context, token string) (*model.Session, error) {
+func (r *SessionRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
 	var session model.Session
-	err := r.db.WithContext(ctx).Where("token = ?", token).First(&session).Error
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
 	return &session, err
 }
 
-func (r *SessionRepository) InvalidateSession(ctx context.Context, token string) error {
+func (r *SessionRepository) GetSessionByID(ctx context.Context, id uint) (*model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).First(&session, id).Error
+	return &session, err
+}
+
+// GetSessionByRefreshToken 按 refresh token 摘要查找会话，包含已失效的会话，以便识别重用
+func (r *SessionRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).Unscoped().Where("refresh_token = ?", refreshTokenHash).First(&session).Error
+	return &session, err
+}
+
+// GetUserSessions 获取用户当前有效的会话，最近活跃的在前
+func (r *SessionRepository) GetUserSessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND is_valid = ? AND refresh_expired_at > ?", userID, true, time.Now()).
+		Order("COALESCE(last_seen_at, created_at) desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RotateSession 将会话标记为已轮换，返回 false 表示会话已被并发轮换或失效
+func (r *SessionRepository) RotateSession(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("id = ? AND is_valid = ?", id, true).
+		Updates(map[string]interface{}{
+			"is_valid":   false,
+			"rotated_at": now,
+			"deleted_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// TouchSession 更新会话的最后活跃时间和 IP，距上次更新不足 interval 时跳过以减少写入
+func (r *SessionRepository) TouchSession(ctx context.Context, id uint, clientIP string, interval time.Duration) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).Model(&model.Session{}).
-		Where("token = ?", token).
+		Where("id = ? AND (last_seen_at IS NULL OR last_seen_at < ?)", id, now.Add(-interval)).
+		Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"client_ip":    clientIP,
+		}).Error
+}
+
+func (r *SessionRepository) InvalidateSession(ctx context.Context, tokenHash string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("token_hash = ?", tokenHash).
+		Updates(map[string]interface{}{
+			"is_valid":   false,
+			"deleted_at": now,
+		}).Error
+}
+
+// InvalidateSessionFamily 使同一登录轮换链上的所有会话失效
+func (r *SessionRepository) InvalidateSessionFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("family_id = ? AND deleted_at IS NULL", familyID).
 		Updates(map[string]interface{}{
 			"is_valid":   false,
 			"deleted_at": now,
@@ -52,3 +120,57 @@ func (r *SessionRepository) InvalidateUserSessions(ctx context.Context, userID u
 			"deleted_at": now,
 		}).Error
 }
+
+// InvalidateUserSessionsExcept 使用户除 keepID 以外的所有会话失效
+func (r *SessionRepository) InvalidateUserSessionsExcept(ctx context.Context, userID uint, keepID uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("user_id = ? AND id <> ? AND deleted_at IS NULL", userID, keepID).
+		Updates(map[string]interface{}{
+			"is_valid":   false,
+			"deleted_at": now,
+		}).Error
+}
+
+// InvalidateExcessSessions 只保留用户最新的 keep 个有效会话，其余的失效，refresh token 已过期的会话不计入。
+// ddup 自身的登录会话和授权给各个应用的会话分别计数，管理员代登录的会话不计入
+func (r *SessionRepository) InvalidateExcessSessions(ctx context.Context, userID uint, clientID string, keep int) error {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("user_id = ? AND client_id = ? AND is_valid = ? AND impersonator_id IS NULL", userID, clientID, true).
+		Where("refresh_expired_at > ?", time.Now()).
+		Order("id desc").
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) <= keep {
+		return err
+	}
+
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("id IN ?", ids[keep:]).
+		Updates(map[string]interface{}{
+			"is_valid":   false,
+			"deleted_at": now,
+		}).Error
+}
+
+// InvalidateClientSessions 撤销授权给应用的会话，userID 为 0 时撤销所有用户的
+func (r *SessionRepository) InvalidateClientSessions(ctx context.Context, clientID string, userID uint) error {
+	db := r.db.WithContext(ctx).Model(&model.Session{}).Where("client_id = ? AND is_valid = ?", clientID, true)
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	return db.Updates(map[string]interface{}{
+		"is_valid":   false,
+		"deleted_at": time.Now(),
+	}).Error
+}
+
+// PurgeSessions 物理删除 refresh token 已过期的会话，以及在 invalidBefore 之前失效的会话。
+// 失效的会话保留一段时间，以便识别 refresh token 重用
+func (r *SessionRepository) PurgeSessions(ctx context.Context, invalidBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("refresh_expired_at < ? OR (is_valid = ? AND updated_at < ?)", time.Now(), false, invalidBefore).
+		Delete(&model.Session{})
+	return result.RowsAffected, result.Error
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"ddup-apis/internal/model"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,10 +15,26 @@ type IUserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, id uint, updates map[string]interface{}) error
-	Delete(ctx context.Context, id uint) error
+	Purge(ctx context.Context, id uint) error
 	UpdatePassword(ctx context.Context, id uint, hashedPassword string) error
 	UpdateLastLogin(ctx context.Context, id uint) error
+	IncrementLoginAttempts(ctx context.Context, id uint) (int, error)
+	AdvanceTwoFactorStep(ctx context.Context, id uint, step int64) (bool, error)
+	List(ctx context.Context, filter *UserFilter) ([]model.User, int64, error)
+	CountByRole(ctx context.Context, role model.UserRole) (int64, error)
+	ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]model.User, error)
+	ScheduleLegacyDeletions(ctx context.Context, at time.Time) (int64, error)
+}
+
+// UserFilter 管理员查询用户的条件，零值字段不参与过滤
+type UserFilter struct {
+	Query  string // 匹配用户名、昵称或邮箱
+	Status model.UserStatus
+	Role   model.UserRole
+	Offset int
+	Limit  int
 }
 
 type UserRepository struct {
@@ -55,12 +73,141 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return &user, nil
 }
 
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) Update(ctx context.Context, id uint, updates map[string]interface{}) error {
 	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.User{}, id).Error
+// userOwnedModels 随账号一起彻底删除的数据，均通过 user_id 关联到用户
+var userOwnedModels = []interface{}{
+	&model.Profile{},
+	&model.ProfileAudience{},
+	&model.OrganizationMember{},
+	&model.Session{},
+	&model.PersonalAccessToken{},
+	&model.RecoveryCode{},
+	&model.UserIdentity{},
+	&model.OAuthState{},
+	&model.OIDCConsent{},
+	&model.OIDCAuthorizationCode{},
+	&model.PasswordResetToken{},
+	&model.MagicLinkToken{},
+	&model.EmailVerificationToken{},
+	&model.LoginHistory{},
+}
+
+// auditPersonalKeys 审计事件 metadata 中可能包含个人信息的字段：用户名、邮箱和代登录期间请求的路径（可能包含用户名），
+// 彻底删除账号时从该用户相关的事件中移除
+var auditPersonalKeys = []string{"username", "email", "path"}
+
+// auditFreeTextReasonActions reason 为管理员填写的文本的事件，其他事件的 reason 是固定的原因代码，予以保留
+var auditFreeTextReasonActions = map[model.AuditAction]bool{
+	model.AuditUserStatusChange:   true,
+	model.AuditImpersonationStart: true,
+}
+
+// Purge 彻底删除用户及其所有数据。与该用户相关（受影响或执行操作）的审计事件保留事件类型、时间、
+// 关联 ID 和 metadata 中的状态、角色、原因代码等字段，清除 IP、UA 和 auditPersonalKeys 中的个人信息。
+// 用户名不存在时的登录失败事件无法对应到用户，其中记录的用户名不会清除。删除后用户名和邮箱可以重新注册
+func (r *UserRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 其他用户在该用户资料项上的可见名单通过 profile_id 关联，需在删除资料项前清理
+		if err := tx.Where("profile_id IN (?)", tx.Unscoped().Model(&model.Profile{}).Select("id").Where("user_id = ?", id)).
+			Delete(&model.ProfileAudience{}).Error; err != nil {
+			return err
+		}
+		// 附件需要先删除存储中的文件，这里只做软删除并让分片上传过期，由后台任务清理
+		if err := tx.Where("user_id = ?", id).Delete(&model.ProfileAttachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.AttachmentUpload{}).Where("user_id = ?", id).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+		for _, m := range userOwnedModels {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.AuditEvent{}).
+			Where("user_id = ? OR actor_id = ?", id, id).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := scrubAuditMetadata(tx, id); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.User{}, id).Error
+	})
+}
+
+// scrubAuditMetadata 移除用户相关审计事件 metadata 中的个人信息
+func scrubAuditMetadata(tx *gorm.DB, userID uint) error {
+	var events []model.AuditEvent
+	return tx.Select("id", "action", "metadata").
+		Where("(user_id = ? OR actor_id = ?) AND metadata IS NOT NULL", userID, userID).
+		FindInBatches(&events, 500, func(*gorm.DB, int) error {
+			for _, event := range events {
+				var metadata map[string]json.RawMessage
+				if err := json.Unmarshal(event.Metadata, &metadata); err != nil || metadata == nil {
+					continue
+				}
+				n := len(metadata)
+				for _, key := range auditPersonalKeys {
+					delete(metadata, key)
+				}
+				if auditFreeTextReasonActions[event.Action] {
+					delete(metadata, "reason")
+				}
+				if len(metadata) == n {
+					continue
+				}
+				scrubbed, err := json.Marshal(metadata)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&model.AuditEvent{}).Where("id = ?", event.ID).
+					Update("metadata", json.RawMessage(scrubbed)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// ListDueForPurge 获取宽限期已过的注销账号
+func (r *UserRepository) ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND deletion_scheduled_at <= ?", model.UserStatusDeleted, now).
+		Order("id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// ScheduleLegacyDeletions 为没有计划删除时间的已注销账号补上删除时间；旧版本软删除的账号
+// 同样转为已注销状态并取消软删除，与新注销的账号一样经过宽限期（期间可登录恢复）后才彻底删除
+func (r *UserRepository) ScheduleLegacyDeletions(ctx context.Context, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("deletion_scheduled_at IS NULL AND (status = ? OR deleted_at IS NOT NULL)", model.UserStatusDeleted).
+		Updates(map[string]interface{}{
+			"status":                model.UserStatusDeleted,
+			"deletion_scheduled_at": at,
+			"deleted_at":            nil,
+		})
+	return result.RowsAffected, result.Error
 }
 
 func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
@@ -70,3 +217,60 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hashedPass
 func (r *UserRepository) UpdateLastLogin(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("last_login", time.Now()).Error
 }
+
+// AdvanceTwoFactorStep 仅当 step 大于已使用的最新 TOTP 时间步时更新，返回是否更新成功。
+// 条件更新保证并发请求中同一时间步只有一个能通过
+func (r *UserRepository) AdvanceTwoFactorStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND two_factor_last_step < ?", id, step).
+		Update("two_factor_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// IncrementLoginAttempts 原子地增加登录失败次数，返回增加后的次数
+func (r *UserRepository) IncrementLoginAttempts(ctx context.Context, id uint) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", id).
+			Update("login_attempts", gorm.Expr("login_attempts + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", id).
+			Select("login_attempts").Scan(&attempts).Error
+	})
+	return attempts, err
+}
+
+// List 按条件查询用户，按注册时间倒序，同时返回符合条件的总数
+func (r *UserRepository) List(ctx context.Context, filter *UserFilter) ([]model.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{})
+	if filter.Query != "" {
+		// 只供管理员使用，查询中的 % 和 _ 按通配符处理
+		like := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(nickname) LIKE ? OR email LIKE ?", like, like, like)
+	}
+	if filter.Status != 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []model.User
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error
+	return users, total, err
+}
+
+// CountByRole 统计指定平台角色且可以登录（正常或待验证）的用户数
+func (r *UserRepository) CountByRole(ctx context.Context, role model.UserRole) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("role = ? AND status IN ?", role, []model.UserStatus{model.UserStatusActive, model.UserStatusPending}).
+		Count(&count).Error
+	return count, err
+}
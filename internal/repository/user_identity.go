@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"ddup-apis/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IUserIdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	GetUserIdentities(ctx context.Context, userID uint) ([]model.UserIdentity, error)
+	Delete(ctx context.Context, userID uint, id uint) (bool, error)
+	CreateState(ctx context.Context, state *model.OAuthState) error
+	ConsumeState(ctx context.Context, stateHash string) (*model.OAuthState, error)
+}
+
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) IUserIdentityRepository {
+	return &UserIdentityRepository{db: db}
+}
+
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// GetByProviderSubject 未绑定时返回 nil, nil
+func (r *UserIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepository) GetUserIdentities(ctx context.Context, userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// Delete 解除绑定，物理删除以便之后重新绑定，返回 false 表示记录不存在
+func (r *UserIdentityRepository) Delete(ctx context.Context, userID uint, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserIdentity{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateState 保存授权请求，同时清理已过期的请求
+func (r *UserIdentityRepository) CreateState(ctx context.Context, state *model.OAuthState) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Unscoped().Where("expired_at < ?", time.Now()).Delete(&model.OAuthState{}).Error; err != nil {
+		return err
+	}
+	return db.Create(state).Error
+}
+
+// ConsumeState 取出并删除授权请求，不存在或已被使用时返回 nil, nil
+func (r *UserIdentityRepository) ConsumeState(ctx context.Context, stateHash string) (*model.OAuthState, error) {
+	var state model.OAuthState
+	err := r.db.WithContext(ctx).Where("state_hash = ?", stateHash).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.WithContext(ctx).Unscoped().Delete(&model.OAuthState{}, state.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &state, nil
+}
@@ -0,0 +1,29 @@
+// Package requestctx 在 context 中传递当前请求的元信息，供审计日志等拿不到 gin.Context 的代码使用
+package requestctx
+
+import "context"
+
+// Info 当前请求的元信息，ActorID 在身份验证通过后填入
+type Info struct {
+	RequestID string
+	ClientIP  string
+	UserAgent string
+	ActorID   uint
+	// ImpersonatorID 管理员代登录时为管理员的用户 ID，ActorID 为被代登录的用户
+	ImpersonatorID uint
+}
+
+type infoKey struct{}
+
+// With 返回携带 info 的 context
+func With(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// From 取出请求元信息，不在请求中（如后台任务）时返回空的 Info
+func From(ctx context.Context) *Info {
+	if info, ok := ctx.Value(infoKey{}).(*Info); ok {
+		return info
+	}
+	return &Info{}
+}
@@ -5,15 +5,20 @@ import (
 	"ddup-apis/internal/config"
 	"ddup-apis/internal/db"
 	"ddup-apis/internal/handler"
+	"ddup-apis/internal/mail"
 	"ddup-apis/internal/middleware"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/notify"
+	"ddup-apis/internal/oauth"
 	"ddup-apis/internal/service"
+	"ddup-apis/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter() *gin.Engine {
+func SetupRouter(store storage.Storage) (*gin.Engine, error) {
 	r := gin.Default()
 
 	// 设置 Swagger 信息
@@ -22,24 +27,47 @@ func SetupRouter() *gin.Engine {
 	docs.SwaggerInfo.Schemes = cfg.Swagger.Schemes
 
 	// 添加全局中间件
+	r.Use(middleware.RequestID())
 	r.Use(middleware.Logger())
 	r.Use(middleware.Cors())
 	r.Use(middleware.ErrorHandler())
 	r.Use(gin.Recovery())
 
+	// 初始化邮件发送器
+	mailer, err := mail.NewMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化安全提醒发送器
+	notifier, err := notify.NewNotifier(cfg, mailer)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化第三方登录提供方
+	oauthProviders, err := oauth.NewProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 初始化 services
-	userService := service.NewUserService(db.DB)
-	profileService := service.NewProfileService(db.DB)
+	userService := service.NewUserService(db.DB, mailer, notifier, oauthProviders)
+	profileService := service.NewProfileService(db.DB, store)
 	organizationService := service.NewOrganizationService(db.DB)
 
 	// 初始化 handlers
 	userHandler := handler.NewUserHandler(userService)
 	profileHandler := handler.NewProfileHandler(profileService)
 	healthHandler := handler.NewHealthHandler()
+	jwksHandler := handler.NewJWKSHandler()
 	organizationHandler := handler.NewOrganizationHandler(organizationService, userService)
+	adminHandler := handler.NewAdminHandler(userService, organizationService)
+	oidcHandler := handler.NewOIDCHandler(userService)
 
 	// 健康检查路由（放在 API v1 路由组之外）
 	r.GET("/health", healthHandler.Check)
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 
 	// API v1 路由组
 	v1 := r.Group("/api/v1")
@@ -49,33 +77,93 @@ func SetupRouter() *gin.Engine {
 		{
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
+			auth.POST("/login/2fa", userHandler.LoginTwoFactor)
+			auth.POST("/login/magic-link", userHandler.LoginMagicLink)
+			auth.POST("/magic-link", userHandler.SendMagicLink)
+			auth.POST("/refresh", userHandler.RefreshToken)
 			auth.POST("/logout", userHandler.Logout)
+			auth.POST("/password/forgot", userHandler.ForgotPassword)
+			auth.POST("/password/reset", userHandler.ResetPassword)
+			auth.POST("/email/verify", userHandler.VerifyEmail)
+
+			// 第三方登录
+			auth.GET("/oauth/providers", userHandler.GetOAuthProviders)
+			auth.GET("/oauth/:provider/authorize", userHandler.OAuthAuthorize)
+			auth.POST("/oauth/:provider/callback", userHandler.OAuthCallback)
 		}
 
 		// 用户相关路由
 		users := v1.Group("/users")
 		users.Use(middleware.JWTAuth(userService))
 		{
-			// 用户个人操作
-			users.GET("", userHandler.GetUser)                 // 获取个人信息
-			users.PUT("", userHandler.UpdateUser)              // 更新个人信息
-			users.DELETE("", userHandler.DeleteUser)           // 注销账号
-			users.PUT("/password", userHandler.ChangePassword) // 修改密码
+			// 用户个人信息（个人访问令牌需要 profile 权限）
+			profile := users.Group("", middleware.RequireScope(model.ScopeProfileRead, model.ScopeProfileWrite))
+			profile.GET("", userHandler.GetUser)                                         // 获取个人信息
+			profile.PUT("", middleware.RequireNoImpersonation(), userHandler.UpdateUser) // 更新个人信息（可修改邮箱，不允许代登录执行）
+
+			// 账号安全相关操作只允许登录会话访问，不接受个人访问令牌；修改凭证的操作不允许管理员代登录执行
+			account := users.Group("", middleware.RequireSession())
+			account.DELETE("", middleware.RequireNoImpersonation(), userHandler.DeleteUser)               // 注销账号
+			account.PUT("/password", middleware.RequireNoImpersonation(), userHandler.ChangePassword)     // 修改密码
+			account.DELETE("/password", middleware.RequireNoImpersonation(), userHandler.DisablePassword) // 关闭密码登录
+			account.POST("/email/verification", userHandler.SendEmailVerification)                        // 重新发送验证邮件
+			account.GET("/security-log", userHandler.GetSecurityLog)                                      // 获取安全日志
+			account.GET("/login-history", userHandler.GetLoginHistory)                                    // 获取登录记录
+
+			// 登录设备管理
+			account.GET("/sessions", userHandler.GetSessions)                                                 // 获取登录设备列表
+			account.DELETE("/sessions", middleware.RequireNoImpersonation(), userHandler.RevokeOtherSessions) // 注销其他设备
+			account.DELETE("/sessions/:id", middleware.RequireNoImpersonation(), userHandler.RevokeSession)   // 注销指定设备
+
+			// 两步验证
+			account.POST("/2fa/setup", middleware.RequireNoImpersonation(), userHandler.SetupTwoFactor)     // 获取两步验证密钥
+			account.POST("/2fa/confirm", middleware.RequireNoImpersonation(), userHandler.ConfirmTwoFactor) // 开启两步验证
+			account.POST("/2fa/disable", middleware.RequireNoImpersonation(), userHandler.DisableTwoFactor) // 关闭两步验证
+
+			// 个人访问令牌
+			account.GET("/tokens", userHandler.GetAccessTokens)                                               // 获取访问令牌列表
+			account.POST("/tokens", middleware.RequireNoImpersonation(), userHandler.CreateAccessToken)       // 创建访问令牌
+			account.DELETE("/tokens/:id", middleware.RequireNoImpersonation(), userHandler.DeleteAccessToken) // 删除访问令牌
+
+			// 第三方账号绑定
+			account.GET("/identities", userHandler.GetIdentities)                                                                   // 获取绑定的第三方账号
+			account.POST("/identities/:provider/authorize", middleware.RequireNoImpersonation(), userHandler.LinkIdentityAuthorize) // 发起绑定
+			account.POST("/identities/:provider/callback", middleware.RequireNoImpersonation(), userHandler.LinkIdentityCallback)   // 完成绑定
+			account.DELETE("/identities/:id", middleware.RequireNoImpersonation(), userHandler.UnlinkIdentity)                      // 解除绑定
 		}
 
 		profiles := v1.Group("/profiles")
-		profiles.Use(middleware.JWTAuth(userService))
+		profiles.Use(middleware.JWTAuth(userService), middleware.RequireScope(model.ScopeProfileRead, model.ScopeProfileWrite))
 		{
-			profiles.POST("", profileHandler.CreateProfile)           // 创建个人资料项
-			profiles.GET("", profileHandler.GetProfiles)              // 获取个人资料列表（支持按类型筛选）
-			profiles.PUT("/:id", profileHandler.UpdateProfile)        // 更新个人资料项
-			profiles.DELETE("/:id", profileHandler.DeleteProfile)     // 删除个人资料项
-			profiles.PUT("/order", profileHandler.UpdateDisplayOrder) // 更新显示顺序
+			profiles.POST("", profileHandler.CreateProfile)               // 创建个人资料项
+			profiles.GET("", profileHandler.GetProfiles)                  // 获取个人资料列表（支持按类型筛选）
+			profiles.GET("/schemas", profileHandler.GetSchemas)           // 获取各资料类型的元数据 schema
+			profiles.PUT("/:id", profileHandler.UpdateProfile)            // 更新个人资料项
+			profiles.PATCH("/:id", profileHandler.PatchProfile)           // 部分更新个人资料项（JSON Merge Patch）
+			profiles.DELETE("/:id", profileHandler.DeleteProfile)         // 删除个人资料项
+			profiles.PUT("/order", profileHandler.UpdateDisplayOrder)     // 更新显示顺序
+			profiles.POST("/:id/share", profileHandler.CreateShareLink)   // 生成分享链接（仅 unlisted 资料项）
+			profiles.DELETE("/:id/share", profileHandler.RevokeShareLink) // 撤销分享链接
+			profiles.GET("/:id/audience", profileHandler.GetAudience)     // 获取可见用户
+			profiles.PUT("/:id/audience", profileHandler.SetAudience)     // 设置可见用户
+
+			profiles.GET("/:id/attachments", profileHandler.ListAttachments)                    // 获取附件列表
+			profiles.POST("/:id/attachments", profileHandler.UploadAttachment)                  // 上传附件（multipart）
+			profiles.DELETE("/:id/attachments/:attachment_id", profileHandler.DeleteAttachment) // 删除附件
+			profiles.POST("/:id/attachments/uploads", profileHandler.CreateUpload)              // 发起分片上传
+			profiles.GET("/:id/attachments/uploads/:upload_id", profileHandler.GetUpload)       // 查询分片上传进度
+			profiles.PUT("/:id/attachments/uploads/:upload_id", profileHandler.UploadChunk)     // 上传分片
+			profiles.DELETE("/:id/attachments/uploads/:upload_id", profileHandler.CancelUpload) // 取消分片上传
 		}
 
-		// 组织相关路由
+		// 公开主页、分享链接和附件下载，未登录也可访问（附件下载地址带签名）
+		v1.GET("/u/:username", middleware.OptionalJWTAuth(userService), profileHandler.GetPublicProfile)
+		v1.GET("/shared/:token", profileHandler.GetSharedProfile)
+		v1.GET("/attachments/:id", profileHandler.DownloadAttachment)
+
+		// 组织相关路由（待验证邮箱的账号无法使用）
 		orgs := v1.Group("/organizations")
-		orgs.Use(middleware.JWTAuth(userService))
+		orgs.Use(middleware.JWTAuth(userService), middleware.RequireActiveUser(), middleware.RequireScope(model.ScopeOrgRead, model.ScopeOrgAdmin))
 		{
 			// 组织基本操作
 			orgs.POST("", organizationHandler.CreateOrganization)
@@ -95,10 +183,52 @@ func SetupRouter() *gin.Engine {
 				members.DELETE("/:username", organizationHandler.RemoveOrganizationMember)
 			}
 		}
+
+		// 平台管理路由
+		admin := v1.Group("/admin")
+		admin.Use(middleware.JWTAuth(userService), middleware.RequireSession(), middleware.AdminAuth())
+		{
+			admin.GET("/users", adminHandler.GetUsers)                                         // 查询用户
+			admin.GET("/users/:username", adminHandler.GetUser)                                // 查看用户
+			admin.POST("/users/:username/unlock", adminHandler.UnlockUser)                     // 解锁账号
+			admin.PUT("/users/:username/status", adminHandler.UpdateUserStatus)                // 变更用户状态
+			admin.PUT("/users/:username/role", adminHandler.UpdateUserRole)                    // 变更平台角色
+			admin.POST("/users/:username/logout", adminHandler.ForceLogout)                    // 强制退出
+			admin.DELETE("/users/:username/2fa", adminHandler.ResetTwoFactor)                  // 重置两步验证
+			admin.POST("/users/:username/impersonate", adminHandler.Impersonate)               // 代登录
+			admin.GET("/organizations", adminHandler.GetOrganizations)                         // 查询组织
+			admin.POST("/organizations/:org_name/transfer", adminHandler.TransferOrganization) // 转交组织
+			admin.DELETE("/organizations/:org_name", adminHandler.DeleteOrganization)          // 删除组织
+			admin.GET("/audit-events", adminHandler.GetAuditEvents)                            // 查询审计日志
+		}
+
+		// OIDC 提供方路由，配置 OIDC_ISSUER 后启用
+		if cfg.OIDC.Issuer != "" {
+			r.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+
+			oidc := v1.Group("/oidc")
+			{
+				oidc.POST("/token", oidcHandler.Token)
+				oidc.GET("/userinfo", oidcHandler.UserInfo)
+				oidc.POST("/userinfo", oidcHandler.UserInfo)
+
+				// 授权确认页面调用，需要用户已登录
+				authorize := oidc.Group("/authorize", middleware.JWTAuth(userService), middleware.RequireSession())
+				authorize.GET("", oidcHandler.Authorize)
+				authorize.POST("", middleware.RequireNoImpersonation(), oidcHandler.Approve)
+			}
+
+			users.GET("/oidc/consents", middleware.RequireSession(), userHandler.GetOIDCConsents)                                                      // 获取已授权的应用
+			users.DELETE("/oidc/consents/:client_id", middleware.RequireSession(), middleware.RequireNoImpersonation(), userHandler.RevokeOIDCConsent) // 撤销应用授权
+
+			admin.GET("/oidc/clients", adminHandler.GetOIDCClients)                 // 获取应用列表
+			admin.POST("/oidc/clients", adminHandler.CreateOIDCClient)              // 登记应用
+			admin.DELETE("/oidc/clients/:client_id", adminHandler.DeleteOIDCClient) // 删除应用
+		}
 	}
 
 	// Swagger API 文档路由
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	return r
+	return r, nil
 }
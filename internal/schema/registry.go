@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 文件名格式为 <资料类型>.v<版本>.json。修改已发布的 schema 时新增一个版本的文件而不是改动旧文件，
+// 旧版本继续用于说明按旧版本保存的资料项
+//
+//go:embed profiles/*.json
+var files embed.FS
+
+// Entry 某个资料类型某一版本的 schema
+type Entry struct {
+	Type    string
+	Version int
+	Schema  *Schema
+	Raw     json.RawMessage // schema 原文，原样返回给前端用于渲染表单
+}
+
+// registry 资料类型到各版本 schema 的映射，版本升序排列
+var registry = map[string][]*Entry{}
+
+func init() {
+	names, err := files.ReadDir("profiles")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range names {
+		entry, err := load(f.Name())
+		if err != nil {
+			panic(fmt.Sprintf("加载资料 schema %s 失败: %v", f.Name(), err))
+		}
+		registry[entry.Type] = append(registry[entry.Type], entry)
+	}
+	for _, entries := range registry {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+	}
+}
+
+func load(name string) (*Entry, error) {
+	profileType, version, ok := strings.Cut(strings.TrimSuffix(name, ".json"), ".v")
+	if !ok {
+		return nil, fmt.Errorf("文件名应为 <类型>.v<版本>.json")
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return nil, fmt.Errorf("无效的版本号 %q", version)
+	}
+
+	raw, err := files.ReadFile(path.Join("profiles", name))
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return &Entry{Type: profileType, Version: v, Schema: &s, Raw: raw}, nil
+}
+
+// Latest 获取资料类型的最新版本 schema，没有时返回 nil
+func Latest(profileType string) *Entry {
+	entries := registry[profileType]
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[len(entries)-1]
+}
+
+// Get 获取资料类型指定版本的 schema，没有时返回 nil
+func Get(profileType string, version int) *Entry {
+	for _, entry := range registry[profileType] {
+		if entry.Version == version {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Versions 获取资料类型的所有版本 schema，版本升序排列
+func Versions(profileType string) []*Entry {
+	return registry[profileType]
+}
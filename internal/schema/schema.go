@@ -0,0 +1,151 @@
+// Package schema 资料项元数据的 JSON Schema，每种资料类型一份，按版本保存在 profiles 目录下。
+// 只实现了这些 schema 用到的关键字：type、properties、required、additionalProperties、
+// items、enum、format、minLength、maxLength、maxItems
+package schema
+
+import (
+	"ddup-apis/internal/errors"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema JSON Schema 的子集
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Validate 校验 JSON 文档，返回的字段路径以 path 开头，如 metadata.collaborators[0]
+func (s *Schema) Validate(data json.RawMessage, path string) []errors.FieldError {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []errors.FieldError{{Field: path, Code: "invalid", Message: "不是有效的 JSON"}}
+	}
+	return s.validate(value, path)
+}
+
+func (s *Schema) validate(value interface{}, path string) []errors.FieldError {
+	if s.Type != "" && !matchType(s.Type, value) {
+		return []errors.FieldError{{Field: path, Code: "type", Message: "类型应为 " + s.Type}}
+	}
+
+	var fields []errors.FieldError
+	fail := func(code, format string, args ...interface{}) {
+		fields = append(fields, errors.FieldError{Field: path, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fields = append(fields, errors.FieldError{Field: join(path, name), Code: "required", Message: "不能为空"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fields = append(fields, errors.FieldError{Field: join(path, name), Code: "unknown_field", Message: "该类型的资料不支持此字段"})
+				}
+				continue
+			}
+			fields = append(fields, prop.validate(v[name], join(path, name))...)
+		}
+
+	case []interface{}:
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("max_items", "最多 %d 项", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				fields = append(fields, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("min_length", "长度不能少于 %d 个字符", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("max_length", "长度不能超过 %d 个字符", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+			fail("enum", "只能是 %s", strings.Join(s.Enum, "、"))
+		}
+		if s.Format != "" && !matchFormat(s.Format, v) {
+			fail("format", "格式应为 %s", s.Format)
+		}
+	}
+	return fields
+}
+
+func matchType(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+func matchFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}
+	return true
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
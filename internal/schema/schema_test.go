@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/model"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateFieldPaths(t *testing.T) {
+	project := Latest(string(model.Project))
+	if project == nil {
+		t.Fatal("缺少 project 的 schema")
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []errors.FieldError
+	}{
+		{"合法", `{"client":"某公司","attachments":[{"type":"page","url":"https://example.com/a"}]}`, nil},
+		{"根节点类型错误", `[]`, []errors.FieldError{{Field: "metadata", Code: "type"}}},
+		{"未知字段", `{"budget":1}`, []errors.FieldError{{Field: "metadata.budget", Code: "unknown_field"}}},
+		{"字段类型错误", `{"client":1}`, []errors.FieldError{{Field: "metadata.client", Code: "type"}}},
+		{"数组元素路径", `{"collaborators":["a",2]}`, []errors.FieldError{{Field: "metadata.collaborators[1]", Code: "type"}}},
+		{"嵌套对象缺少必填字段", `{"attachments":[{"url":"https://example.com/a"},{"name":"b"}]}`,
+			[]errors.FieldError{{Field: "metadata.attachments[1].url", Code: "required"}}},
+		{"嵌套对象多个错误按字段名排序", `{"attachments":[{"url":"not a uri","type":"video"}]}`, []errors.FieldError{
+			{Field: "metadata.attachments[0].type", Code: "enum"},
+			{Field: "metadata.attachments[0].url", Code: "format"},
+		}},
+		{"整数", `{"attachments":[{"url":"https://example.com/a","size":1.5}]}`,
+			[]errors.FieldError{{Field: "metadata.attachments[0].size", Code: "type"}}},
+		{"无效的 JSON", `{`, []errors.FieldError{{Field: "metadata", Code: "invalid"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := project.Schema.Validate(json.RawMessage(tt.data), "metadata")
+			if !reflect.DeepEqual(codes(got), codes(tt.want)) {
+				t.Errorf("Validate() = %v, want %v", codes(got), codes(tt.want))
+			}
+		})
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	var s Schema
+	raw := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 3},
+			"tags": {"type": "array", "maxItems": 1},
+			"email": {"type": "string", "format": "email"},
+			"date": {"type": "string", "format": "date"},
+			"extra": {"type": "null"}
+		}
+	}`
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		data string
+		want []errors.FieldError
+	}{
+		{`{"name":"ab","tags":["a"],"email":"a@example.com","date":"2024-01-31"}`, nil},
+		{`{"name":"a"}`, []errors.FieldError{{Field: "name", Code: "min_length"}}},
+		{`{"name":"名字太长"}`, []errors.FieldError{{Field: "name", Code: "max_length"}}},
+		{`{"tags":["a","b"]}`, []errors.FieldError{{Field: "tags", Code: "max_items"}}},
+		{`{"email":"张三 <a@example.com>"}`, []errors.FieldError{{Field: "email", Code: "format"}}},
+		{`{"date":"2024-02-30"}`, []errors.FieldError{{Field: "date", Code: "format"}}},
+		{`{"other":1}`, nil}, // 未设置 additionalProperties 时允许其他字段
+		// 未实现的类型不能当作任意类型放行
+		{`{"extra":null}`, []errors.FieldError{{Field: "extra", Code: "type"}}},
+	}
+	for _, tt := range tests {
+		got := s.Validate(json.RawMessage(tt.data), "")
+		if !reflect.DeepEqual(codes(got), codes(tt.want)) {
+			t.Errorf("Validate(%s) = %v, want %v", tt.data, codes(got), codes(tt.want))
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, profileType := range model.ProfileTypes {
+		if Latest(string(profileType)) == nil {
+			t.Errorf("资料类型 %s 缺少 schema", profileType)
+		}
+	}
+	if Latest("unknown") != nil || Get("unknown", 1) != nil || len(Versions("unknown")) != 0 {
+		t.Error("未知的资料类型不应有 schema")
+	}
+	if Get(string(model.Project), 0) != nil {
+		t.Error("不存在的版本应返回 nil")
+	}
+}
+
+// codes 只比较字段路径和错误码，不比较提示文字
+func codes(fields []errors.FieldError) []errors.FieldError {
+	result := make([]errors.FieldError, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, errors.FieldError{Field: f.Field, Code: f.Code})
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AccessTokenPrefix 个人访问令牌的固定前缀，用于和 JWT 区分
+const AccessTokenPrefix = "ddup_pat_"
+
+// accessTokenTouchInterval 令牌最后使用时间的最小更新间隔
+const accessTokenTouchInterval = time.Minute
+
+// IsAccessToken 判断令牌是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// ValidateAccessToken 校验个人访问令牌，并记录最后使用时间
+func (s *UserService) ValidateAccessToken(ctx context.Context, token string, clientIP string) (*TokenValidationResult, error) {
+	pat, err := s.accessTokenRepo.GetByTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return &TokenValidationResult{Valid: false}, errors.Wrap(err, "查询访问令牌失败")
+	}
+	if pat == nil || pat.Expired() {
+		return &TokenValidationResult{Valid: false}, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, pat.UserID)
+	if err != nil {
+		return &TokenValidationResult{Valid: false}, errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil {
+		return &TokenValidationResult{Valid: false}, nil
+	}
+	if !user.Status.CanLogin() {
+		return &TokenValidationResult{Valid: false}, errUserInactive(user.Status)
+	}
+
+	if err := s.accessTokenRepo.Touch(ctx, pat.ID, clientIP, accessTokenTouchInterval); err != nil {
+		logger.Warn("更新访问令牌使用时间失败", zap.Uint("token_id", pat.ID), zap.Error(err))
+	}
+
+	return &TokenValidationResult{
+		UserID:        user.ID,
+		Username:      user.Username,
+		AccessTokenID: pat.ID,
+		Scopes:        pat.ScopeList(),
+		Status:        user.Status,
+		Role:          user.Role,
+		Valid:         true,
+	}, nil
+}
+
+// CreateAccessToken 创建个人访问令牌，明文令牌只在创建时返回一次
+func (s *UserService) CreateAccessToken(ctx context.Context, userID uint, req *dto.CreateAccessTokenRequest) (*dto.CreateAccessTokenResponse, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !model.TokenScope(scope).Valid() {
+			return nil, errors.New(400, "无效的权限范围: "+scope, nil)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	random, err := utils.GenerateRandomToken(20)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成访问令牌失败")
+	}
+	plain := AccessTokenPrefix + random
+
+	pat := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: utils.HashToken(plain),
+		TokenHint: plain[:len(AccessTokenPrefix)+4],
+		Scopes:    strings.Join(scopes, " "),
+	}
+	if req.ExpiresInDays > 0 {
+		expiredAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		pat.ExpiredAt = &expiredAt
+	}
+
+	if err := s.accessTokenRepo.Create(ctx, pat); err != nil {
+		return nil, errors.Wrap(err, "创建访问令牌失败")
+	}
+
+	s.audit(ctx, model.AuditAccessTokenCreate, userID, map[string]interface{}{"tokenId": pat.ID, "scopes": pat.Scopes})
+	logger.Info("用户创建访问令牌", zap.Uint("user_id", userID), zap.Uint("token_id", pat.ID), zap.String("scopes", pat.Scopes))
+	return &dto.CreateAccessTokenResponse{
+		AccessTokenResponse: toAccessTokenResponse(pat),
+		Token:               plain,
+	}, nil
+}
+
+func (s *UserService) ListAccessTokens(ctx context.Context, userID uint) ([]dto.AccessTokenResponse, error) {
+	tokens, err := s.accessTokenRepo.GetUserTokens(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取访问令牌失败")
+	}
+
+	resp := make([]dto.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		resp = append(resp, toAccessTokenResponse(&tokens[i]))
+	}
+	return resp, nil
+}
+
+func (s *UserService) DeleteAccessToken(ctx context.Context, userID uint, id uint) error {
+	deleted, err := s.accessTokenRepo.Delete(ctx, userID, id)
+	if err != nil {
+		return errors.Wrap(err, "删除访问令牌失败")
+	}
+	if !deleted {
+		return errors.New(404, "访问令牌不存在", nil)
+	}
+	s.audit(ctx, model.AuditAccessTokenRevoke, userID, map[string]interface{}{"tokenId": id})
+	return nil
+}
+
+func toAccessTokenResponse(t *model.PersonalAccessToken) dto.AccessTokenResponse {
+	return dto.AccessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		TokenHint:  t.TokenHint,
+		Scopes:     strings.Fields(t.Scopes),
+		ExpiredAt:  t.ExpiredAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		CreatedAt:  t.CreatedAt,
+	}
+}
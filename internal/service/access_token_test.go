@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenScopes(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	user := createTestUser(t, db, "alice")
+
+	if _, err := s.CreateAccessToken(ctx, user.ID, &dto.CreateAccessTokenRequest{Name: "ci", Scopes: []string{"profile:read", "admin"}}); errorCode(err) != 400 {
+		t.Errorf("无效的权限范围: error = %v, want 400", err)
+	}
+
+	created, err := s.CreateAccessToken(ctx, user.ID, &dto.CreateAccessTokenRequest{
+		Name:          " ci ",
+		Scopes:        []string{"profile:write", "org:read", "profile:write"},
+		ExpiresInDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	if !IsAccessToken(created.Token) || !strings.HasPrefix(created.Token, created.TokenHint) || created.Name != "ci" {
+		t.Errorf("创建的令牌 = %+v", created)
+	}
+	if !reflect.DeepEqual(created.Scopes, []string{"profile:write", "org:read"}) {
+		t.Errorf("Scopes = %v, 应去重并保持顺序", created.Scopes)
+	}
+	if created.ExpiredAt == nil || created.ExpiredAt.Before(time.Now().AddDate(0, 0, 29)) {
+		t.Errorf("ExpiredAt = %v", created.ExpiredAt)
+	}
+
+	// 写权限隐含读权限，未授予的权限不可用
+	result, err := s.ValidateAccessToken(ctx, created.Token, "10.0.0.1")
+	if err != nil || !result.Valid || result.UserID != user.ID || result.AccessTokenID != created.ID || result.SessionID != 0 {
+		t.Fatalf("ValidateAccessToken() = %+v, %v", result, err)
+	}
+	for scope, want := range map[model.TokenScope]bool{
+		model.ScopeProfileRead:  true,
+		model.ScopeProfileWrite: true,
+		model.ScopeOrgRead:      true,
+		model.ScopeOrgAdmin:     false,
+	} {
+		if got := model.HasScope(result.Scopes, scope); got != want {
+			t.Errorf("HasScope(%s) = %v, want %v", scope, got, want)
+		}
+	}
+	tokens, err := s.ListAccessTokens(ctx, user.ID)
+	if err != nil || len(tokens) != 1 || tokens[0].LastUsedAt == nil || tokens[0].LastUsedIP != "10.0.0.1" {
+		t.Errorf("ListAccessTokens() = %+v, %v", tokens, err)
+	}
+
+	// 过期的令牌、停用用户的令牌都不可用
+	expired, err := s.CreateAccessToken(ctx, user.ID, &dto.CreateAccessTokenRequest{Name: "old", Scopes: []string{"profile:read"}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&model.PersonalAccessToken{}).Where("id = ?", expired.ID).Update("expired_at", time.Now().Add(-time.Second))
+	if result, _ := s.ValidateAccessToken(ctx, expired.Token, ""); result.Valid {
+		t.Error("过期的令牌应无效")
+	}
+	if result, _ := s.ValidateAccessToken(ctx, AccessTokenPrefix+"forged", ""); result.Valid {
+		t.Error("伪造的令牌应无效")
+	}
+	db.Model(user).Update("status", model.UserStatusSuspended)
+	if result, err := s.ValidateAccessToken(ctx, created.Token, ""); result.Valid || errorCode(err) != 403 {
+		t.Errorf("用户暂停后 ValidateAccessToken() = %+v, %v", result, err)
+	}
+	db.Model(user).Update("status", model.UserStatusActive)
+
+	// 只能删除自己的令牌，删除后立即失效
+	other := createTestUser(t, db, "bob")
+	if err := s.DeleteAccessToken(ctx, other.ID, created.ID); errorCode(err) != 404 {
+		t.Errorf("删除其他用户的令牌: error = %v, want 404", err)
+	}
+	if err := s.DeleteAccessToken(ctx, user.ID, created.ID); err != nil {
+		t.Fatalf("DeleteAccessToken() error = %v", err)
+	}
+	if result, _ := s.ValidateAccessToken(ctx, created.Token, ""); result.Valid {
+		t.Error("删除后令牌应无效")
+	}
+}
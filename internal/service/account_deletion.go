@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// accountPurgeBatchSize 每轮最多彻底删除的账号数
+const accountPurgeBatchSize = 100
+
+// DeleteUser 注销账号：立即结束所有会话并删除访问令牌，宽限期结束后由 PeriodicAccountPurge 彻底删除
+func (s *UserService) DeleteUser(ctx context.Context, id uint) (*dto.AccountDeletionResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return nil, errors.New(404, "用户不存在", err)
+	}
+	if user.Status == model.UserStatusDeleted {
+		return nil, errors.New(400, "账号已注销", nil)
+	}
+	if err := s.checkLastAdmin(ctx, user, "不能注销最后一个平台管理员"); err != nil {
+		return nil, err
+	}
+
+	cfg := config.GetConfig().AccountDeletion
+	scheduledAt, err := s.scheduleDeletion(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, model.AuditUserDelete, user.ID, map[string]interface{}{"deletionScheduledAt": scheduledAt})
+	logger.Info("用户已注销账号", zap.Uint("user_id", user.ID), zap.Time("deletion_scheduled_at", scheduledAt))
+	return &dto.AccountDeletionResponse{
+		DeletionScheduledAt: scheduledAt,
+		RestoreOnLogin:      cfg.RestoreOnLogin,
+	}, nil
+}
+
+// scheduleDeletion 将账号标记为已注销并计划删除时间，同时撤销所有会话和访问令牌
+func (s *UserService) scheduleDeletion(ctx context.Context, user *model.User) (time.Time, error) {
+	scheduledAt := time.Now().Add(config.GetConfig().AccountDeletion.GracePeriod)
+	if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{
+		"status":                model.UserStatusDeleted,
+		"deletion_scheduled_at": scheduledAt,
+	}); err != nil {
+		return time.Time{}, errors.Wrap(err, "注销账号失败")
+	}
+	if err := s.sessionRepo.InvalidateUserSessions(ctx, user.ID); err != nil {
+		return time.Time{}, errors.Wrap(err, "结束用户会话失败")
+	}
+	if err := s.accessTokenRepo.DeleteUserTokens(ctx, user.ID); err != nil {
+		return time.Time{}, errors.Wrap(err, "删除访问令牌失败")
+	}
+	return scheduledAt, nil
+}
+
+// checkLastAdmin user 是最后一个可以登录的平台管理员时返回 message 对应的错误。
+// 暂停、停用或注销的管理员无法登录，不计入管理员人数
+func (s *UserService) checkLastAdmin(ctx context.Context, user *model.User, message string) error {
+	if user.Role != model.UserRoleAdmin || !user.Status.CanLogin() {
+		return nil
+	}
+	count, err := s.userRepo.CountByRole(ctx, model.UserRoleAdmin)
+	if err != nil {
+		return errors.Wrap(err, "查询管理员失败")
+	}
+	if count <= 1 {
+		return errors.New(400, message, nil)
+	}
+	return nil
+}
+
+// canLoginOrRestore 账号可以登录，或处于注销宽限期且配置了登录时恢复
+func canLoginOrRestore(user *model.User) bool {
+	if user.Status.CanLogin() {
+		return true
+	}
+	return user.Status == model.UserStatusDeleted &&
+		config.GetConfig().AccountDeletion.RestoreOnLogin &&
+		user.DeletionScheduledAt != nil &&
+		time.Now().Before(*user.DeletionScheduledAt)
+}
+
+// restoreAccount 宽限期内重新登录时恢复账号，邮箱未验证的账号恢复为待验证状态
+func (s *UserService) restoreAccount(ctx context.Context, user *model.User) error {
+	status := model.UserStatusActive
+	if user.Email != nil && !user.EmailVerified() {
+		status = model.UserStatusPending
+	}
+	if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{
+		"status":                status,
+		"deletion_scheduled_at": nil,
+	}); err != nil {
+		return errors.Wrap(err, "恢复账号失败")
+	}
+
+	user.Status = status
+	user.DeletionScheduledAt = nil
+	s.audit(ctx, model.AuditUserRestore, user.ID, nil)
+	logger.Info("用户登录后已恢复注销的账号", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// PeriodicAccountPurge 定期彻底删除宽限期已过的注销账号
+func PeriodicAccountPurge(db *gorm.DB, interval, gracePeriod time.Duration) {
+	userRepo := repository.NewUserRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	purge := func() {
+		ctx := context.Background()
+
+		// 注销宽限期上线前已注销或软删除的账号没有计划删除时间，从现在开始计算宽限期
+		if scheduled, err := userRepo.ScheduleLegacyDeletions(ctx, time.Now().Add(gracePeriod)); err != nil {
+			logger.Error("设置注销账号删除时间失败", zap.Error(err))
+		} else if scheduled > 0 {
+			logger.Info("已为注销账号设置删除时间", zap.Int64("count", scheduled))
+		}
+
+		users, err := userRepo.ListDueForPurge(ctx, time.Now(), accountPurgeBatchSize)
+		if err != nil {
+			logger.Error("查询待删除账号失败", zap.Error(err))
+			return
+		}
+		for _, user := range users {
+			if err := purgeAccount(ctx, userRepo, orgRepo, auditRepo, &user); err != nil {
+				logger.Error("彻底删除账号失败", zap.Uint("user_id", user.ID), zap.Error(err))
+				continue
+			}
+			logger.Info("已彻底删除账号", zap.Uint("user_id", user.ID))
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		purge()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
+
+// purgeAccount 为用户管理的组织安排新的管理员，然后删除账号及其所有数据
+func purgeAccount(ctx context.Context, userRepo repository.IUserRepository, orgRepo *repository.OrganizationRepository, auditRepo repository.IAuditRepository, user *model.User) error {
+	orgs, err := orgRepo.GetUserOrganizations(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := handOverOrganization(ctx, orgRepo, auditRepo, org.ID, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := userRepo.Purge(ctx, user.ID); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditRepo, &model.AuditEvent{Action: model.AuditUserPurge, UserID: user.ID}, nil)
+	return nil
+}
+
+// handOverOrganization 被删除的用户是组织唯一的管理员时，将最早加入的成员设为管理员；
+// 组织没有其他成员时删除组织
+func handOverOrganization(ctx context.Context, orgRepo *repository.OrganizationRepository, auditRepo repository.IAuditRepository, orgID, userID uint) error {
+	admins, err := orgRepo.GetMembersByRole(ctx, orgID, "admin")
+	if err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		if admin.UserID != userID {
+			return nil
+		}
+	}
+
+	members, err := orgRepo.GetMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	var successor *model.OrganizationMember
+	for i := range members {
+		if members[i].UserID != userID && (successor == nil || members[i].CreatedAt.Before(successor.CreatedAt)) {
+			successor = &members[i]
+		}
+	}
+
+	if successor == nil {
+		if err := orgRepo.Delete(ctx, orgID); err != nil {
+			return err
+		}
+		recordAudit(ctx, auditRepo, &model.AuditEvent{
+			Action:         model.AuditOrgDelete,
+			UserID:         userID,
+			OrganizationID: orgID,
+		}, map[string]interface{}{"reason": "account_purge"})
+		return nil
+	}
+
+	if err := orgRepo.UpdateMember(ctx, orgID, successor.UserID, map[string]interface{}{"role": "admin"}); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditRepo, &model.AuditEvent{
+		Action:         model.AuditOrgMemberRoleChange,
+		UserID:         successor.UserID,
+		OrganizationID: orgID,
+	}, map[string]interface{}{"from": successor.Role, "to": "admin", "reason": "account_purge"})
+	return nil
+}
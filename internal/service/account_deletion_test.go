@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeleteUserGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	var cfg config.Config
+	s, db, _ := newTestAccountService(t, func(c *config.Config) {
+		c.AccountDeletion.GracePeriod = 30 * 24 * time.Hour
+		c.AccountDeletion.RestoreOnLogin = true
+		cfg = *c
+	})
+	const password = "Xq7#Str0ng!pass"
+	alice := createTestUser(t, db, "alice")
+	setTestPassword(t, db, alice, password)
+	login := func(username string) (*dto.LoginResponse, error) {
+		return s.Login(ctx, &dto.LoginRequest{Username: username, Password: password}, &dto.ClientInfo{})
+	}
+	reload := func(id uint) *model.User {
+		t.Helper()
+		var user model.User
+		if err := db.First(&user, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &user
+	}
+
+	session, err := login("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateAccessToken(ctx, alice.ID, &dto.CreateAccessTokenRequest{Name: "ci", Scopes: []string{"profile:read"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 注销后立即结束会话并删除访问令牌，宽限期结束后才彻底删除
+	resp, err := s.DeleteUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if !resp.RestoreOnLogin || resp.DeletionScheduledAt.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Errorf("DeleteUser() = %+v", resp)
+	}
+	if user := reload(alice.ID); user.Status != model.UserStatusDeleted || user.DeletionScheduledAt == nil {
+		t.Errorf("注销后 status = %s, deletion_scheduled_at = %v", user.Status, user.DeletionScheduledAt)
+	}
+	if result, _ := s.ValidateToken(session.Token); result.Valid {
+		t.Error("注销后会话应失效")
+	}
+	var tokens int64
+	db.Model(&model.PersonalAccessToken{}).Where("user_id = ?", alice.ID).Count(&tokens)
+	if tokens != 0 {
+		t.Errorf("注销后仍有 %d 个访问令牌", tokens)
+	}
+	if _, err := s.DeleteUser(ctx, alice.ID); errorCode(err) != 400 {
+		t.Errorf("重复注销: error = %v, want 400", err)
+	}
+	if due, err := s.userRepo.ListDueForPurge(ctx, time.Now(), 10); err != nil || len(due) != 0 {
+		t.Errorf("宽限期内 ListDueForPurge() = %v, %v", due, err)
+	}
+
+	// 宽限期内重新登录即恢复账号
+	if _, err := login("alice"); err != nil {
+		t.Fatalf("宽限期内登录: error = %v", err)
+	}
+	if user := reload(alice.ID); user.Status != model.UserStatusActive || user.DeletionScheduledAt != nil {
+		t.Errorf("恢复后 status = %s, deletion_scheduled_at = %v", user.Status, user.DeletionScheduledAt)
+	}
+	var restores int64
+	db.Model(&model.AuditEvent{}).Where("action = ? AND user_id = ?", model.AuditUserRestore, alice.ID).Count(&restores)
+	if restores != 1 {
+		t.Errorf("恢复审计事件数 = %d, want 1", restores)
+	}
+
+	// 邮箱未验证的账号恢复为待验证状态
+	carol := createTestUserWithEmail(t, db, "carol", "carol@example.com", false)
+	setTestPassword(t, db, carol, password)
+	if _, err := s.DeleteUser(ctx, carol.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login("carol"); err != nil {
+		t.Fatalf("宽限期内登录: error = %v", err)
+	}
+	if user := reload(carol.ID); user.Status != model.UserStatusPending {
+		t.Errorf("未验证邮箱的账号恢复后 status = %s, want pending", user.Status)
+	}
+
+	// 关闭登录恢复时宽限期内也不能登录
+	if _, err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AccountDeletion.RestoreOnLogin = false
+	config.SetConfig(cfg)
+	if _, err := login("alice"); errorCode(err) != 403 {
+		t.Errorf("关闭登录恢复后登录: error = %v, want 403", err)
+	}
+
+	// 宽限期已过的账号不能恢复，等待彻底删除
+	cfg.AccountDeletion.RestoreOnLogin = true
+	config.SetConfig(cfg)
+	db.Model(alice).Update("deletion_scheduled_at", time.Now().Add(-time.Minute))
+	if _, err := login("alice"); errorCode(err) != 403 {
+		t.Errorf("宽限期已过登录: error = %v, want 403", err)
+	}
+	due, err := s.userRepo.ListDueForPurge(ctx, time.Now(), 10)
+	if err != nil || len(due) != 1 || due[0].ID != alice.ID {
+		t.Errorf("ListDueForPurge() = %v, %v", due, err)
+	}
+}
+
+func TestPurgeScrubsAuditMetadata(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	user := createTestUser(t, db, "alice")
+	admin := createTestAdmin(t, db, "root", model.UserStatusActive)
+
+	events := []struct {
+		event    model.AuditEvent
+		metadata string
+		want     string
+	}{
+		{model.AuditEvent{Action: model.AuditLoginFailure, UserID: user.ID}, `{"method":"password","reason":"invalid_password"}`, `{"method":"password","reason":"invalid_password"}`},
+		{model.AuditEvent{Action: model.AuditUserStatusChange, ActorID: admin.ID, UserID: user.ID}, `{"from":"active","reason":"alice 的手机号 138…","to":"suspended"}`, `{"from":"active","to":"suspended"}`},
+		{model.AuditEvent{Action: model.AuditImpersonationAction, ActorID: user.ID, UserID: user.ID, ImpersonatorID: admin.ID}, `{"method":"GET","path":"/api/v1/users/alice","status":200}`, `{"method":"GET","status":200}`},
+		{model.AuditEvent{Action: model.AuditLoginFailure, UserID: user.ID}, `{"email":"alice@example.com","username":"alice"}`, `{}`},
+		// 与该用户无关的事件不受影响
+		{model.AuditEvent{Action: model.AuditUserStatusChange, ActorID: admin.ID, UserID: admin.ID}, `{"reason":"root 休假"}`, `{"reason":"root 休假"}`},
+	}
+	for i := range events {
+		e := &events[i].event
+		e.IP = "10.0.0.1"
+		e.Metadata = json.RawMessage(events[i].metadata)
+		if err := db.Create(e).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.userRepo.Purge(ctx, user.ID); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	for _, tt := range events {
+		var saved model.AuditEvent
+		if err := db.First(&saved, tt.event.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if string(saved.Metadata) != tt.want {
+			t.Errorf("%s 事件 metadata = %s, want %s", saved.Action, saved.Metadata, tt.want)
+		}
+		if related := saved.UserID == user.ID || saved.ActorID == user.ID; related == (saved.IP != "") {
+			t.Errorf("%s 事件 IP = %q", saved.Action, saved.IP)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// createTestAdmin 创建指定状态的平台管理员
+func createTestAdmin(t *testing.T, db *gorm.DB, username string, status model.UserStatus) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Nickname: username, Password: "x", Role: model.UserRoleAdmin, Status: status}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestLastAdminGuard(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	root := createTestAdmin(t, db, "root", model.UserStatusActive)
+	// 已暂停的管理员无法登录，不计入管理员人数
+	createTestAdmin(t, db, "dormant", model.UserStatusSuspended)
+
+	for _, status := range []string{"suspended", "disabled", "deleted"} {
+		err := s.ChangeUserStatus(ctx, "root", &dto.UpdateUserStatusRequest{Status: status})
+		if errorCode(err) != 400 {
+			t.Errorf("将最后一个管理员变更为 %s: error = %v, want 400", status, err)
+		}
+	}
+	if err := s.ChangeUserRole(ctx, "root", &dto.UpdateUserRoleRequest{Role: "user"}); errorCode(err) != 400 {
+		t.Errorf("撤销最后一个管理员: error = %v, want 400", err)
+	}
+	if _, err := s.DeleteUser(ctx, root.ID); errorCode(err) != 400 {
+		t.Errorf("最后一个管理员注销账号: error = %v, want 400", err)
+	}
+	// 变更为仍可登录的状态不受限制
+	if err := s.ChangeUserStatus(ctx, "root", &dto.UpdateUserStatusRequest{Status: "pending"}); err != nil {
+		t.Errorf("变更为 pending: error = %v", err)
+	}
+
+	// 不可登录的管理员可以继续停用或注销
+	if err := s.ChangeUserStatus(ctx, "dormant", &dto.UpdateUserStatusRequest{Status: "disabled"}); err != nil {
+		t.Errorf("停用已暂停的管理员: error = %v", err)
+	}
+
+	// 有另一个可登录的管理员后可以暂停 root，之后 admin2 成为最后一个
+	createTestAdmin(t, db, "admin2", model.UserStatusPending)
+	if err := s.ChangeUserStatus(ctx, "root", &dto.UpdateUserStatusRequest{Status: "suspended"}); err != nil {
+		t.Fatalf("暂停 root: error = %v", err)
+	}
+	if err := s.ChangeUserStatus(ctx, "admin2", &dto.UpdateUserStatusRequest{Status: "suspended"}); errorCode(err) != 400 {
+		t.Errorf("暂停最后一个可登录的管理员: error = %v, want 400", err)
+	}
+	if err := s.ChangeUserRole(ctx, "root", &dto.UpdateUserRoleRequest{Role: "user"}); err != nil {
+		t.Errorf("撤销已暂停的管理员: error = %v", err)
+	}
+
+	var saved model.User
+	db.First(&saved, root.ID)
+	if saved.Status != model.UserStatusSuspended || saved.Role != model.UserRoleUser {
+		t.Errorf("root 状态 = %s, 角色 = %s", saved.Status, saved.Role)
+	}
+}
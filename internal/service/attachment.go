@@ -0,0 +1,532 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/storage"
+	"ddup-apis/internal/utils"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	attachmentPurgeBatchSize = 100
+	sniffLen                 = 512 // http.DetectContentType 最多读取的字节数
+)
+
+// ListAttachments 获取自己资料项的附件
+func (s *ProfileService) ListAttachments(ctx context.Context, userID, profileID uint) ([]dto.AttachmentResponse, error) {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachmentsOf(ctx, []model.Profile{*profile}, repository.ProfileViewer{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if attachments[profileID] == nil {
+		return []dto.AttachmentResponse{}, nil
+	}
+	return attachments[profileID], nil
+}
+
+// UploadAttachment 一次性上传附件（multipart/form-data），适合较小的文件
+func (s *ProfileService) UploadAttachment(ctx context.Context, userID, profileID uint, name string, size int64, r io.Reader) (*dto.AttachmentResponse, error) {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	// 保存期间用一条没有暂存文件的上传记录预留空间
+	upload, err := s.reserveUpload(ctx, profile, name, size)
+	if err != nil {
+		return nil, err
+	}
+	defer s.removeUpload(ctx, upload.ID)
+	return s.storeAttachment(ctx, profile, name, size, r)
+}
+
+// CreateUpload 发起分片上传，文件大小计入配额直到上传完成或过期
+func (s *ProfileService) CreateUpload(ctx context.Context, userID, profileID uint, req *dto.CreateAttachmentUploadRequest) (*dto.AttachmentUploadResponse, error) {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := s.reserveUpload(ctx, profile, req.Name, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(config.GetConfig().Upload.TempDir, 0o750); err != nil {
+		s.removeUpload(ctx, upload.ID)
+		return nil, errors.Wrap(err, "创建上传失败")
+	}
+	f, err := os.OpenFile(uploadTempPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		s.removeUpload(ctx, upload.ID)
+		return nil, errors.Wrap(err, "创建上传失败")
+	}
+	f.Close()
+	return toUploadResponse(upload), nil
+}
+
+// GetUpload 获取分片上传的进度，用于断点续传
+func (s *ProfileService) GetUpload(ctx context.Context, userID, profileID uint, uploadID string) (*dto.AttachmentUploadResponse, error) {
+	upload, err := s.getOwnedUpload(ctx, userID, profileID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return toUploadResponse(upload), nil
+}
+
+// WriteUploadChunk 写入从 start 开始、长度为 length 的分片，分片必须按顺序上传；
+// 接收完所有内容后识别文件类型并保存到存储后端
+func (s *ProfileService) WriteUploadChunk(ctx context.Context, userID, profileID uint, uploadID string, start, length, total int64, r io.Reader) (*dto.AttachmentUploadResponse, error) {
+	upload, err := s.getOwnedUpload(ctx, userID, profileID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if total != upload.Size || start+length > upload.Size {
+		return nil, errors.New(400, "分片范围超出文件大小", nil)
+	}
+	if start != upload.Received {
+		return nil, errors.New(409, fmt.Sprintf("分片起始位置应为 %d", upload.Received), nil)
+	}
+
+	r = io.LimitReader(r, length)
+	if start == 0 && length >= min(sniffLen, upload.Size) {
+		// 第一个分片已足够识别文件类型，不允许的类型不必等到全部上传完才拒绝
+		head := make([]byte, min(sniffLen, length))
+		n, err := io.ReadFull(r, head)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, errors.Wrap(err, "写入分片失败")
+		}
+		head = head[:n]
+		if _, err := detectAttachmentType(head); err != nil {
+			s.removeUpload(ctx, upload.ID)
+			return nil, err
+		}
+		r = io.MultiReader(bytes.NewReader(head), r)
+	}
+
+	// 分片先写入本请求独占的暂存文件，占用该范围成功后再拼接到上传内容中，
+	// 并发写入同一范围的请求只有一个能占用成功，失败的请求不会覆盖已写入的内容
+	part, err := os.CreateTemp(filepath.Dir(uploadTempPath(upload.ID)), upload.ID+".*.part")
+	if err != nil {
+		return nil, errors.Wrap(err, "写入分片失败")
+	}
+	defer func() {
+		part.Close()
+		os.Remove(part.Name())
+	}()
+	written, err := io.Copy(part, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "写入分片失败")
+	}
+	if written != length {
+		return nil, errors.New(400, "请求体长度与 Content-Range 不符", nil)
+	}
+
+	ok, err := s.attachmentRepo.AdvanceUpload(ctx, upload.ID, start, start+length)
+	if err != nil {
+		return nil, errors.Wrap(err, "写入分片失败")
+	}
+	if !ok {
+		return nil, errors.New(409, "分片已被其他请求写入，请查询进度后重试", nil)
+	}
+	if err := spliceUploadChunk(upload.ID, start, part); err != nil {
+		// 拼接失败时退回占用的范围，客户端可以从原位置重试
+		if _, rollbackErr := s.attachmentRepo.AdvanceUpload(ctx, upload.ID, start+length, start); rollbackErr != nil {
+			logger.Error("退回分片上传进度失败", zap.String("upload_id", upload.ID), zap.Error(rollbackErr))
+		}
+		return nil, errors.Wrap(err, "写入分片失败")
+	}
+	upload.Received = start + length
+
+	resp := toUploadResponse(upload)
+	if upload.Received < upload.Size {
+		return resp, nil
+	}
+
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(uploadTempPath(upload.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "读取上传内容失败")
+	}
+	attachment, err := s.storeAttachment(ctx, profile, upload.Name, upload.Size, f)
+	f.Close()
+	// 内容已全部接收，无论保存是否成功都不能再续传，删除上传以释放预留的空间
+	s.removeUpload(ctx, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Completed = true
+	resp.Attachment = attachment
+	return resp, nil
+}
+
+// CancelUpload 取消分片上传并删除已接收的内容
+func (s *ProfileService) CancelUpload(ctx context.Context, userID, profileID uint, uploadID string) error {
+	upload, err := s.getOwnedUpload(ctx, userID, profileID, uploadID)
+	if err != nil {
+		return err
+	}
+	s.removeUpload(ctx, upload.ID)
+	return nil
+}
+
+// DeleteAttachment 删除自己资料项的附件
+func (s *ProfileService) DeleteAttachment(ctx context.Context, userID, profileID, attachmentID uint) error {
+	if _, err := s.getOwned(ctx, userID, profileID); err != nil {
+		return err
+	}
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return errors.Wrap(err, "获取附件失败")
+	}
+	if attachment == nil || attachment.ProfileID != profileID {
+		return errors.New(404, "附件不存在", nil)
+	}
+	if err := s.attachmentRepo.Delete(ctx, attachmentID); err != nil {
+		return errors.Wrap(err, "删除附件失败")
+	}
+	return nil
+}
+
+// OpenAttachment 校验下载链接的签名和有效期，并按签名中的访问者重新判断资料项是否可见，
+// 资料项改为不可见或分享链接撤销后，已发出的下载链接随之失效
+func (s *ProfileService) OpenAttachment(ctx context.Context, attachmentID uint, query *dto.AttachmentDownloadQuery) (*model.ProfileAttachment, io.ReadCloser, error) {
+	if time.Now().Unix() > query.Expires {
+		return nil, nil, errors.New(403, "下载链接已过期", nil)
+	}
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "获取附件失败")
+	}
+	if attachment == nil {
+		return nil, nil, errors.New(404, "附件不存在", nil)
+	}
+
+	viewer := repository.ProfileViewer{UserID: query.Viewer}
+	if query.Shared {
+		profile, err := s.repo.GetByID(ctx, attachment.ProfileID)
+		if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.Wrap(err, "获取资料失败")
+		}
+		if err != nil || profile.ShareTokenHash == "" {
+			return nil, nil, errors.New(404, "附件不存在", nil)
+		}
+		viewer.ShareTokenHash = profile.ShareTokenHash
+	}
+	expected := signAttachment(attachment.ID, viewer, query.Expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Signature)) {
+		return nil, nil, errors.New(403, "无效的下载链接", nil)
+	}
+
+	profile, err := s.repo.GetVisible(ctx, attachment.ProfileID, viewer)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "获取资料失败")
+	}
+	if profile == nil {
+		return nil, nil, errors.New(404, "附件不存在", nil)
+	}
+	if profile.UserID != viewer.UserID {
+		owner, err := s.userRepo.GetByID(ctx, profile.UserID)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "查询用户失败")
+		}
+		if owner == nil || owner.Status != model.UserStatusActive {
+			return nil, nil, errors.New(404, "附件不存在", nil)
+		}
+	}
+
+	body, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if stderrors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errors.New(404, "附件不存在", err)
+		}
+		return nil, nil, errors.Wrap(err, "读取附件失败")
+	}
+	return attachment, body, nil
+}
+
+// attachmentsOf 获取资料项的附件并生成访问者专属的下载链接，按资料项 ID 分组
+func (s *ProfileService) attachmentsOf(ctx context.Context, profiles []model.Profile, viewer repository.ProfileViewer) (map[uint][]dto.AttachmentResponse, error) {
+	ids := make([]uint, 0, len(profiles))
+	for _, p := range profiles {
+		ids = append(ids, p.ID)
+	}
+	attachments, err := s.attachmentRepo.ListByProfiles(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取附件失败")
+	}
+
+	expires := time.Now().Add(config.GetConfig().Upload.URLTTL).Unix()
+	result := make(map[uint][]dto.AttachmentResponse, len(profiles))
+	for _, a := range attachments {
+		query := url.Values{}
+		query.Set("expires", strconv.FormatInt(expires, 10))
+		if viewer.UserID != 0 {
+			query.Set("viewer", strconv.FormatUint(uint64(viewer.UserID), 10))
+		}
+		if viewer.ShareTokenHash != "" {
+			query.Set("shared", "true")
+		}
+		query.Set("signature", signAttachment(a.ID, viewer, expires))
+
+		result[a.ProfileID] = append(result[a.ProfileID], dto.AttachmentResponse{
+			ID:        a.ID,
+			Name:      a.Name,
+			MimeType:  a.MimeType,
+			Size:      a.Size,
+			URL:       fmt.Sprintf("/api/v1/attachments/%d?%s", a.ID, query.Encode()),
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// signAttachment 下载链接的签名，覆盖附件、访问者身份和过期时间
+func signAttachment(id uint, viewer repository.ProfileViewer, expires int64) string {
+	mac := hmac.New(sha256.New, attachmentSigningKey())
+	fmt.Fprintf(mac, "%d:%d:%s:%d", id, viewer.UserID, viewer.ShareTokenHash, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// attachmentSigningKey 下载链接的签名密钥，未配置 UPLOAD_SIGNING_KEY 时由 JWT 签名密钥派生
+func attachmentSigningKey() []byte {
+	if key := config.GetConfig().Upload.SigningKey; key != "" {
+		return []byte(key)
+	}
+	return utils.DeriveKey("ddup attachment download url")
+}
+
+// reserveUpload 检查单个文件大小上限，并创建上传记录在用户的附件空间中预留 size 字节，
+// 预留在上传记录删除或过期后释放
+func (s *ProfileService) reserveUpload(ctx context.Context, profile *model.Profile, name string, size int64) (*model.AttachmentUpload, error) {
+	cfg := config.GetConfig()
+	if size > cfg.Upload.MaxSize {
+		return nil, errors.New(413, fmt.Sprintf("文件大小不能超过 %d 字节", cfg.Upload.MaxSize), nil)
+	}
+	id, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建上传失败")
+	}
+	upload := &model.AttachmentUpload{
+		ID:        id,
+		UserID:    profile.UserID,
+		ProfileID: profile.ID,
+		Name:      attachmentName(name),
+		Size:      size,
+		ExpiresAt: time.Now().Add(cfg.Upload.SessionTTL),
+	}
+	used, ok, err := s.attachmentRepo.ReserveUpload(ctx, upload, cfg.Upload.UserQuota)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建上传失败")
+	}
+	if !ok {
+		return nil, errors.New(413, fmt.Sprintf("附件空间不足，已使用 %d 字节，上限 %d 字节", used, cfg.Upload.UserQuota), nil)
+	}
+	return upload, nil
+}
+
+// storeAttachment 按文件内容识别类型，检查是否允许后保存到存储后端并创建附件记录
+func (s *ProfileService) storeAttachment(ctx context.Context, profile *model.Profile, name string, size int64, r io.Reader) (*dto.AttachmentResponse, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, errors.Wrap(err, "读取上传内容失败")
+	}
+	head = head[:n]
+
+	mimeType, err := detectAttachmentType(head)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "保存附件失败")
+	}
+	key := fmt.Sprintf("%d/%d/%s", profile.UserID, profile.ID, token)
+	if err := s.store.Put(ctx, key, io.MultiReader(bytes.NewReader(head), r), size, mimeType); err != nil {
+		return nil, errors.Wrap(err, "保存附件失败")
+	}
+
+	attachment := &model.ProfileAttachment{
+		UserID:     profile.UserID,
+		ProfileID:  profile.ID,
+		Name:       attachmentName(name),
+		MimeType:   mimeType,
+		Size:       size,
+		StorageKey: key,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			logger.Error("删除未保存的附件文件失败", zap.String("key", key), zap.Error(delErr))
+		}
+		return nil, errors.Wrap(err, "保存附件失败")
+	}
+
+	attachments, err := s.attachmentsOf(ctx, []model.Profile{*profile}, repository.ProfileViewer{UserID: profile.UserID})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments[profile.ID] {
+		if a.ID == attachment.ID {
+			return &a, nil
+		}
+	}
+	return nil, errors.New(500, "保存附件失败", nil)
+}
+
+// detectAttachmentType 按文件开头的内容识别类型，不在 UPLOAD_ALLOWED_TYPES 中时返回 415
+func detectAttachmentType(head []byte) (string, error) {
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(config.GetConfig().Upload.AllowedTypes, mimeType) {
+		return "", errors.New(415, fmt.Sprintf("不支持的文件类型 %s", mimeType), nil)
+	}
+	return mimeType, nil
+}
+
+// getOwnedUpload 获取当前用户在该资料项上未过期的分片上传
+func (s *ProfileService) getOwnedUpload(ctx context.Context, userID, profileID uint, uploadID string) (*model.AttachmentUpload, error) {
+	if _, err := s.getOwned(ctx, userID, profileID); err != nil {
+		return nil, err
+	}
+	upload, err := s.attachmentRepo.GetUpload(ctx, uploadID)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取上传失败")
+	}
+	if upload == nil || upload.UserID != userID || upload.ProfileID != profileID || !upload.ExpiresAt.After(time.Now()) {
+		return nil, errors.New(404, "上传不存在或已过期", nil)
+	}
+	return upload, nil
+}
+
+// removeUpload 删除分片上传及其暂存文件
+func (s *ProfileService) removeUpload(ctx context.Context, id string) {
+	if err := os.Remove(uploadTempPath(id)); err != nil && !stderrors.Is(err, os.ErrNotExist) {
+		logger.Error("删除上传暂存文件失败", zap.String("upload_id", id), zap.Error(err))
+	}
+	if err := s.attachmentRepo.DeleteUpload(ctx, id); err != nil {
+		logger.Error("删除上传记录失败", zap.String("upload_id", id), zap.Error(err))
+	}
+}
+
+// spliceUploadChunk 将暂存的分片写入上传内容的 offset 位置
+func spliceUploadChunk(id string, offset int64, part *os.File) error {
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(uploadTempPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.NewOffsetWriter(f, offset), part)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func uploadTempPath(id string) string {
+	return filepath.Join(config.GetConfig().Upload.TempDir, id)
+}
+
+// attachmentName 只保留文件名本身，去掉客户端可能带上的路径
+func attachmentName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	for utf8.RuneCountInString(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func toUploadResponse(upload *model.AttachmentUpload) *dto.AttachmentUploadResponse {
+	return &dto.AttachmentUploadResponse{
+		ID:        upload.ID,
+		Name:      upload.Name,
+		Size:      upload.Size,
+		Offset:    upload.Received,
+		ExpiresAt: upload.ExpiresAt,
+	}
+}
+
+// PeriodicAttachmentPurge 定期删除已删除附件在存储中的文件，以及过期的分片上传
+func PeriodicAttachmentPurge(db *gorm.DB, store storage.Storage, interval time.Duration) {
+	attachmentRepo := repository.NewAttachmentRepository(db)
+
+	purge := func() {
+		ctx := context.Background()
+
+		attachments, err := attachmentRepo.ListDeleted(ctx, attachmentPurgeBatchSize)
+		if err != nil {
+			logger.Error("查询已删除附件失败", zap.Error(err))
+		}
+		for _, a := range attachments {
+			if err := store.Delete(ctx, a.StorageKey); err != nil {
+				logger.Error("删除附件文件失败", zap.Uint("attachment_id", a.ID), zap.Error(err))
+				continue
+			}
+			if err := attachmentRepo.Purge(ctx, a.ID); err != nil {
+				logger.Error("删除附件记录失败", zap.Uint("attachment_id", a.ID), zap.Error(err))
+			}
+		}
+
+		uploads, err := attachmentRepo.ListExpiredUploads(ctx, time.Now(), attachmentPurgeBatchSize)
+		if err != nil {
+			logger.Error("查询过期上传失败", zap.Error(err))
+		}
+		for _, u := range uploads {
+			if err := os.Remove(uploadTempPath(u.ID)); err != nil && !stderrors.Is(err, os.ErrNotExist) {
+				logger.Error("删除上传暂存文件失败", zap.String("upload_id", u.ID), zap.Error(err))
+				continue
+			}
+			if err := attachmentRepo.DeleteUpload(ctx, u.ID); err != nil {
+				logger.Error("删除上传记录失败", zap.String("upload_id", u.ID), zap.Error(err))
+			}
+		}
+		if len(attachments) > 0 || len(uploads) > 0 {
+			logger.Info("已清理附件", zap.Int("attachments", len(attachments)), zap.Int("uploads", len(uploads)))
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		purge()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
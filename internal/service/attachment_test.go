@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/storage"
+	stderrors "errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAttachment(t *testing.T) {
+	config.SetConfig(testConfig())
+
+	viewer := repository.ProfileViewer{UserID: 7}
+	sig := signAttachment(1, viewer, 1700000000)
+	if sig != signAttachment(1, viewer, 1700000000) {
+		t.Fatal("相同参数的签名应相同")
+	}
+	for name, other := range map[string]string{
+		"附件":   signAttachment(2, viewer, 1700000000),
+		"访问者":  signAttachment(1, repository.ProfileViewer{UserID: 8}, 1700000000),
+		"分享令牌": signAttachment(1, repository.ProfileViewer{UserID: 7, ShareTokenHash: "abc"}, 1700000000),
+		"过期时间": signAttachment(1, viewer, 1700000001),
+	} {
+		if other == sig {
+			t.Errorf("%s不同时签名应不同", name)
+		}
+	}
+
+	cfg := testConfig()
+	cfg.Upload.SigningKey = "another-upload-signing-key-0123456789abcdef"
+	config.SetConfig(cfg)
+	if signAttachment(1, viewer, 1700000000) == sig {
+		t.Error("更换密钥后签名应不同")
+	}
+}
+
+func TestOpenAttachment(t *testing.T) {
+	config.SetConfig(testConfig())
+	ctx := context.Background()
+	db := newTestDB(t, &model.User{}, &model.Profile{}, &model.ProfileAudience{}, &model.OrganizationMember{},
+		&model.ProfileAttachment{}, &model.AttachmentUpload{})
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewProfileService(db, store)
+
+	owner := &model.User{Username: "owner", Password: "x", Status: model.UserStatusActive}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	profile := &model.Profile{UserID: owner.ID, Type: model.Project, Title: "项目", Visibility: model.VisibilityPublic}
+	if err := db.Create(profile).Error; err != nil {
+		t.Fatal(err)
+	}
+	content := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte{0}, 100)...)
+	uploaded, err := svc.UploadAttachment(ctx, owner.ID, profile.ID, "a.pdf", int64(len(content)), bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+	if uploaded.MimeType != "application/pdf" {
+		t.Errorf("MimeType = %s, want application/pdf", uploaded.MimeType)
+	}
+
+	query := downloadQuery(t, uploaded.URL)
+	attachment, body, err := svc.OpenAttachment(ctx, uploaded.ID, query)
+	if err != nil {
+		t.Fatalf("OpenAttachment() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if attachment.ID != uploaded.ID || !bytes.Equal(got, content) {
+		t.Error("下载的内容与上传的不一致")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(q *dto.AttachmentDownloadQuery)
+		code   int
+	}{
+		{"修改访问者", func(q *dto.AttachmentDownloadQuery) { q.Viewer = 0 }, 403},
+		{"修改签名", func(q *dto.AttachmentDownloadQuery) { q.Signature = strings.Repeat("0", len(q.Signature)) }, 403},
+		{"延长有效期", func(q *dto.AttachmentDownloadQuery) { q.Expires += 3600 }, 403},
+		{"改为分享链接", func(q *dto.AttachmentDownloadQuery) { q.Shared = true }, 404},
+		{"已过期", func(q *dto.AttachmentDownloadQuery) {
+			q.Expires = time.Now().Add(-time.Second).Unix()
+			q.Signature = signAttachment(uploaded.ID, repository.ProfileViewer{UserID: q.Viewer}, q.Expires)
+		}, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := *query
+			tt.modify(&q)
+			_, _, err := svc.OpenAttachment(ctx, uploaded.ID, &q)
+			if code := errorCode(err); code != tt.code {
+				t.Errorf("OpenAttachment() code = %d, want %d (%v)", code, tt.code, err)
+			}
+		})
+	}
+
+	t.Run("资料项改为不可见", func(t *testing.T) {
+		q := *query
+		q.Viewer = 0
+		q.Signature = signAttachment(uploaded.ID, repository.ProfileViewer{}, q.Expires)
+		if _, _, err := svc.OpenAttachment(ctx, uploaded.ID, &q); err != nil {
+			t.Fatalf("公开资料项的匿名链接应可下载: %v", err)
+		}
+		if err := db.Model(profile).Update("visibility", model.VisibilityPrivate).Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := svc.OpenAttachment(ctx, uploaded.ID, &q); errorCode(err) != 404 {
+			t.Errorf("OpenAttachment() error = %v, want 404", err)
+		}
+	})
+}
+
+// downloadQuery 从附件响应的下载地址中解析查询参数
+func downloadQuery(t *testing.T, rawURL string) *dto.AttachmentDownloadQuery {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := u.Query()
+	expires, _ := strconv.ParseInt(values.Get("expires"), 10, 64)
+	viewer, _ := strconv.ParseUint(values.Get("viewer"), 10, 64)
+	return &dto.AttachmentDownloadQuery{
+		Expires:   expires,
+		Viewer:    uint(viewer),
+		Shared:    values.Get("shared") == "true",
+		Signature: values.Get("signature"),
+	}
+}
+
+func errorCode(err error) int {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
+
+func TestWriteUploadChunkConcurrent(t *testing.T) {
+	ctx := context.Background()
+	svc, db := newTestProfileService(t)
+	cfg := testConfig()
+	cfg.Upload.TempDir = t.TempDir()
+	config.SetConfig(cfg)
+	owner := createTestUser(t, db, "owner")
+	profile := createTestProfile(t, db, owner.ID, model.VisibilityPublic)
+
+	content := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("a"), 1491)...)
+	size := int64(len(content))
+	upload, err := svc.CreateUpload(ctx, owner.ID, profile.ID, &dto.CreateAttachmentUploadRequest{Name: "a.pdf", Size: size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.WriteUploadChunk(ctx, owner.ID, profile.ID, upload.ID, 0, 600, size, bytes.NewReader(content[:600])); err != nil {
+		t.Fatalf("写入第一个分片失败: %v", err)
+	}
+
+	// 请求 A 写到一半时，请求 B 写完同一范围；A 占用范围失败，不能覆盖 B 写入的内容
+	loser := bytes.Repeat([]byte("x"), 400)
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.WriteUploadChunk(ctx, owner.ID, profile.ID, upload.ID, 600, 400, size, pr)
+		done <- err
+	}()
+	if _, err := pw.Write(loser[:200]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.WriteUploadChunk(ctx, owner.ID, profile.ID, upload.ID, 600, 400, size, bytes.NewReader(content[600:1000])); err != nil {
+		t.Fatalf("写入分片失败: %v", err)
+	}
+	pw.Write(loser[200:])
+	pw.Close()
+	if err := <-done; errorCode(err) != 409 {
+		t.Fatalf("并发写入同一分片: error = %v, want 409", err)
+	}
+
+	resp, err := svc.WriteUploadChunk(ctx, owner.ID, profile.ID, upload.ID, 1000, size-1000, size, bytes.NewReader(content[1000:]))
+	if err != nil || !resp.Completed {
+		t.Fatalf("写入最后一个分片: %+v, %v", resp, err)
+	}
+	var attachment model.ProfileAttachment
+	if err := db.First(&attachment, resp.Attachment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	rc, err := svc.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	got, _ := io.ReadAll(rc)
+	if !bytes.Equal(got, content) {
+		t.Error("保存的附件内容被并发写入的分片覆盖")
+	}
+}
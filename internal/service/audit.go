@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/requestctx"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize      = 20
+	maxAuditUserAgentLen = 500
+)
+
+// recordAudit 追加一条审计事件，请求 ID、IP、UA 以及未指定的操作人从 context 中的请求信息补全。
+// 写入失败只记录日志，不影响已经完成的操作
+func recordAudit(ctx context.Context, repo repository.IAuditRepository, event *model.AuditEvent, metadata map[string]interface{}) {
+	info := requestctx.From(ctx)
+	if event.ActorID == 0 {
+		event.ActorID = info.ActorID
+	}
+	if event.ImpersonatorID == 0 {
+		event.ImpersonatorID = info.ImpersonatorID
+	}
+	event.IP = info.ClientIP
+	event.UserAgent = info.UserAgent
+	if len(event.UserAgent) > maxAuditUserAgentLen {
+		event.UserAgent = strings.ToValidUTF8(event.UserAgent[:maxAuditUserAgentLen], "")
+	}
+	event.RequestID = info.RequestID
+
+	if len(metadata) > 0 {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			logger.Error("序列化审计事件失败", zap.String("action", string(event.Action)), zap.Error(err))
+			return
+		}
+		event.Metadata = data
+	}
+
+	// 请求结束或客户端断开不应导致审计记录丢失
+	if err := repo.Create(context.WithoutCancel(ctx), event); err != nil {
+		logger.Error("写入审计事件失败",
+			zap.String("action", string(event.Action)),
+			zap.Uint("user_id", event.UserID),
+			zap.String("request_id", event.RequestID),
+			zap.Error(err),
+		)
+	}
+}
+
+// normalizePage 补全分页参数的默认值，返回查询使用的 offset 和 limit
+func normalizePage(page *dto.PageRequest) (int, int) {
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.PageSize == 0 {
+		page.PageSize = defaultPageSize
+	}
+	return (page.Page - 1) * page.PageSize, page.PageSize
+}
+
+// audit 记录与 userID 相关的审计事件
+func (s *UserService) audit(ctx context.Context, action model.AuditAction, userID uint, metadata map[string]interface{}) {
+	recordAudit(ctx, s.auditRepo, &model.AuditEvent{Action: action, UserID: userID}, metadata)
+}
+
+// ListSecurityLog 获取与用户本人相关的审计事件
+func (s *UserService) ListSecurityLog(ctx context.Context, userID uint, req *dto.PageRequest) (*dto.AuditEventListResponse, error) {
+	return s.listAuditEvents(ctx, &repository.AuditEventFilter{UserID: userID}, req)
+}
+
+// ListAuditEvents 管理员按条件查询审计事件
+func (s *UserService) ListAuditEvents(ctx context.Context, req *dto.AuditEventQueryRequest) (*dto.AuditEventListResponse, error) {
+	if req.Since != nil && req.Until != nil && !req.Since.Before(*req.Until) {
+		return nil, errors.New(400, "since 必须早于 until", nil)
+	}
+
+	return s.listAuditEvents(ctx, &repository.AuditEventFilter{
+		UserID:         req.UserID,
+		ActorID:        req.ActorID,
+		OrganizationID: req.OrganizationID,
+		ImpersonatorID: req.ImpersonatorID,
+		Action:         model.AuditAction(req.Action),
+		IP:             req.IP,
+		RequestID:      req.RequestID,
+		Since:          req.Since,
+		Until:          req.Until,
+	}, &req.PageRequest)
+}
+
+func (s *UserService) listAuditEvents(ctx context.Context, filter *repository.AuditEventFilter, page *dto.PageRequest) (*dto.AuditEventListResponse, error) {
+	filter.Offset, filter.Limit = normalizePage(page)
+
+	events, total, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询审计事件失败")
+	}
+
+	items := make([]dto.AuditEventResponse, 0, len(events))
+	for _, event := range events {
+		items = append(items, toAuditEventResponse(&event))
+	}
+	return &dto.AuditEventListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page.Page,
+		PageSize: page.PageSize,
+	}, nil
+}
+
+func toAuditEventResponse(event *model.AuditEvent) dto.AuditEventResponse {
+	resp := dto.AuditEventResponse{
+		ID:             event.ID,
+		Action:         string(event.Action),
+		ActorID:        event.ActorID,
+		UserID:         event.UserID,
+		OrganizationID: event.OrganizationID,
+		ImpersonatorID: event.ImpersonatorID,
+		IP:             event.IP,
+		UserAgent:      event.UserAgent,
+		RequestID:      event.RequestID,
+		CreatedAt:      event.CreatedAt,
+	}
+	if len(event.Metadata) > 0 {
+		if err := json.Unmarshal(event.Metadata, &resp.Metadata); err != nil {
+			logger.Warn("解析审计事件元数据失败", zap.Uint("event_id", event.ID), zap.Error(err))
+		}
+	}
+	return resp
+}
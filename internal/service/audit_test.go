@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/requestctx"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	s, db, _ := newTestAccountService(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	admin := createTestUser(t, db, "admin")
+
+	// 请求信息从 context 补全，操作人默认为当前登录用户
+	aliceCtx := requestctx.With(context.Background(), &requestctx.Info{RequestID: "req-1", ClientIP: "10.0.0.1", UserAgent: strings.Repeat("u", 600), ActorID: alice.ID})
+	adminCtx := requestctx.With(context.Background(), &requestctx.Info{RequestID: "req-2", ClientIP: "10.0.0.2", ActorID: admin.ID})
+	s.audit(aliceCtx, model.AuditPasswordChange, alice.ID, nil)
+	s.audit(aliceCtx, model.AuditSessionRevoke, alice.ID, map[string]interface{}{"sessionId": 7})
+	s.audit(adminCtx, model.AuditUserStatusChange, alice.ID, map[string]interface{}{"from": "active", "to": "suspended"})
+	s.audit(adminCtx, model.AuditUserStatusChange, bob.ID, nil)
+	// 后台任务没有请求信息
+	s.audit(context.Background(), model.AuditUserPurge, bob.ID, nil)
+
+	// 用户只能看到与自己相关的事件，按时间倒序分页
+	log, err := s.ListSecurityLog(context.Background(), alice.ID, &dto.PageRequest{PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListSecurityLog() error = %v", err)
+	}
+	if log.Total != 3 || len(log.Items) != 2 || log.Page != 1 || log.Items[0].Action != string(model.AuditUserStatusChange) {
+		t.Fatalf("ListSecurityLog() = %+v", log)
+	}
+	if got := log.Items[0]; got.ActorID != admin.ID || got.IP != "10.0.0.2" || got.RequestID != "req-2" || got.Metadata["to"] != "suspended" {
+		t.Errorf("管理员操作事件 = %+v", got)
+	}
+	if got := log.Items[1]; got.ActorID != alice.ID || len(got.UserAgent) != maxAuditUserAgentLen || got.Metadata["sessionId"] != float64(7) {
+		t.Errorf("本人操作事件 = %+v", got)
+	}
+	page2, err := s.ListSecurityLog(context.Background(), alice.ID, &dto.PageRequest{Page: 2, PageSize: 2})
+	if err != nil || len(page2.Items) != 1 || page2.Items[0].Action != string(model.AuditPasswordChange) {
+		t.Errorf("第 2 页 = %+v, %v", page2, err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name string
+		req  dto.AuditEventQueryRequest
+		want int
+	}{
+		{"全部", dto.AuditEventQueryRequest{}, 5},
+		{"按受影响用户", dto.AuditEventQueryRequest{UserID: bob.ID}, 2},
+		{"按操作人", dto.AuditEventQueryRequest{ActorID: admin.ID}, 2},
+		{"按操作类型", dto.AuditEventQueryRequest{Action: string(model.AuditUserStatusChange)}, 2},
+		{"按 IP", dto.AuditEventQueryRequest{IP: "10.0.0.1"}, 2},
+		{"按请求 ID", dto.AuditEventQueryRequest{RequestID: "req-2"}, 2},
+		{"组合条件", dto.AuditEventQueryRequest{ActorID: admin.ID, UserID: alice.ID}, 1},
+		{"时间范围", dto.AuditEventQueryRequest{Since: &past, Until: &future}, 5},
+		{"开始时间之后没有事件", dto.AuditEventQueryRequest{Since: &future}, 0},
+		{"结束时间之前没有事件", dto.AuditEventQueryRequest{Until: &past}, 0},
+	}
+	for _, tt := range tests {
+		resp, err := s.ListAuditEvents(context.Background(), &tt.req)
+		if err != nil {
+			t.Errorf("%s: ListAuditEvents() error = %v", tt.name, err)
+			continue
+		}
+		if resp.Total != int64(tt.want) || len(resp.Items) != tt.want {
+			t.Errorf("%s: ListAuditEvents() total = %d, items = %d, want %d", tt.name, resp.Total, len(resp.Items), tt.want)
+		}
+	}
+
+	if _, err := s.ListAuditEvents(context.Background(), &dto.AuditEventQueryRequest{Since: &future, Until: &past}); errorCode(err) != 400 {
+		t.Errorf("since 晚于 until: error = %v, want 400", err)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/mail"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// normalizeEmail 邮箱统一去除首尾空白并转为小写后存储和比较
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkEmailAvailable 检查邮箱是否已被其他用户使用，exceptUserID 为当前用户
+func (s *UserService) checkEmailAvailable(ctx context.Context, email string, exceptUserID uint) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user != nil && user.ID != exceptUserID {
+		return errors.New(400, "邮箱已被注册", nil)
+	}
+	return nil
+}
+
+// SendEmailVerification 重新发送邮箱验证邮件
+func (s *UserService) SendEmailVerification(ctx context.Context, id uint) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return errors.New(404, "用户不存在", err)
+	}
+	if user.Email == nil {
+		return errors.New(400, "尚未设置邮箱", nil)
+	}
+	if user.EmailVerified() {
+		return errors.New(400, "邮箱已验证", nil)
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// sendVerificationEmail 生成验证令牌并发送验证邮件，之前发出的令牌全部作废
+func (s *UserService) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.Wrap(err, "生成验证令牌失败")
+	}
+
+	if err := s.emailVerificationRepo.InvalidateUserTokens(ctx, user.ID); err != nil {
+		return errors.Wrap(err, "作废旧令牌失败")
+	}
+
+	cfg := config.GetConfig()
+	if err := s.emailVerificationRepo.Create(ctx, &model.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.EmailAddress(),
+		TokenHash: utils.HashToken(token),
+		ExpiredAt: time.Now().Add(cfg.Security.EmailVerifyTTL),
+	}); err != nil {
+		return errors.Wrap(err, "保存验证令牌失败")
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(cfg.App.URL, "/"), token)
+	if err := s.mailer.Send(ctx, &mail.Message{
+		To:      user.EmailAddress(),
+		Subject: "验证您的 DDUP 邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n请在 %d 小时内打开以下链接验证您的邮箱：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件。\n",
+			user.Nickname, int(cfg.Security.EmailVerifyTTL.Hours()), link),
+	}); err != nil {
+		return errors.Wrap(err, "发送验证邮件失败")
+	}
+
+	return nil
+}
+
+// VerifyEmail 使用验证令牌确认邮箱，待验证的账号随即恢复正常状态
+func (s *UserService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error {
+	token, err := s.emailVerificationRepo.GetByTokenHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return errors.Wrap(err, "查询验证令牌失败")
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiredAt) {
+		return errors.New(400, "验证链接无效或已过期", nil)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil || user == nil {
+		return errors.New(404, "用户不存在", err)
+	}
+	if user.EmailAddress() != token.Email {
+		return errors.New(400, "邮箱已变更，验证链接已失效", nil)
+	}
+
+	used, err := s.emailVerificationRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return errors.Wrap(err, "更新验证令牌失败")
+	}
+	if !used {
+		return errors.New(400, "验证链接无效或已过期", nil)
+	}
+
+	updates := map[string]interface{}{"email_verified_at": time.Now()}
+	if user.Status == model.UserStatusPending {
+		updates["status"] = model.UserStatusActive
+	}
+	if err := s.userRepo.Update(ctx, user.ID, updates); err != nil {
+		return errors.Wrap(err, "更新邮箱验证状态失败")
+	}
+
+	logger.Info("用户邮箱已验证", zap.Uint("user_id", user.ID), zap.String("email", token.Email))
+	return nil
+}
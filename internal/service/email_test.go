@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	s, db, mailer := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.App.URL = "https://ddup.test"
+		cfg.Security.EmailVerifyTTL = 24 * time.Hour
+	})
+	const password = "Xq7#Str0ng!pass"
+	reload := func() *model.User {
+		user, err := s.userRepo.GetByUsername(ctx, "alice")
+		if err != nil || user == nil {
+			t.Fatalf("查询用户失败: %v", err)
+		}
+		return user
+	}
+	login := func(username string) error {
+		_, err := s.Login(ctx, &dto.LoginRequest{Username: username, Password: password}, &dto.ClientInfo{})
+		return err
+	}
+	verify := func(token string) error {
+		return s.VerifyEmail(ctx, &dto.VerifyEmailRequest{Token: token})
+	}
+
+	// 注册时保存规范化的邮箱，验证前为待验证状态
+	if err := s.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: " Alice@Example.com ", Password: password}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	user := reload()
+	if user.EmailAddress() != "alice@example.com" || user.EmailVerified() || user.Status != model.UserStatusPending {
+		t.Errorf("注册后 email = %s, verified = %v, status = %s", user.EmailAddress(), user.EmailVerified(), user.Status)
+	}
+	if msg := mailer.last(); msg == nil || msg.To != "alice@example.com" {
+		t.Fatalf("验证邮件 = %+v", msg)
+	}
+	first := mailer.lastToken(t)
+
+	// 邮箱和用户名唯一
+	if err := s.Register(ctx, &dto.RegisterRequest{Username: "bob", Email: "ALICE@example.com", Password: password}); errorCode(err) != 400 {
+		t.Errorf("邮箱已注册: error = %v, want 400", err)
+	}
+	if err := s.Register(ctx, &dto.RegisterRequest{Username: "alice", Email: "other@example.com", Password: password}); errorCode(err) != 400 {
+		t.Errorf("用户名已存在: error = %v, want 400", err)
+	}
+
+	// 未验证的邮箱不能用于登录，用户名可以
+	if err := login("alice@example.com"); errorCode(err) != 401 {
+		t.Errorf("未验证的邮箱登录: error = %v, want 401", err)
+	}
+	if err := login("alice"); err != nil {
+		t.Errorf("待验证时用户名登录: error = %v", err)
+	}
+
+	// 重新发送后旧令牌作废
+	if err := s.SendEmailVerification(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(first); errorCode(err) != 400 {
+		t.Errorf("旧令牌: error = %v, want 400", err)
+	}
+	second := mailer.lastToken(t)
+	if err := verify(second); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if user = reload(); !user.EmailVerified() || user.Status != model.UserStatusActive {
+		t.Errorf("验证后 verified = %v, status = %s", user.EmailVerified(), user.Status)
+	}
+	if err := verify(second); errorCode(err) != 400 {
+		t.Errorf("令牌只能使用一次: error = %v, want 400", err)
+	}
+	if err := s.SendEmailVerification(ctx, user.ID); errorCode(err) != 400 {
+		t.Errorf("邮箱已验证时重新发送: error = %v, want 400", err)
+	}
+	if err := login("ALICE@example.com"); err != nil {
+		t.Errorf("已验证的邮箱登录: error = %v", err)
+	}
+
+	// 修改邮箱后需要重新验证，不能改为其他用户的邮箱
+	createTestUserWithEmail(t, db, "bob", "bob@example.com", true)
+	if err := s.UpdateUser(ctx, user.ID, &dto.UpdateUserRequest{Nickname: "Alice", Email: "Bob@example.com"}); errorCode(err) != 400 {
+		t.Errorf("改为其他用户的邮箱: error = %v, want 400", err)
+	}
+	if err := s.UpdateUser(ctx, user.ID, &dto.UpdateUserRequest{Nickname: "Alice", Email: "alice@new.example.com"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user = reload(); user.EmailAddress() != "alice@new.example.com" || user.EmailVerified() || user.Status != model.UserStatusPending {
+		t.Errorf("修改邮箱后 email = %s, verified = %v, status = %s", user.EmailAddress(), user.EmailVerified(), user.Status)
+	}
+	if err := login("alice@example.com"); errorCode(err) != 401 {
+		t.Errorf("旧邮箱登录: error = %v, want 401", err)
+	}
+
+	// 过期的令牌
+	expired := mailer.lastToken(t)
+	if err := db.Model(&model.EmailVerificationToken{}).Where("token_hash = ?", utils.HashToken(expired)).
+		Update("expired_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(expired); errorCode(err) != 400 {
+		t.Errorf("过期的令牌: error = %v, want 400", err)
+	}
+
+	// 令牌发出后邮箱又被修改时，发往旧邮箱的令牌失效
+	if err := s.SendEmailVerification(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	stale := mailer.lastToken(t)
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("email", "alice@other.example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(stale); errorCode(err) != 400 {
+		t.Errorf("邮箱变更后的令牌: error = %v, want 400", err)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// impersonationDeviceLabel 代登录会话在用户设备列表中显示的名称
+const impersonationDeviceLabel = "管理员代登录"
+
+// ActClaim RFC 8693 的 act 声明，表示实际操作的一方（代登录的管理员）
+type ActClaim struct {
+	Subject  string `json:"sub"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Impersonate 管理员以目标用户的身份登录，签发的令牌有效期较短且不能续期。
+// 不能代登录自己、其他管理员或无法登录的账号
+func (s *UserService) Impersonate(ctx context.Context, adminID uint, username string, req *dto.ImpersonateRequest, client *dto.ClientInfo) (*dto.ImpersonationResponse, error) {
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil || admin == nil {
+		return nil, errors.New(401, "用户不存在", err)
+	}
+	user, err := s.getUserForAdmin(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == admin.ID {
+		return nil, errors.New(400, "不能代登录自己的账号", nil)
+	}
+	if user.Role == model.UserRoleAdmin {
+		return nil, errors.New(403, "不能代登录平台管理员", nil)
+	}
+	if !user.Status.CanLogin() {
+		return nil, errUserInactive(user.Status)
+	}
+
+	ttl := config.GetConfig().Security.ImpersonationTTL
+	now := time.Now()
+	expiredAt := now.Add(ttl)
+
+	tokenID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成令牌失败")
+	}
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成令牌失败")
+	}
+
+	token, err := utils.SignToken(&Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Act: &ActClaim{
+			Subject:  strconv.FormatUint(uint64(admin.ID), 10),
+			UserID:   admin.ID,
+			Username: admin.Username,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiredAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "生成令牌失败")
+	}
+
+	session := &model.Session{
+		UserID:           user.ID,
+		TokenHash:        utils.HashToken(token),
+		FamilyID:         familyID,
+		IsValid:          true,
+		ExpiredAt:        expiredAt,
+		RefreshExpiredAt: expiredAt,
+		UserAgent:        client.UserAgent,
+		ClientIP:         client.ClientIP,
+		DeviceLabel:      impersonationDeviceLabel,
+		ImpersonatorID:   &admin.ID,
+		SignedInAt:       now,
+		LastSeenAt:       &now,
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, errors.Wrap(err, "创建会话失败")
+	}
+
+	recordAudit(ctx, s.auditRepo, &model.AuditEvent{
+		Action:         model.AuditImpersonationStart,
+		ActorID:        admin.ID,
+		UserID:         user.ID,
+		ImpersonatorID: admin.ID,
+	}, map[string]interface{}{
+		"reason":    req.Reason,
+		"sessionId": session.ID,
+		"expiredAt": expiredAt,
+	})
+	logger.Warn("管理员代登录用户",
+		zap.Uint("admin_id", admin.ID),
+		zap.Uint("user_id", user.ID),
+		zap.Uint("session_id", session.ID),
+	)
+
+	return &dto.ImpersonationResponse{
+		Token:     token,
+		Username:  user.Username,
+		ExpiresIn: int64(ttl.Seconds()),
+		ExpiredAt: expiredAt,
+	}, nil
+}
+
+// RecordImpersonatedRequest 记录代登录期间的每个请求，操作人和管理员从 context 中的请求信息补全
+func (s *UserService) RecordImpersonatedRequest(ctx context.Context, userID uint, method, path string, status int) {
+	s.audit(ctx, model.AuditImpersonationAction, userID, map[string]interface{}{
+		"method": method,
+		"path":   path,
+		"status": status,
+	})
+}
+
+// checkImpersonator 代登录会话只在管理员仍然有效时可用，管理员被撤销角色或停用后立即失效
+func (s *UserService) checkImpersonator(ctx context.Context, adminID uint) (bool, error) {
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return false, errors.Wrap(err, "查询用户失败")
+	}
+	return admin != nil && admin.Role == model.UserRoleAdmin && admin.Status.CanLogin(), nil
+}
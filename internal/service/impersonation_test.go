@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/requestctx"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestImpersonate(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.Security.ImpersonationTTL = 30 * time.Minute
+	})
+	admin := createTestAdmin(t, db, "admin", model.UserStatusActive)
+	createTestAdmin(t, db, "admin2", model.UserStatusActive)
+	alice := createTestUser(t, db, "alice")
+	suspended := createTestUser(t, db, "suspended")
+	db.Model(suspended).Update("status", model.UserStatusSuspended)
+
+	req := &dto.ImpersonateRequest{Reason: "排查工单 #42"}
+	client := &dto.ClientInfo{UserAgent: "admin-console", ClientIP: "10.0.0.1"}
+	tests := []struct {
+		name     string
+		username string
+		want     int
+	}{
+		{"用户不存在", "nobody", 404},
+		{"不能代登录自己", "admin", 400},
+		{"不能代登录其他管理员", "admin2", 403},
+		{"不能代登录无法登录的账号", "suspended", 403},
+	}
+	for _, tt := range tests {
+		if _, err := s.Impersonate(ctx, admin.ID, tt.username, req, client); errorCode(err) != tt.want {
+			t.Errorf("%s: Impersonate() error = %v, want %d", tt.name, err, tt.want)
+		}
+	}
+
+	resp, err := s.Impersonate(ctx, admin.ID, "alice", req, client)
+	if err != nil {
+		t.Fatalf("Impersonate() error = %v", err)
+	}
+	if resp.Username != "alice" || resp.ExpiresIn != int64((30*time.Minute).Seconds()) {
+		t.Errorf("Impersonate() = %+v", resp)
+	}
+	result, err := s.ValidateToken(resp.Token)
+	if err != nil || !result.Valid || result.UserID != alice.ID || result.ImpersonatorID != admin.ID {
+		t.Fatalf("ValidateToken() = %+v, %v", result, err)
+	}
+
+	// 代登录会话出现在用户的设备列表中，不签发 refresh token
+	var session model.Session
+	db.First(&session, result.SessionID)
+	if session.DeviceLabel != impersonationDeviceLabel || session.ImpersonatorID == nil || *session.ImpersonatorID != admin.ID ||
+		session.RefreshToken != "" || session.ExpiredAt.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("代登录会话 = %+v", session)
+	}
+
+	var start model.AuditEvent
+	if err := db.Where("action = ?", model.AuditImpersonationStart).First(&start).Error; err != nil {
+		t.Fatalf("没有记录代登录审计事件: %v", err)
+	}
+	if start.ActorID != admin.ID || start.UserID != alice.ID || start.ImpersonatorID != admin.ID {
+		t.Errorf("代登录审计事件 = %+v", start)
+	}
+
+	// 代登录期间的请求以用户身份记录，并标注管理员
+	reqCtx := requestctx.With(ctx, &requestctx.Info{ActorID: alice.ID, ImpersonatorID: admin.ID, ClientIP: "10.0.0.1"})
+	s.RecordImpersonatedRequest(reqCtx, alice.ID, http.MethodGet, "/api/v1/users/profile", http.StatusOK)
+	var action model.AuditEvent
+	if err := db.Where("action = ?", model.AuditImpersonationAction).First(&action).Error; err != nil {
+		t.Fatalf("没有记录代登录请求: %v", err)
+	}
+	if action.ActorID != alice.ID || action.UserID != alice.ID || action.ImpersonatorID != admin.ID {
+		t.Errorf("代登录请求审计事件 = %+v", action)
+	}
+
+	// 管理员被撤销角色或停用后，代登录令牌立即失效
+	db.Model(admin).Update("role", model.UserRoleUser)
+	if result, _ := s.ValidateToken(resp.Token); result.Valid {
+		t.Error("管理员被撤销角色后代登录令牌应失效")
+	}
+	db.Model(admin).Updates(map[string]interface{}{"role": model.UserRoleAdmin, "status": model.UserStatusSuspended})
+	if result, _ := s.ValidateToken(resp.Token); result.Valid {
+		t.Error("管理员被停用后代登录令牌应失效")
+	}
+	db.Model(admin).Update("status", model.UserStatusActive)
+	if result, _ := s.ValidateToken(resp.Token); !result.Valid {
+		t.Error("管理员恢复后代登录令牌在有效期内应可用")
+	}
+
+	// 代登录令牌过期后失效
+	db.Model(&model.Session{}).Where("id = ?", result.SessionID).Update("expired_at", time.Now().Add(-time.Second))
+	if result, _ := s.ValidateToken(resp.Token); result.Valid {
+		t.Error("代登录令牌过期后应失效")
+	}
+}
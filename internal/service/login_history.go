@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/notify"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loginFailed 记录一次已知用户的登录失败，同时写入审计日志和登录记录
+func (s *UserService) loginFailed(ctx context.Context, user *model.User, client *dto.ClientInfo, method, reason string) {
+	s.audit(ctx, model.AuditLoginFailure, user.ID, map[string]interface{}{"method": method, "reason": reason})
+	s.saveLoginHistory(ctx, &model.LoginHistory{
+		UserID: user.ID,
+		Method: method,
+		Reason: reason,
+	}, client)
+}
+
+// loginSucceeded 记录一次成功登录。该设备指纹未曾在该 IP 上成功登录过时视为新设备，
+// 向用户发送提醒；首次登录不提醒
+func (s *UserService) loginSucceeded(ctx context.Context, user *model.User, client *dto.ClientInfo, method string) {
+	fingerprint := utils.DeviceFingerprint(client.UserAgent)
+
+	hasHistory, err := s.loginHistoryRepo.HasSuccessfulLogin(ctx, user.ID)
+	if err != nil {
+		logger.Warn("查询登录记录失败", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	newDevice := false
+	if hasHistory {
+		known, err := s.loginHistoryRepo.IsKnownDevice(ctx, user.ID, fingerprint, client.ClientIP)
+		if err != nil {
+			logger.Warn("查询登录记录失败", zap.Uint("user_id", user.ID), zap.Error(err))
+			return
+		}
+		newDevice = !known
+	}
+
+	record := &model.LoginHistory{
+		UserID:    user.ID,
+		Success:   true,
+		Method:    method,
+		NewDevice: newDevice,
+	}
+	if !s.saveLoginHistory(ctx, record, client) || !newDevice {
+		return
+	}
+
+	// 通知可能需要访问外部服务，不阻塞登录
+	go s.notifyNewDevice(context.WithoutCancel(ctx), user, record)
+}
+
+// saveLoginHistory 补全客户端信息后保存登录记录，失败只记录日志
+func (s *UserService) saveLoginHistory(ctx context.Context, record *model.LoginHistory, client *dto.ClientInfo) bool {
+	record.IP = client.ClientIP
+	record.UserAgent = client.UserAgent
+	if len(record.UserAgent) > maxAuditUserAgentLen {
+		record.UserAgent = strings.ToValidUTF8(record.UserAgent[:maxAuditUserAgentLen], "")
+	}
+	record.DeviceLabel = utils.DeviceLabel(client.UserAgent)
+	record.DeviceFingerprint = utils.DeviceFingerprint(client.UserAgent)
+
+	if err := s.loginHistoryRepo.Create(ctx, record); err != nil {
+		logger.Warn("保存登录记录失败", zap.Uint("user_id", record.UserID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *UserService) notifyNewDevice(ctx context.Context, user *model.User, record *model.LoginHistory) {
+	email := ""
+	if user.EmailVerified() {
+		email = user.EmailAddress()
+	}
+
+	loginTime := record.CreatedAt.Format(time.DateTime)
+	err := s.notifier.Notify(ctx, &notify.Notification{
+		Event:   notify.EventNewDeviceLogin,
+		UserID:  user.ID,
+		Email:   email,
+		Subject: "您的 DDUP 账号在新设备上登录",
+		Body: fmt.Sprintf("%s，您好：\n\n您的账号刚刚在一台新设备上登录：\n\n时间：%s\n设备：%s\nIP：%s\n\n如果这是您本人的操作，请忽略此提醒。如果不是，请立即修改密码，并在登录设备管理中注销该设备。\n",
+			user.Nickname, loginTime, record.DeviceLabel, record.IP),
+		Data: map[string]interface{}{
+			"loginId":     record.ID,
+			"method":      record.Method,
+			"ip":          record.IP,
+			"userAgent":   record.UserAgent,
+			"deviceLabel": record.DeviceLabel,
+			"time":        record.CreatedAt,
+		},
+	})
+	if err != nil {
+		logger.Warn("发送新设备登录提醒失败", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+}
+
+// ListLoginHistory 获取用户的登录记录
+func (s *UserService) ListLoginHistory(ctx context.Context, userID uint, req *dto.PageRequest) (*dto.LoginHistoryListResponse, error) {
+	offset, limit := normalizePage(req)
+	records, total, err := s.loginHistoryRepo.List(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取登录记录失败")
+	}
+
+	items := make([]dto.LoginHistoryResponse, 0, len(records))
+	for _, record := range records {
+		items = append(items, dto.LoginHistoryResponse{
+			ID:          record.ID,
+			Success:     record.Success,
+			Method:      record.Method,
+			Reason:      record.Reason,
+			IP:          record.IP,
+			UserAgent:   record.UserAgent,
+			DeviceLabel: record.DeviceLabel,
+			NewDevice:   record.NewDevice,
+			CreatedAt:   record.CreatedAt,
+		})
+	}
+	return &dto.LoginHistoryListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// PeriodicLoginHistoryPurge 定期删除超过 retention 的登录记录
+func PeriodicLoginHistoryPurge(db *gorm.DB, interval, retention time.Duration) {
+	loginHistoryRepo := repository.NewLoginHistoryRepository(db)
+	purge := func() {
+		purged, err := loginHistoryRepo.Purge(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			logger.Error("清理登录记录失败", zap.Error(err))
+			return
+		}
+		if purged > 0 {
+			logger.Info("已清理过期登录记录", zap.Int64("count", purged))
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		purge()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/notify"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/utils"
+	"testing"
+	"time"
+)
+
+// recordingNotifier 将通知发到 channel，便于等待异步发送的提醒
+type recordingNotifier chan *notify.Notification
+
+func (r recordingNotifier) Notify(ctx context.Context, n *notify.Notification) error {
+	r <- n
+	return nil
+}
+
+func TestLoginSucceededNewDevice(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.User{}, &model.LoginHistory{}, &model.AuditEvent{})
+	notifications := make(recordingNotifier, 10)
+	s := &UserService{
+		userRepo:         repository.NewUserRepository(db),
+		loginHistoryRepo: repository.NewLoginHistoryRepository(db),
+		auditRepo:        repository.NewAuditRepository(db),
+		notifier:         notifications,
+	}
+	user := &model.User{Username: "alice", Nickname: "Alice", Password: "x", Status: model.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		macChrome = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+		winEdge   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0"
+	)
+	tests := []struct {
+		name      string
+		client    dto.ClientInfo
+		newDevice bool
+	}{
+		{"首次登录不提醒", dto.ClientInfo{UserAgent: macChrome, ClientIP: "10.0.0.1"}, false},
+		{"同一设备", dto.ClientInfo{UserAgent: macChrome, ClientIP: "10.0.0.1"}, false},
+		{"已知设备换了 IP", dto.ClientInfo{UserAgent: macChrome, ClientIP: "10.0.0.2"}, true},
+		{"已知 IP 换了设备", dto.ClientInfo{UserAgent: winEdge, ClientIP: "10.0.0.1"}, true},
+		{"已知设备回到已知 IP", dto.ClientInfo{UserAgent: macChrome, ClientIP: "10.0.0.2"}, false},
+		{"新设备和新 IP", dto.ClientInfo{UserAgent: "curl/8.0", ClientIP: "192.0.2.1"}, true},
+	}
+	for _, tt := range tests {
+		s.loginSucceeded(ctx, user, &tt.client, "password")
+
+		var record model.LoginHistory
+		if err := db.Order("id DESC").First(&record).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !record.Success || record.NewDevice != tt.newDevice || record.IP != tt.client.ClientIP {
+			t.Errorf("%s: 登录记录 = %+v", tt.name, record)
+		}
+
+		select {
+		case n := <-notifications:
+			if !tt.newDevice {
+				t.Errorf("%s: 不应发送提醒", tt.name)
+			} else if n.Event != notify.EventNewDeviceLogin || n.UserID != user.ID || n.Data["ip"] != tt.client.ClientIP {
+				t.Errorf("%s: 提醒内容 = %+v", tt.name, n)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if tt.newDevice {
+				t.Errorf("%s: 应发送新设备提醒", tt.name)
+			}
+		}
+	}
+
+	// 失败的登录不计入已知设备
+	s.loginFailed(ctx, user, &dto.ClientInfo{UserAgent: "Wget/1.21", ClientIP: "198.51.100.1"}, "password", "invalid_password")
+	known, err := s.loginHistoryRepo.IsKnownDevice(ctx, user.ID, utils.DeviceFingerprint("Wget/1.21"), "198.51.100.1")
+	if err != nil || known {
+		t.Errorf("登录失败的 IP 不应视为已知设备: %v, %v", known, err)
+	}
+}
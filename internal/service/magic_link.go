@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/mail"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SendMagicLink 向已验证的邮箱发送一次性登录链接。为避免泄露邮箱是否注册，
+// 邮箱不存在或未验证时也视为成功；发送频率按邮箱和 IP 分别限制
+func (s *UserService) SendMagicLink(ctx context.Context, req *dto.MagicLinkRequest, client *dto.ClientInfo) error {
+	email := normalizeEmail(req.Email)
+	if !s.magicLinkIPLimiter.Allow(client.ClientIP) || !s.magicLinkEmailLimiter.Allow(email) {
+		return errors.New(http.StatusTooManyRequests, "请求过于频繁，请稍后再试", nil)
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil || !user.EmailVerified() || !canLoginOrRestore(user) {
+		logger.Info("登录链接请求的邮箱无对应可用账号", zap.String("email", email))
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.Wrap(err, "生成登录令牌失败")
+	}
+
+	// 新链接发出后，之前发出的链接全部作废
+	if err := s.magicLinkRepo.InvalidateUserTokens(ctx, user.ID); err != nil {
+		return errors.Wrap(err, "作废旧令牌失败")
+	}
+
+	cfg := config.GetConfig()
+	if err := s.magicLinkRepo.Create(ctx, &model.MagicLinkToken{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: utils.HashToken(token),
+		RequestIP: client.ClientIP,
+		ExpiredAt: time.Now().Add(cfg.MagicLink.TTL),
+	}); err != nil {
+		return errors.Wrap(err, "保存登录令牌失败")
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s", strings.TrimRight(cfg.App.URL, "/"), token)
+	if err := s.mailer.Send(ctx, &mail.Message{
+		To:      email,
+		Subject: "登录 DDUP",
+		Body: fmt.Sprintf("%s，您好：\n\n请在 %d 分钟内打开以下链接登录 DDUP，链接只能使用一次：\n\n%s\n\n请求来自 IP %s。如果这不是您本人的操作，请忽略本邮件。\n",
+			user.Nickname, int(cfg.MagicLink.TTL.Minutes()), link, client.ClientIP),
+	}); err != nil {
+		return errors.Wrap(err, "发送登录邮件失败")
+	}
+
+	return nil
+}
+
+// LoginWithMagicLink 使用邮件登录链接登录，链接只能使用一次。开启两步验证的账号仍需提交验证码
+func (s *UserService) LoginWithMagicLink(ctx context.Context, req *dto.MagicLinkLoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	token, err := s.magicLinkRepo.GetByTokenHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return nil, errors.Wrap(err, "查询登录令牌失败")
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiredAt) {
+		return nil, errors.New(401, "登录链接无效或已过期", nil)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(401, "登录链接无效或已过期", err)
+	}
+	if user.EmailAddress() != token.Email {
+		return nil, errors.New(401, "邮箱已变更，登录链接已失效", nil)
+	}
+
+	used, err := s.magicLinkRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "更新登录令牌失败")
+	}
+	if !used {
+		return nil, errors.New(401, "登录链接无效或已过期", nil)
+	}
+
+	if !canLoginOrRestore(user) {
+		return nil, errUserInactive(user.Status)
+	}
+	if user.TwoFactorEnabled() {
+		return s.newTwoFactorChallenge(user)
+	}
+
+	return s.completeLogin(ctx, user, client, "magic_link")
+}
+
+// DisablePassword 关闭密码登录，之后只能通过邮件链接或第三方账号登录。需要已验证的邮箱，
+// 重新设置密码即可恢复
+func (s *UserService) DisablePassword(ctx context.Context, id uint, req *dto.DisablePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return errors.New(404, "用户不存在", err)
+	}
+	if user.Password == "" {
+		return errors.New(400, "未设置密码", nil)
+	}
+	if !user.EmailVerified() {
+		return errors.New(400, "请先验证邮箱，关闭密码后需要通过邮件链接登录", nil)
+	}
+	if ok, _ := utils.VerifyPassword(req.Password, user.Password); !ok {
+		return errors.New(400, "密码错误", nil)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, id, ""); err != nil {
+		return errors.Wrap(err, "关闭密码失败")
+	}
+
+	s.audit(ctx, model.AuditPasswordDisable, id, nil)
+	logger.Info("用户关闭了密码登录", zap.Uint("user_id", id))
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMagicLink(t *testing.T) {
+	ctx := context.Background()
+	s, db, mailer := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.App.URL = "https://ddup.test"
+		cfg.MagicLink.TTL = 15 * time.Minute
+		cfg.MagicLink.EmailLimit = 3
+		cfg.MagicLink.IPLimit = 100
+		cfg.MagicLink.RateWindow = time.Hour
+	})
+	createTestUserWithEmail(t, db, "alice", "alice@example.com", true)
+	bob := createTestUserWithEmail(t, db, "bob", "bob@example.com", true)
+	createTestUserWithEmail(t, db, "carol", "carol@example.com", false)
+	client := &dto.ClientInfo{ClientIP: "10.0.0.1"}
+
+	send := func(email string, client *dto.ClientInfo) error {
+		return s.SendMagicLink(ctx, &dto.MagicLinkRequest{Email: email}, client)
+	}
+	login := func(token string) (*dto.LoginResponse, error) {
+		return s.LoginWithMagicLink(ctx, &dto.MagicLinkLoginRequest{Token: token}, client)
+	}
+
+	// 邮箱不存在或未验证时同样返回成功，但不发送邮件
+	for _, email := range []string{"nobody@example.com", "carol@example.com"} {
+		if err := send(email, client); err != nil {
+			t.Errorf("SendMagicLink(%s) error = %v", email, err)
+		}
+	}
+	if mailer.count() != 0 {
+		t.Fatalf("不存在或未验证的邮箱发送了 %d 封邮件", mailer.count())
+	}
+
+	// 新链接发出后旧链接作废
+	if err := send(" Alice@Example.com ", client); err != nil {
+		t.Fatalf("SendMagicLink() error = %v", err)
+	}
+	first := mailer.lastToken(t)
+	if err := send("alice@example.com", client); err != nil {
+		t.Fatalf("SendMagicLink() error = %v", err)
+	}
+	second := mailer.lastToken(t)
+	if mailer.last().To != "alice@example.com" {
+		t.Errorf("登录邮件收件人 = %s", mailer.last().To)
+	}
+	var stored int64
+	db.Model(&model.MagicLinkToken{}).Where("token_hash = ?", utils.HashToken(second)).Count(&stored)
+	if stored != 1 {
+		t.Error("数据库应只保存令牌摘要")
+	}
+	if _, err := login(first); errorCode(err) != 401 {
+		t.Errorf("旧链接登录: error = %v, want 401", err)
+	}
+
+	// 链接只能使用一次，并发使用时只有一个成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := login(second); err == nil && resp.Token != "" {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("同一链接并发登录成功 %d 次, want 1", succeeded)
+	}
+	if _, err := login(second); errorCode(err) != 401 {
+		t.Errorf("链接重复使用: error = %v, want 401", err)
+	}
+
+	// 过期的链接不能使用
+	if err := send("alice@example.com", client); err != nil {
+		t.Fatal(err)
+	}
+	expired := mailer.lastToken(t)
+	db.Model(&model.MagicLinkToken{}).Where("token_hash = ?", utils.HashToken(expired)).Update("expired_at", time.Now().Add(-time.Minute))
+	if _, err := login(expired); errorCode(err) != 401 {
+		t.Errorf("过期链接登录: error = %v, want 401", err)
+	}
+
+	// 每个邮箱在时间窗口内最多发送 EmailLimit 次
+	if err := send("alice@example.com", client); errorCode(err) != 429 {
+		t.Errorf("超过邮箱发送次数: error = %v, want 429", err)
+	}
+
+	// 邮箱变更后，发往旧邮箱的链接失效
+	if err := send("bob@example.com", &dto.ClientInfo{ClientIP: "10.0.0.2"}); err != nil {
+		t.Fatal(err)
+	}
+	changed := mailer.lastToken(t)
+	db.Model(bob).Update("email", "bob@other.example.com")
+	if _, err := login(changed); errorCode(err) != 401 {
+		t.Errorf("邮箱变更后登录: error = %v, want 401", err)
+	}
+}
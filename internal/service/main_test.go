@@ -0,0 +1,48 @@
+package service
+
+import (
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/logger"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestDB 创建只在本测试中使用的内存 SQLite 数据库并迁移 models
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	logger.Log = zap.NewNop()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	// 每个连接都是独立的内存数据库，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("迁移数据库失败: %v", err)
+	}
+	return db
+}
+
+// testConfig 测试用的全局配置，各测试按需修改后调用 config.SetConfig
+func testConfig() config.Config {
+	var cfg config.Config
+	cfg.Upload.MaxSize = 1 << 20
+	cfg.Upload.UserQuota = 4 << 20
+	cfg.Upload.AllowedTypes = []string{"image/png", "application/pdf"}
+	cfg.Upload.SessionTTL = time.Hour
+	cfg.Upload.URLTTL = 15 * time.Minute
+	cfg.Upload.SigningKey = "test-upload-signing-key-0123456789abcdef"
+	return cfg
+}
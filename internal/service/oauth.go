@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/oauth"
+	"ddup-apis/internal/utils"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// ListOAuthProviders 返回已配置的第三方登录提供方
+func (s *UserService) ListOAuthProviders() []dto.OAuthProviderResponse {
+	names := make([]string, 0, len(s.oauthProviders))
+	for name := range s.oauthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := make([]dto.OAuthProviderResponse, 0, len(names))
+	for _, name := range names {
+		resp = append(resp, dto.OAuthProviderResponse{Name: name})
+	}
+	return resp
+}
+
+// OAuthAuthorize 发起第三方登录或绑定，返回提供方的授权地址。purpose 为 link 时需传入当前用户
+func (s *UserService) OAuthAuthorize(ctx context.Context, providerName, purpose string, userID uint) (*dto.OAuthAuthorizeResponse, error) {
+	provider, ok := s.oauthProviders[providerName]
+	if !ok {
+		return nil, errors.New(404, "不支持的登录方式", nil)
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成授权请求失败")
+	}
+	nonce, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成授权请求失败")
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	url, err := provider.AuthCodeURL(ctx, state, verifier, nonce)
+	if err != nil {
+		return nil, errors.New(502, "连接登录服务失败", err)
+	}
+
+	if err := s.identityRepo.CreateState(ctx, &model.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		Purpose:      purpose,
+		UserID:       userID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiredAt:    time.Now().Add(config.GetConfig().OAuth.StateTTL),
+	}); err != nil {
+		return nil, errors.Wrap(err, "保存授权请求失败")
+	}
+
+	return &dto.OAuthAuthorizeResponse{AuthorizationURL: url, State: state}, nil
+}
+
+// exchangeOAuthCode 校验 state 并用授权码换取第三方身份
+func (s *UserService) exchangeOAuthCode(ctx context.Context, providerName, purpose string, userID uint, req *dto.OAuthCallbackRequest) (*oauth.Identity, error) {
+	provider, ok := s.oauthProviders[providerName]
+	if !ok {
+		return nil, errors.New(404, "不支持的登录方式", nil)
+	}
+
+	state, err := s.identityRepo.ConsumeState(ctx, utils.HashToken(req.State))
+	if err != nil {
+		return nil, errors.Wrap(err, "查询授权请求失败")
+	}
+	if state == nil || state.Provider != providerName || state.Purpose != purpose ||
+		state.UserID != userID || time.Now().After(state.ExpiredAt) {
+		return nil, errors.New(400, "授权请求无效或已过期，请重新发起", nil)
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return nil, errors.New(401, "第三方登录验证失败", err)
+	}
+	if identity.Subject == "" {
+		return nil, errors.New(401, "第三方登录验证失败", nil)
+	}
+	return identity, nil
+}
+
+// OAuthLogin 第三方登录回调。已绑定的账号直接登录，首次登录时自动创建账号
+func (s *UserService) OAuthLogin(ctx context.Context, providerName string, req *dto.OAuthCallbackRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	identity, err := s.exchangeOAuthCode(ctx, providerName, model.OAuthPurposeLogin, 0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, providerName, identity.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询绑定账号失败")
+	}
+
+	var user *model.User
+	if linked != nil {
+		user, err = s.userRepo.GetByID(ctx, linked.UserID)
+		if err != nil || user == nil {
+			return nil, errors.New(401, "绑定的账号不存在", err)
+		}
+	} else {
+		user, err = s.createOAuthUser(ctx, providerName, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !canLoginOrRestore(user) {
+		return nil, errUserInactive(user.Status)
+	}
+	if user.TwoFactorEnabled() {
+		return s.newTwoFactorChallenge(user)
+	}
+
+	return s.completeLogin(ctx, user, client, "oauth:"+providerName)
+}
+
+// createOAuthUser 首次第三方登录时创建账号。已验证的邮箱会一并保存，
+// 但该邮箱已被其他账号使用时不会自动绑定，需用户登录原账号后手动绑定
+func (s *UserService) createOAuthUser(ctx context.Context, providerName string, identity *oauth.Identity) (*model.User, error) {
+	user := &model.User{
+		Nickname: oauthNickname(identity),
+		Avatar:   identity.AvatarURL,
+		Status:   model.UserStatusPending,
+	}
+
+	if identity.Email != "" && identity.EmailVerified {
+		email := normalizeEmail(identity.Email)
+		existing, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil {
+			return nil, errors.Wrap(err, "查询用户失败")
+		}
+		if existing != nil {
+			return nil, errors.New(409, "该邮箱已注册，请使用原账号登录后在账号设置中绑定", nil)
+		}
+		now := time.Now()
+		user.Email = &email
+		user.EmailVerifiedAt = &now
+		user.Status = model.UserStatusActive
+	}
+
+	username, err := s.availableUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	user.Username = username
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, errors.Wrap(err, "创建用户失败")
+	}
+	if err := s.identityRepo.Create(ctx, &model.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Username: identity.Username,
+	}); err != nil {
+		return nil, errors.Wrap(err, "绑定第三方账号失败")
+	}
+
+	logger.Info("通过第三方登录创建用户", zap.Uint("user_id", user.ID), zap.String("provider", providerName))
+	return user, nil
+}
+
+// availableUsername 以第三方用户名或邮箱前缀为基础生成未被占用的用户名，冲突时追加数字后缀
+func (s *UserService) availableUsername(ctx context.Context, identity *oauth.Identity) (string, error) {
+	base := sanitizeUsername(identity.Username)
+	if base == "" {
+		base = sanitizeUsername(strings.Split(identity.Email, "@")[0])
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		existing, err := s.userRepo.GetByUsername(ctx, candidate)
+		if err != nil {
+			return "", errors.Wrap(err, "查询用户失败")
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, 1000+rand.Intn(9000))
+	}
+	return "", errors.New(409, "无法生成可用的用户名，请稍后重试", nil)
+}
+
+// sanitizeUsername 只保留用户名允许的字母和数字
+func sanitizeUsername(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+func oauthNickname(identity *oauth.Identity) string {
+	for _, name := range []string{identity.Name, identity.Username} {
+		if name = strings.TrimSpace(name); name != "" {
+			if len([]rune(name)) > 20 {
+				name = string([]rune(name)[:20])
+			}
+			return name
+		}
+	}
+	return "user"
+}
+
+// LinkIdentity 为当前用户绑定第三方账号
+func (s *UserService) LinkIdentity(ctx context.Context, userID uint, providerName string, req *dto.OAuthCallbackRequest) (*dto.IdentityResponse, error) {
+	identity, err := s.exchangeOAuthCode(ctx, providerName, model.OAuthPurposeLink, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, providerName, identity.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询绑定账号失败")
+	}
+	if linked != nil {
+		if linked.UserID == userID {
+			return nil, errors.New(400, "已绑定该账号", nil)
+		}
+		return nil, errors.New(409, "该第三方账号已绑定其他用户", nil)
+	}
+
+	record := &model.UserIdentity{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Username: identity.Username,
+	}
+	if err := s.identityRepo.Create(ctx, record); err != nil {
+		return nil, errors.Wrap(err, "绑定第三方账号失败")
+	}
+
+	s.audit(ctx, model.AuditIdentityLink, userID, map[string]interface{}{"provider": providerName, "identityId": record.ID})
+	logger.Info("用户绑定第三方账号", zap.Uint("user_id", userID), zap.String("provider", providerName))
+	resp := toIdentityResponse(record)
+	return &resp, nil
+}
+
+func (s *UserService) ListIdentities(ctx context.Context, userID uint) ([]dto.IdentityResponse, error) {
+	identities, err := s.identityRepo.GetUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取绑定账号失败")
+	}
+
+	resp := make([]dto.IdentityResponse, 0, len(identities))
+	for i := range identities {
+		resp = append(resp, toIdentityResponse(&identities[i]))
+	}
+	return resp, nil
+}
+
+// UnlinkIdentity 解除绑定。既没有密码也没有已验证邮箱的账号不能解除最后一个绑定，否则将无法登录
+func (s *UserService) UnlinkIdentity(ctx context.Context, userID uint, id uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return errors.New(404, "用户不存在", err)
+	}
+
+	if user.Password == "" && !user.EmailVerified() {
+		identities, err := s.identityRepo.GetUserIdentities(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "获取绑定账号失败")
+		}
+		if len(identities) <= 1 {
+			return errors.New(400, "请先设置密码或验证邮箱再解除最后一个绑定账号", nil)
+		}
+	}
+
+	deleted, err := s.identityRepo.Delete(ctx, userID, id)
+	if err != nil {
+		return errors.Wrap(err, "解除绑定失败")
+	}
+	if !deleted {
+		return errors.New(404, "绑定账号不存在", nil)
+	}
+	s.audit(ctx, model.AuditIdentityUnlink, userID, map[string]interface{}{"identityId": id})
+	return nil
+}
+
+func toIdentityResponse(identity *model.UserIdentity) dto.IdentityResponse {
+	return dto.IdentityResponse{
+		ID:        identity.ID,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		Username:  identity.Username,
+		CreatedAt: identity.CreatedAt,
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/oauth"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// mockOIDCServer 模拟第三方 OpenID Connect 提供方，提供发现文档、JWKS 和令牌端点。
+// 授权端点不经过 HTTP，由 authorize 模拟用户同意授权后签发授权码
+type mockOIDCServer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// mockAuthorization 授权码对应的授权请求和 id_token 中的用户信息
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+const mockClientID = "ddup"
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCServer{key: key, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.handleToken)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize 校验授权地址中的 PKCE 和 nonce 参数，模拟用户同意授权并返回授权码
+func (m *mockOIDCServer) authorize(t *testing.T, authURL, state string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil || !strings.HasPrefix(authURL, m.URL+"/authorize?") {
+		t.Fatalf("授权地址 = %s", authURL)
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != mockClientID || q.Get("state") != state {
+		t.Fatalf("授权地址参数 = %v", q)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("nonce") == "" {
+		t.Fatalf("授权地址缺少 PKCE 或 nonce 参数: %v", q)
+	}
+
+	code := "code-" + q.Get("state")[:8]
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+// handleToken 授权码只能使用一次，code_verifier 必须与授权请求的 code_challenge 对应
+func (m *mockOIDCServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.Form.Get("client_id")
+	}
+
+	m.mu.Lock()
+	auth, found := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !found || clientID != mockClientID || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   m.URL,
+		"aud":   mockClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestOAuthService 创建使用模拟 OIDC 提供方 mock 的 UserService
+func newTestOAuthService(t *testing.T) (*UserService, *gorm.DB, *mockOIDCServer) {
+	t.Helper()
+	server := newMockOIDCServer(t)
+	s, db, _ := newTestAccountService(t, func(cfg *config.Config) { cfg.OAuth.StateTTL = 10 * time.Minute })
+	s.oauthProviders = map[string]oauth.Provider{
+		"mock": oauth.NewOIDCProvider(config.OAuthProvider{
+			Name:         "mock",
+			Type:         "oidc",
+			ClientID:     mockClientID,
+			ClientSecret: "secret",
+			Issuer:       server.URL,
+		}, "http://app.test/oauth/callback"),
+	}
+	return s, db, server
+}
+
+// oauthLogin 走完一次第三方登录：发起授权、模拟用户同意并回调
+func oauthLogin(t *testing.T, s *UserService, server *mockOIDCServer, claims jwt.MapClaims) (*dto.LoginResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	authorize, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLogin, 0)
+	if err != nil {
+		t.Fatalf("OAuthAuthorize() error = %v", err)
+	}
+	code := server.authorize(t, authorize.AuthorizationURL, authorize.State, claims)
+	return s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: authorize.State}, &dto.ClientInfo{})
+}
+
+func TestOAuthLogin(t *testing.T) {
+	ctx := context.Background()
+	s, _, server := newTestOAuthService(t)
+
+	if _, err := s.OAuthAuthorize(ctx, "unknown", model.OAuthPurposeLogin, 0); errorCode(err) != 404 {
+		t.Errorf("未配置的提供方: error = %v, want 404", err)
+	}
+
+	// 首次登录创建账号，已验证的邮箱一并保存
+	alice := jwt.MapClaims{"sub": "sub-alice", "preferred_username": "alice", "name": "Alice", "email": "Alice@Example.com", "email_verified": true}
+	first, err := oauthLogin(t, s, server, alice)
+	if err != nil {
+		t.Fatalf("首次登录: error = %v", err)
+	}
+	user, err := s.userRepo.GetByUsername(ctx, first.User.Username)
+	if err != nil || user == nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if user.Username != "alice" || user.Nickname != "Alice" || user.Status != model.UserStatusActive ||
+		user.EmailAddress() != "alice@example.com" || !user.EmailVerified() || user.Password != "" {
+		t.Errorf("创建的用户 = %+v", user)
+	}
+	if first.TokenResponse == nil || first.Token == "" {
+		t.Error("登录后应签发 token")
+	}
+
+	// 再次登录使用已绑定的账号
+	again, err := oauthLogin(t, s, server, alice)
+	if err != nil || again.User.Username != user.Username {
+		t.Fatalf("再次登录: %+v, %v", again, err)
+	}
+
+	// 用户名冲突时追加数字后缀，邮箱未验证的账号为待验证状态
+	bob, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-bob", "preferred_username": "alice", "email": "bob@example.com"})
+	if err != nil {
+		t.Fatalf("用户名冲突: error = %v", err)
+	}
+	if name := bob.User.Username; name == "alice" || !strings.HasPrefix(name, "alice") || len(name) != len("alice")+4 {
+		t.Errorf("冲突时的用户名 = %s", name)
+	}
+	if saved, _ := s.userRepo.GetByUsername(ctx, bob.User.Username); saved.Status != model.UserStatusPending || saved.Email != nil {
+		t.Errorf("邮箱未验证的用户 = %+v", saved)
+	}
+	// 没有可用的用户名时使用邮箱前缀，过短时加上 user 前缀
+	carol, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-carol", "preferred_username": "李", "email": "c@example.com"})
+	if err != nil || carol.User.Username != "userc" {
+		t.Errorf("用户名 = %v, %v, want userc", carol, err)
+	}
+
+	// 邮箱已被其他账号使用时不自动绑定
+	if _, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-mallory", "email": "alice@example.com", "email_verified": true}); errorCode(err) != 409 {
+		t.Errorf("邮箱已注册: error = %v, want 409", err)
+	}
+}
+
+func TestOAuthLoginStateAndPKCE(t *testing.T) {
+	ctx := context.Background()
+	s, db, server := newTestOAuthService(t)
+	claims := jwt.MapClaims{"sub": "sub-alice", "preferred_username": "alice"}
+	client := &dto.ClientInfo{}
+
+	// state 只能使用一次
+	authorize, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLogin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := server.authorize(t, authorize.AuthorizationURL, authorize.State, claims)
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: authorize.State}, client); err != nil {
+		t.Fatalf("OAuthLogin() error = %v", err)
+	}
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: authorize.State}, client); errorCode(err) != 400 {
+		t.Errorf("重复使用 state: error = %v, want 400", err)
+	}
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: "forged"}, client); errorCode(err) != 400 {
+		t.Errorf("伪造的 state: error = %v, want 400", err)
+	}
+
+	// 绑定用的 state 不能用于登录
+	link, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLink, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code = server.authorize(t, link.AuthorizationURL, link.State, claims)
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: link.State}, client); errorCode(err) != 400 {
+		t.Errorf("绑定的 state 用于登录: error = %v, want 400", err)
+	}
+
+	// 过期的 state
+	expired, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLogin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code = server.authorize(t, expired.AuthorizationURL, expired.State, claims)
+	if err := db.Model(&model.OAuthState{}).Where("1 = 1").Update("expired_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: code, State: expired.State}, client); errorCode(err) != 400 {
+		t.Errorf("过期的 state: error = %v, want 400", err)
+	}
+
+	// 截获的授权码配合另一次授权请求的 state 使用时，code_verifier 不匹配
+	victim, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLogin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stolen := server.authorize(t, victim.AuthorizationURL, victim.State, claims)
+	attacker, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLogin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OAuthLogin(ctx, "mock", &dto.OAuthCallbackRequest{Code: stolen, State: attacker.State}, client); errorCode(err) != 401 {
+		t.Errorf("code_verifier 不匹配: error = %v, want 401", err)
+	}
+
+	// id_token 中的 nonce 与授权请求不一致
+	if _, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-alice", "nonce": "replayed"}); errorCode(err) != 401 {
+		t.Errorf("nonce 不匹配: error = %v, want 401", err)
+	}
+}
+
+// oauthLink 走完一次绑定流程
+func oauthLink(t *testing.T, s *UserService, server *mockOIDCServer, userID uint, claims jwt.MapClaims) (*dto.IdentityResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	authorize, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLink, userID)
+	if err != nil {
+		t.Fatalf("OAuthAuthorize() error = %v", err)
+	}
+	code := server.authorize(t, authorize.AuthorizationURL, authorize.State, claims)
+	return s.LinkIdentity(ctx, userID, "mock", &dto.OAuthCallbackRequest{Code: code, State: authorize.State})
+}
+
+func TestLinkAndUnlinkIdentity(t *testing.T) {
+	ctx := context.Background()
+	s, db, server := newTestOAuthService(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	identity, err := oauthLink(t, s, server, alice.ID, jwt.MapClaims{"sub": "sub-1", "preferred_username": "alice-gh", "email": "alice@example.com"})
+	if err != nil {
+		t.Fatalf("LinkIdentity() error = %v", err)
+	}
+	if identity.Provider != "mock" || identity.Username != "alice-gh" {
+		t.Errorf("绑定的账号 = %+v", identity)
+	}
+	if _, err := oauthLink(t, s, server, alice.ID, jwt.MapClaims{"sub": "sub-1"}); errorCode(err) != 400 {
+		t.Errorf("重复绑定: error = %v, want 400", err)
+	}
+	if _, err := oauthLink(t, s, server, bob.ID, jwt.MapClaims{"sub": "sub-1"}); errorCode(err) != 409 {
+		t.Errorf("绑定其他用户的第三方账号: error = %v, want 409", err)
+	}
+
+	// 为其他用户发起的绑定请求不能用于自己的账号
+	authorize, err := s.OAuthAuthorize(ctx, "mock", model.OAuthPurposeLink, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := server.authorize(t, authorize.AuthorizationURL, authorize.State, jwt.MapClaims{"sub": "sub-2"})
+	if _, err := s.LinkIdentity(ctx, alice.ID, "mock", &dto.OAuthCallbackRequest{Code: code, State: authorize.State}); errorCode(err) != 400 {
+		t.Errorf("使用其他用户的 state 绑定: error = %v, want 400", err)
+	}
+
+	// 绑定后使用第三方账号登录原账号
+	login, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-1"})
+	if err != nil || login.User.Username != alice.Username {
+		t.Errorf("绑定后登录: %+v, %v", login, err)
+	}
+
+	// 有密码的账号可以解除最后一个绑定；不能解除其他用户的绑定
+	if err := s.UnlinkIdentity(ctx, bob.ID, identity.ID); errorCode(err) != 404 {
+		t.Errorf("解除其他用户的绑定: error = %v, want 404", err)
+	}
+	if err := s.UnlinkIdentity(ctx, alice.ID, identity.ID); err != nil {
+		t.Errorf("UnlinkIdentity() error = %v", err)
+	}
+
+	// 第三方登录创建的账号没有密码，邮箱未验证时不能解除最后一个绑定
+	created, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-3", "preferred_username": "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	carol, err := s.userRepo.GetByUsername(ctx, created.User.Username)
+	if err != nil || carol == nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	second, err := oauthLink(t, s, server, carol.ID, jwt.MapClaims{"sub": "sub-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnlinkIdentity(ctx, carol.ID, second.ID); err != nil {
+		t.Errorf("还有其他绑定时解除: error = %v", err)
+	}
+	identities, err := s.ListIdentities(ctx, carol.ID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("ListIdentities() = %v, %v", identities, err)
+	}
+	if err := s.UnlinkIdentity(ctx, carol.ID, identities[0].ID); errorCode(err) != 400 {
+		t.Errorf("解除最后一个绑定: error = %v, want 400", err)
+	}
+
+	// 邮箱已验证的账号可以通过邮箱登录，允许解除最后一个绑定
+	verified, err := oauthLogin(t, s, server, jwt.MapClaims{"sub": "sub-5", "preferred_username": "dave", "email": "dave@example.com", "email_verified": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dave, err := s.userRepo.GetByUsername(ctx, verified.User.Username)
+	if err != nil || dave == nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	identities, _ = s.ListIdentities(ctx, dave.ID)
+	if len(identities) != 1 {
+		t.Fatalf("ListIdentities() = %v", identities)
+	}
+	if err := s.UnlinkIdentity(ctx, dave.ID, identities[0].ID); err != nil {
+		t.Errorf("邮箱已验证时解除最后一个绑定: error = %v", err)
+	}
+}
@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/utils"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// OIDCError 令牌端点和 userinfo 端点的错误，按 OAuth 2.0 规范以 error/error_description 返回
+type OIDCError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *OIDCError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOIDCError(status int, code, description string) *OIDCError {
+	return &OIDCError{Status: status, Code: code, Description: description}
+}
+
+// OIDCAuthorize 校验应用的授权请求，返回授权确认页面需要展示的信息
+func (s *UserService) OIDCAuthorize(ctx context.Context, userID uint, req *dto.OIDCAuthorizeRequest) (*dto.OIDCAuthorizeResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	consent, err := s.oidcRepo.GetConsent(ctx, userID, client.ClientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询授权记录失败")
+	}
+
+	return &dto.OIDCAuthorizeResponse{
+		Client:          dto.OIDCClientInfo{ClientID: client.ClientID, Name: client.Name},
+		Scopes:          scopes,
+		ConsentRequired: consent == nil || !consent.Covers(scopes),
+	}, nil
+}
+
+// OIDCApprove 用户确认或拒绝授权，返回携带授权码或错误信息的应用回调地址
+func (s *UserService) OIDCApprove(ctx context.Context, userID, sessionID uint, req *dto.OIDCApproveRequest) (*dto.OIDCRedirectResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(ctx, &req.OIDCAuthorizeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Approve {
+		return &dto.OIDCRedirectResponse{
+			RedirectURL: oidcRedirectURL(req.RedirectURI, url.Values{"error": {"access_denied"}, "state": {req.State}}),
+		}, nil
+	}
+
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, errors.New(401, "会话不存在", err)
+	}
+
+	// 合并已授权的 scope，之后申请其中任意组合都无需再次确认
+	granted := scopes
+	consent, err := s.oidcRepo.GetConsent(ctx, userID, client.ClientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询授权记录失败")
+	}
+	if consent != nil {
+		granted = mergeScopes(strings.Fields(consent.Scopes), scopes)
+	}
+	if err := s.oidcRepo.SaveConsent(ctx, userID, client.ClientID, strings.Join(granted, " ")); err != nil {
+		return nil, errors.Wrap(err, "保存授权记录失败")
+	}
+
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成授权码失败")
+	}
+	if err := s.oidcRepo.CreateCode(ctx, &model.OIDCAuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      session.SignedInAt,
+		ExpiredAt:     time.Now().Add(config.GetConfig().OIDC.CodeTTL),
+	}); err != nil {
+		return nil, errors.Wrap(err, "保存授权码失败")
+	}
+
+	return &dto.OIDCRedirectResponse{
+		RedirectURL: oidcRedirectURL(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}),
+	}, nil
+}
+
+// validateAuthorizeRequest 校验客户端、回调地址、scope 和 PKCE 参数，返回客户端和支持的 scope
+func (s *UserService) validateAuthorizeRequest(ctx context.Context, req *dto.OIDCAuthorizeRequest) (*model.OIDCClient, []string, error) {
+	client, err := s.oidcRepo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "查询应用失败")
+	}
+	if client == nil {
+		return nil, nil, errors.New(400, "应用不存在", nil)
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return nil, nil, errors.New(400, "回调地址未登记", nil)
+	}
+	if req.ResponseType != "code" {
+		return nil, nil, errors.New(400, "只支持授权码模式", nil)
+	}
+
+	// 不认识的 scope 直接忽略
+	var scopes []string
+	for _, scope := range strings.Fields(req.Scope) {
+		if containsScope(model.OIDCScopes, scope) {
+			scopes = mergeScopes(scopes, []string{scope})
+		}
+	}
+	if !containsScope(scopes, model.OIDCScopeOpenID) {
+		return nil, nil, errors.New(400, "scope 必须包含 openid", nil)
+	}
+
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return nil, nil, errors.New(400, "code_challenge_method 只支持 S256", nil)
+	}
+	if client.Public() && req.CodeChallenge == "" {
+		return nil, nil, errors.New(400, "公开客户端必须使用 PKCE", nil)
+	}
+
+	return client, scopes, nil
+}
+
+// OIDCToken 令牌端点，支持 authorization_code 和 refresh_token。
+// 应用的令牌复用会话机制，会出现在用户的登录设备列表中，但只能访问 userinfo
+func (s *UserService) OIDCToken(ctx context.Context, req *dto.OIDCTokenRequest, clientInfo *dto.ClientInfo) (*dto.OIDCTokenResponse, error) {
+	client, err := s.authenticateOIDCClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		return s.exchangeOIDCCode(ctx, client, req, clientInfo)
+	case "refresh_token":
+		tokens, err := s.rotateRefreshToken(ctx, req.RefreshToken, client.ClientID, clientInfo)
+		if err != nil {
+			return nil, newOIDCError(400, "invalid_grant", "refresh token 无效或已过期")
+		}
+		// scope 与原授权一致，按规范可以省略
+		return toOIDCTokenResponse(tokens, "", ""), nil
+	default:
+		return nil, newOIDCError(400, "unsupported_grant_type", "不支持的 grant_type")
+	}
+}
+
+// authenticateOIDCClient 校验客户端身份，公开客户端只需 client_id
+func (s *UserService) authenticateOIDCClient(ctx context.Context, clientID, secret string) (*model.OIDCClient, error) {
+	if clientID == "" {
+		return nil, newOIDCError(401, "invalid_client", "缺少 client_id")
+	}
+	client, err := s.oidcRepo.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询应用失败")
+	}
+	if client == nil {
+		return nil, newOIDCError(401, "invalid_client", "应用不存在")
+	}
+	if !client.Public() && subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, newOIDCError(401, "invalid_client", "client secret 错误")
+	}
+	return client, nil
+}
+
+func (s *UserService) exchangeOIDCCode(ctx context.Context, client *model.OIDCClient, req *dto.OIDCTokenRequest, clientInfo *dto.ClientInfo) (*dto.OIDCTokenResponse, error) {
+	code, err := s.oidcRepo.ConsumeCode(ctx, utils.HashToken(req.Code))
+	if err != nil {
+		return nil, errors.Wrap(err, "查询授权码失败")
+	}
+	if code == nil || code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI || time.Now().After(code.ExpiredAt) {
+		return nil, newOIDCError(400, "invalid_grant", "授权码无效或已过期")
+	}
+	if code.CodeChallenge != "" {
+		sum := sha256.Sum256([]byte(req.CodeVerifier))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != code.CodeChallenge {
+			return nil, newOIDCError(400, "invalid_grant", "code_verifier 错误")
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, code.UserID)
+	if err != nil || user == nil || !user.Status.CanLogin() {
+		return nil, newOIDCError(400, "invalid_grant", "用户不存在或已停用")
+	}
+
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.issueTokens(ctx, user.ID, user.Username, &model.Session{
+		FamilyID:    familyID,
+		UserAgent:   clientInfo.UserAgent,
+		ClientIP:    clientInfo.ClientIP,
+		DeviceLabel: client.Name,
+		ClientID:    client.ClientID,
+		Scope:       code.Scopes,
+		SignedInAt:  code.AuthTime,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "生成token失败")
+	}
+
+	idToken, err := s.signIDToken(ctx, user, client.ClientID, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成 ID token 失败")
+	}
+
+	logger.Info("应用通过 OIDC 获取令牌", zap.Uint("user_id", user.ID), zap.String("client_id", client.ClientID))
+	return toOIDCTokenResponse(tokens, code.Scopes, idToken), nil
+}
+
+func (s *UserService) signIDToken(ctx context.Context, user *model.User, clientID string, code *model.OIDCAuthorizationCode) (string, error) {
+	claims, err := s.oidcUserClaims(ctx, user, strings.Fields(code.Scopes))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims["iss"] = config.GetConfig().OIDC.Issuer
+	claims["aud"] = clientID
+	claims["azp"] = clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(config.GetConfig().JWT.ExpiresIn).Unix()
+	claims["auth_time"] = code.AuthTime.Unix()
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	return utils.SignToken(claims)
+}
+
+// OIDCUserInfo userinfo 端点，按授权的 scope 返回用户信息
+func (s *UserService) OIDCUserInfo(ctx context.Context, token string) (map[string]interface{}, error) {
+	invalid := newOIDCError(401, "invalid_token", "access token 无效或已过期")
+	if _, err := utils.ParseToken(token); err != nil {
+		return nil, invalid
+	}
+
+	session, err := s.sessionRepo.GetSessionByTokenHash(ctx, utils.HashToken(token))
+	if err != nil || !session.IsValid || session.ClientID == "" || time.Now().After(session.ExpiredAt) {
+		return nil, invalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil || user == nil || !user.Status.CanLogin() {
+		return nil, invalid
+	}
+
+	claims, err := s.oidcUserClaims(ctx, user, strings.Fields(session.Scope))
+	if err != nil {
+		return nil, errors.Wrap(err, "获取用户信息失败")
+	}
+	return claims, nil
+}
+
+// oidcUserClaims 根据 scope 生成标准 claims，profile 取自用户信息和公开的 general 个人资料
+func (s *UserService) oidcUserClaims(ctx context.Context, user *model.User, scopes []string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{"sub": strconv.FormatUint(uint64(user.ID), 10)}
+
+	if containsScope(scopes, model.OIDCScopeProfile) {
+		claims["preferred_username"] = user.Username
+		claims["nickname"] = user.Nickname
+		claims["name"] = user.Nickname
+		claims["locale"] = user.Language
+		claims["updated_at"] = user.UpdatedAt.Unix()
+		if user.Avatar != "" {
+			claims["picture"] = user.Avatar
+		}
+		if user.Gender != "" && user.Gender != "unknown" {
+			claims["gender"] = user.Gender
+		}
+		if user.Birthday != nil {
+			claims["birthdate"] = user.Birthday.Format("2006-01-02")
+		}
+
+		// 第三方应用按匿名访客处理，只读取公开的资料项
+		profiles, err := s.profileRepo.ListVisible(ctx, user.ID, string(model.General), repository.ProfileViewer{})
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			var metadata model.ProfileMetadata
+			if len(profile.Metadata) > 0 {
+				if err := json.Unmarshal(profile.Metadata, &metadata); err != nil {
+					return nil, err
+				}
+			}
+			if metadata.DisplayName != "" {
+				claims["name"] = metadata.DisplayName
+			}
+			if profile.URL != "" {
+				claims["website"] = profile.URL
+			}
+			break
+		}
+	}
+
+	if containsScope(scopes, model.OIDCScopeEmail) && user.Email != nil {
+		claims["email"] = user.EmailAddress()
+		claims["email_verified"] = user.EmailVerified()
+	}
+
+	return claims, nil
+}
+
+func toOIDCTokenResponse(tokens *dto.TokenResponse, scope, idToken string) *dto.OIDCTokenResponse {
+	return &dto.OIDCTokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      idToken,
+		Scope:        scope,
+	}
+}
+
+func oidcRedirectURL(redirectURI string, params url.Values) string {
+	if params.Get("state") == "" {
+		params.Del("state")
+	}
+	sep := "?"
+	if strings.Contains(redirectURI, "?") {
+		sep = "&"
+	}
+	return redirectURI + sep + params.Encode()
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeScopes 合并两组 scope 并去重，保持原有顺序
+func mergeScopes(a, b []string) []string {
+	merged := append([]string{}, a...)
+	for _, scope := range b {
+		if !containsScope(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	return merged
+}
+
+// ListOIDCConsents 列出用户已授权的应用
+func (s *UserService) ListOIDCConsents(ctx context.Context, userID uint) ([]dto.OIDCConsentResponse, error) {
+	consents, err := s.oidcRepo.GetUserConsents(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取授权记录失败")
+	}
+
+	resp := make([]dto.OIDCConsentResponse, 0, len(consents))
+	for _, consent := range consents {
+		client, err := s.oidcRepo.GetClient(ctx, consent.ClientID)
+		if err != nil {
+			return nil, errors.Wrap(err, "查询应用失败")
+		}
+		if client == nil {
+			continue
+		}
+		resp = append(resp, dto.OIDCConsentResponse{
+			ClientID:   client.ClientID,
+			ClientName: client.Name,
+			Scopes:     strings.Fields(consent.Scopes),
+			UpdatedAt:  consent.UpdatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// RevokeOIDCConsent 撤销对应用的授权，应用持有的令牌立即失效
+func (s *UserService) RevokeOIDCConsent(ctx context.Context, userID uint, clientID string) error {
+	deleted, err := s.oidcRepo.DeleteConsent(ctx, userID, clientID)
+	if err != nil {
+		return errors.Wrap(err, "撤销授权失败")
+	}
+	if !deleted {
+		return errors.New(404, "未授权该应用", nil)
+	}
+
+	if err := s.sessionRepo.InvalidateClientSessions(ctx, clientID, userID); err != nil {
+		return errors.Wrap(err, "撤销应用令牌失败")
+	}
+	s.audit(ctx, model.AuditOIDCConsentRevoke, userID, map[string]interface{}{"clientId": clientID})
+	return nil
+}
+
+// CreateOIDCClient 登记接入 ddup 登录的应用，client secret 只在创建时返回
+func (s *UserService) CreateOIDCClient(ctx context.Context, createdBy uint, req *dto.CreateOIDCClientRequest) (*dto.CreateOIDCClientResponse, error) {
+	clientID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成 client id 失败")
+	}
+
+	client := &model.OIDCClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		RedirectURIs: strings.Join(req.RedirectURIs, " "),
+		CreatedBy:    createdBy,
+	}
+
+	var secret string
+	if !req.Public {
+		secret, err = utils.GenerateRandomToken(32)
+		if err != nil {
+			return nil, errors.Wrap(err, "生成 client secret 失败")
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.oidcRepo.CreateClient(ctx, client); err != nil {
+		return nil, errors.Wrap(err, "创建应用失败")
+	}
+
+	logger.Info("登记 OIDC 应用", zap.String("client_id", clientID), zap.Uint("created_by", createdBy))
+	return &dto.CreateOIDCClientResponse{
+		OIDCClientResponse: toOIDCClientResponse(client),
+		ClientSecret:       secret,
+	}, nil
+}
+
+func (s *UserService) ListOIDCClients(ctx context.Context) ([]dto.OIDCClientResponse, error) {
+	clients, err := s.oidcRepo.ListClients(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取应用列表失败")
+	}
+
+	resp := make([]dto.OIDCClientResponse, 0, len(clients))
+	for i := range clients {
+		resp = append(resp, toOIDCClientResponse(&clients[i]))
+	}
+	return resp, nil
+}
+
+// DeleteOIDCClient 删除应用，所有用户对它的授权和它持有的令牌一并失效
+func (s *UserService) DeleteOIDCClient(ctx context.Context, clientID string) error {
+	deleted, err := s.oidcRepo.DeleteClient(ctx, clientID)
+	if err != nil {
+		return errors.Wrap(err, "删除应用失败")
+	}
+	if !deleted {
+		return errors.New(404, "应用不存在", nil)
+	}
+
+	if err := s.sessionRepo.InvalidateClientSessions(ctx, clientID, 0); err != nil {
+		return errors.Wrap(err, "撤销应用令牌失败")
+	}
+	return nil
+}
+
+func toOIDCClientResponse(client *model.OIDCClient) dto.OIDCClientResponse {
+	return dto.OIDCClientResponse{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIList(),
+		Public:       client.Public(),
+		CreatedAt:    client.CreatedAt,
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"encoding/base64"
+	stderrors "errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testRedirectURI = "https://app.example.com/callback"
+
+// oidcErrorCode 返回 OIDCError 的错误码，不是 OIDCError 时返回空字符串
+func oidcErrorCode(err error) string {
+	var oidcErr *OIDCError
+	if stderrors.As(err, &oidcErr) {
+		return oidcErr.Code
+	}
+	return ""
+}
+
+// approveOIDC 用户确认授权，返回回调地址中的授权码
+func approveOIDC(t *testing.T, s *UserService, userID, sessionID uint, req dto.OIDCAuthorizeRequest) string {
+	t.Helper()
+	resp, err := s.OIDCApprove(context.Background(), userID, sessionID, &dto.OIDCApproveRequest{OIDCAuthorizeRequest: req, Approve: true})
+	if err != nil {
+		t.Fatalf("OIDCApprove() error = %v", err)
+	}
+	redirect, err := url.Parse(resp.RedirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect.Query().Get("state") != req.State || redirect.Query().Get("code") == "" {
+		t.Fatalf("OIDCApprove() 回调地址 = %s", resp.RedirectURL)
+	}
+	return redirect.Query().Get("code")
+}
+
+func TestOIDCAuthorizationCodeWithPKCE(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.OIDC.Issuer = "https://ddup.example.com"
+		cfg.OIDC.CodeTTL = time.Minute
+	})
+	alice := createTestUser(t, db, "alice")
+	login, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := sessionIDOf(t, s, login.Token)
+
+	client, err := s.CreateOIDCClient(ctx, alice.ID, &dto.CreateOIDCClientRequest{Name: "应用", RedirectURIs: []string{testRedirectURI}, Public: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ClientSecret != "" || !client.Public {
+		t.Errorf("公开客户端 = %+v, 不应返回 client secret", client)
+	}
+
+	verifier := "test-code-verifier-0123456789abcdefghijklmnopqrstuvwxyz"
+	sum := sha256.Sum256([]byte(verifier))
+	valid := dto.OIDCAuthorizeRequest{
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		ResponseType:        "code",
+		Scope:               "openid profile unknown",
+		State:               "state-1",
+		Nonce:               "nonce-1",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *dto.OIDCAuthorizeRequest)
+	}{
+		{"应用不存在", func(req *dto.OIDCAuthorizeRequest) { req.ClientID = "unknown" }},
+		{"回调地址未登记", func(req *dto.OIDCAuthorizeRequest) { req.RedirectURI = "https://evil.example.com/callback" }},
+		{"不是授权码模式", func(req *dto.OIDCAuthorizeRequest) { req.ResponseType = "token" }},
+		{"缺少 openid", func(req *dto.OIDCAuthorizeRequest) { req.Scope = "profile" }},
+		{"公开客户端不使用 PKCE", func(req *dto.OIDCAuthorizeRequest) { req.CodeChallenge, req.CodeChallengeMethod = "", "" }},
+		{"plain challenge", func(req *dto.OIDCAuthorizeRequest) { req.CodeChallengeMethod = "plain" }},
+	}
+	for _, tt := range tests {
+		req := valid
+		tt.modify(&req)
+		if _, err := s.OIDCAuthorize(ctx, alice.ID, &req); errorCode(err) != 400 {
+			t.Errorf("%s: OIDCAuthorize() error = %v, want 400", tt.name, err)
+		}
+	}
+
+	authorize, err := s.OIDCAuthorize(ctx, alice.ID, &valid)
+	if err != nil {
+		t.Fatalf("OIDCAuthorize() error = %v", err)
+	}
+	if !authorize.ConsentRequired || len(authorize.Scopes) != 2 {
+		t.Errorf("首次授权 OIDCAuthorize() = %+v, 应需要确认且忽略未知 scope", authorize)
+	}
+
+	// 用户拒绝授权时回调 access_denied，不发授权码
+	denied, err := s.OIDCApprove(ctx, alice.ID, sessionID, &dto.OIDCApproveRequest{OIDCAuthorizeRequest: valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect, _ := url.Parse(denied.RedirectURL); redirect.Query().Get("error") != "access_denied" || redirect.Query().Has("code") {
+		t.Errorf("拒绝授权的回调地址 = %s", denied.RedirectURL)
+	}
+
+	exchange := func(code, verifier string) (*dto.OIDCTokenResponse, error) {
+		return s.OIDCToken(ctx, &dto.OIDCTokenRequest{
+			GrantType:    "authorization_code",
+			Code:         code,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: verifier,
+			ClientID:     client.ClientID,
+		}, &dto.ClientInfo{})
+	}
+
+	// code_verifier 错误时授权码作废，不能再用正确的 verifier 兑换
+	code := approveOIDC(t, s, alice.ID, sessionID, valid)
+	if _, err := exchange(code, "wrong-verifier"); oidcErrorCode(err) != "invalid_grant" {
+		t.Errorf("错误的 code_verifier: error = %v, want invalid_grant", err)
+	}
+	if _, err := exchange(code, verifier); oidcErrorCode(err) != "invalid_grant" {
+		t.Errorf("作废的授权码: error = %v, want invalid_grant", err)
+	}
+
+	code = approveOIDC(t, s, alice.ID, sessionID, valid)
+	tokens, err := exchange(code, verifier)
+	if err != nil {
+		t.Fatalf("OIDCToken() error = %v", err)
+	}
+	if _, err := exchange(code, verifier); oidcErrorCode(err) != "invalid_grant" {
+		t.Errorf("授权码重复使用: error = %v, want invalid_grant", err)
+	}
+
+	idClaims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokens.IDToken, idClaims); err != nil {
+		t.Fatal(err)
+	}
+	if idClaims["aud"] != client.ClientID || idClaims["nonce"] != "nonce-1" || idClaims["iss"] != "https://ddup.example.com" || idClaims["preferred_username"] != "alice" {
+		t.Errorf("ID token claims = %v", idClaims)
+	}
+	userInfo, err := s.OIDCUserInfo(ctx, tokens.AccessToken)
+	if err != nil || userInfo["preferred_username"] != "alice" {
+		t.Errorf("OIDCUserInfo() = %v, %v", userInfo, err)
+	}
+
+	// 登录令牌不能访问 userinfo
+	if _, err := s.OIDCUserInfo(ctx, login.Token); oidcErrorCode(err) != "invalid_token" {
+		t.Errorf("登录令牌访问 userinfo: error = %v, want invalid_token", err)
+	}
+}
+
+func TestOIDCConsent(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.OIDC.Issuer = "https://ddup.example.com"
+		cfg.OIDC.CodeTTL = time.Minute
+	})
+	alice := createTestUser(t, db, "alice")
+	login, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := sessionIDOf(t, s, login.Token)
+
+	client, err := s.CreateOIDCClient(ctx, alice.ID, &dto.CreateOIDCClientRequest{Name: "应用", RedirectURIs: []string{testRedirectURI}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ClientSecret == "" || client.Public {
+		t.Fatalf("机密客户端 = %+v, 应返回 client secret", client)
+	}
+
+	req := dto.OIDCAuthorizeRequest{ClientID: client.ClientID, RedirectURI: testRedirectURI, ResponseType: "code", Scope: "openid profile"}
+	code := approveOIDC(t, s, alice.ID, sessionID, req)
+
+	// 机密客户端必须提供正确的 client secret
+	tokenReq := &dto.OIDCTokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: client.ClientID, ClientSecret: "wrong"}
+	if _, err := s.OIDCToken(ctx, tokenReq, &dto.ClientInfo{}); oidcErrorCode(err) != "invalid_client" {
+		t.Errorf("错误的 client secret: error = %v, want invalid_client", err)
+	}
+	tokenReq.ClientSecret = client.ClientSecret
+	tokens, err := s.OIDCToken(ctx, tokenReq, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatalf("OIDCToken() error = %v", err)
+	}
+
+	// 已授权的 scope 及其子集无需再次确认，新增 scope 需要确认
+	for scope, want := range map[string]bool{"openid": false, "openid profile": false, "openid email": true} {
+		req.Scope = scope
+		resp, err := s.OIDCAuthorize(ctx, alice.ID, &req)
+		if err != nil || resp.ConsentRequired != want {
+			t.Errorf("scope %q: OIDCAuthorize() = %+v, %v, want ConsentRequired %v", scope, resp, err, want)
+		}
+	}
+	req.Scope = "openid email"
+	approveOIDC(t, s, alice.ID, sessionID, req)
+	req.Scope = "openid profile email"
+	if resp, err := s.OIDCAuthorize(ctx, alice.ID, &req); err != nil || resp.ConsentRequired {
+		t.Errorf("合并授权后 OIDCAuthorize() = %+v, %v, 不应再需要确认", resp, err)
+	}
+
+	consents, err := s.ListOIDCConsents(ctx, alice.ID)
+	if err != nil || len(consents) != 1 || len(consents[0].Scopes) != 3 {
+		t.Fatalf("ListOIDCConsents() = %+v, %v", consents, err)
+	}
+
+	// 撤销授权后应用的令牌立即失效，用户自己的登录会话不受影响
+	if err := s.RevokeOIDCConsent(ctx, alice.ID, client.ClientID); err != nil {
+		t.Fatalf("RevokeOIDCConsent() error = %v", err)
+	}
+	if _, err := s.OIDCUserInfo(ctx, tokens.AccessToken); oidcErrorCode(err) != "invalid_token" {
+		t.Errorf("撤销授权后 userinfo: error = %v, want invalid_token", err)
+	}
+	refreshReq := &dto.OIDCTokenRequest{GrantType: "refresh_token", RefreshToken: tokens.RefreshToken, ClientID: client.ClientID, ClientSecret: client.ClientSecret}
+	if _, err := s.OIDCToken(ctx, refreshReq, &dto.ClientInfo{}); oidcErrorCode(err) != "invalid_grant" {
+		t.Errorf("撤销授权后刷新: error = %v, want invalid_grant", err)
+	}
+	if result, _ := s.ValidateToken(login.Token); !result.Valid {
+		t.Error("撤销应用授权不应影响用户的登录会话")
+	}
+	if err := s.RevokeOIDCConsent(ctx, alice.ID, client.ClientID); errorCode(err) != 404 {
+		t.Errorf("重复撤销: error = %v, want 404", err)
+	}
+	req.Scope = "openid"
+	if resp, err := s.OIDCAuthorize(ctx, alice.ID, &req); err != nil || !resp.ConsentRequired {
+		t.Errorf("撤销后 OIDCAuthorize() = %+v, %v, 应重新确认", resp, err)
+	}
+}
@@ -15,17 +15,24 @@ import (
 )
 
 type OrganizationService struct {
-	orgRepo  *repository.OrganizationRepository
-	userRepo repository.IUserRepository
+	orgRepo   *repository.OrganizationRepository
+	userRepo  repository.IUserRepository
+	auditRepo repository.IAuditRepository
 }
 
 func NewOrganizationService(db *gorm.DB) *OrganizationService {
 	return &OrganizationService{
-		orgRepo:  repository.NewOrganizationRepository(db),
-		userRepo: repository.NewUserRepository(db),
+		orgRepo:   repository.NewOrganizationRepository(db),
+		userRepo:  repository.NewUserRepository(db),
+		auditRepo: repository.NewAuditRepository(db),
 	}
 }
 
+// audit 记录组织成员相关的审计事件，userID 为受影响的成员
+func (s *OrganizationService) audit(ctx context.Context, action model.AuditAction, orgID, userID uint, metadata map[string]interface{}) {
+	recordAudit(ctx, s.auditRepo, &model.AuditEvent{Action: action, UserID: userID, OrganizationID: orgID}, metadata)
+}
+
 // CreateOrganization 创建组织
 func (s *OrganizationService) CreateOrganization(ctx context.Context, userID uint, req *dto.CreateOrganizationRequest) error {
 	// 验证组织名称
@@ -59,8 +66,12 @@ func (s *OrganizationService) CreateOrganization(ctx context.Context, userID uin
 		}
 		return txOrgRepo.AddMember(ctx, member)
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	s.audit(ctx, model.AuditOrgMemberAdd, org.ID, userID, map[string]interface{}{"role": "admin"})
+	return nil
 }
 
 // GetUserOrganizations 获取用户的组织列表
@@ -129,7 +140,117 @@ func (s *OrganizationService) UpdateOrganization(ctx context.Context, orgID uint
 
 // DeleteOrganization 删除组织
 func (s *OrganizationService) DeleteOrganization(ctx context.Context, orgID uint) error {
-	return s.orgRepo.Delete(ctx, orgID)
+	if err := s.orgRepo.Delete(ctx, orgID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, model.AuditOrgDelete, orgID, 0, nil)
+	return nil
+}
+
+// ListOrganizations 管理员查询全部组织
+func (s *OrganizationService) ListOrganizations(ctx context.Context, req *dto.AdminOrganizationQueryRequest) (*dto.AdminOrganizationListResponse, error) {
+	offset, limit := normalizePage(&req.PageRequest)
+	orgs, total, err := s.orgRepo.List(ctx, strings.TrimSpace(req.Query), offset, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询组织失败")
+	}
+
+	ids := make([]uint, 0, len(orgs))
+	for _, org := range orgs {
+		ids = append(ids, org.ID)
+	}
+	counts := map[uint]int64{}
+	if len(ids) > 0 {
+		if counts, err = s.orgRepo.CountMembers(ctx, ids); err != nil {
+			return nil, errors.Wrap(err, "统计组织成员失败")
+		}
+	}
+
+	items := make([]dto.AdminOrganizationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		items = append(items, dto.AdminOrganizationResponse{
+			ID:          org.ID,
+			Name:        org.Name,
+			DisplayName: org.DisplayName,
+			Email:       org.Email,
+			Website:     org.Website,
+			MemberCount: counts[org.ID],
+			CreatedAt:   org.CreatedAt,
+		})
+	}
+	return &dto.AdminOrganizationListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// TransferOrganization 将组织转交给指定用户：该用户成为唯一的组织管理员（不是成员时自动加入），
+// 原有的管理员降为普通成员
+func (s *OrganizationService) TransferOrganization(ctx context.Context, orgID uint, username string) error {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil {
+		return errors.New(404, "用户不存在", nil)
+	}
+
+	var demoted []uint
+	joined, promoted := false, false
+	err = s.orgRepo.DB().Transaction(func(tx *gorm.DB) error {
+		txOrgRepo := s.orgRepo.WithTransaction(tx)
+		admins, err := txOrgRepo.GetMembersByRole(ctx, orgID, "admin")
+		if err != nil {
+			return err
+		}
+		wasAdmin := false
+		for _, admin := range admins {
+			if admin.UserID == user.ID {
+				wasAdmin = true
+				continue
+			}
+			if err := txOrgRepo.UpdateMember(ctx, orgID, admin.UserID, map[string]interface{}{"role": "member"}); err != nil {
+				return err
+			}
+			demoted = append(demoted, admin.UserID)
+		}
+
+		isMember, err := txOrgRepo.IsMember(ctx, orgID, user.ID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			joined = true
+			return txOrgRepo.AddMember(ctx, &model.OrganizationMember{
+				OrganizationID: orgID,
+				UserID:         user.ID,
+				Role:           "admin",
+			})
+		}
+		if wasAdmin {
+			return nil
+		}
+		promoted = true
+		return txOrgRepo.UpdateMember(ctx, orgID, user.ID, map[string]interface{}{"role": "admin"})
+	})
+	if err != nil {
+		return errors.Wrap(err, "转交组织失败")
+	}
+
+	for _, userID := range demoted {
+		s.audit(ctx, model.AuditOrgMemberRoleChange, orgID, userID, map[string]interface{}{"from": "admin", "to": "member"})
+	}
+	if joined {
+		s.audit(ctx, model.AuditOrgMemberAdd, orgID, user.ID, map[string]interface{}{"role": "admin"})
+	}
+	if promoted {
+		s.audit(ctx, model.AuditOrgMemberRoleChange, orgID, user.ID, map[string]interface{}{"from": "member", "to": "admin"})
+	}
+	s.audit(ctx, model.AuditOrgTransfer, orgID, user.ID, nil)
+	return nil
 }
 
 // GetMembers 获取组织成员列表
@@ -149,7 +270,7 @@ func (s *OrganizationService) GetMembers(ctx context.Context, orgID uint) ([]dto
 		resp = append(resp, dto.MemberResponse{
 			Username: user.Username,
 			Nickname: user.Nickname,
-			Email:    user.Email,
+			Email:    user.EmailAddress(),
 			Avatar:   user.Avatar,
 			Role:     member.Role,
 			JoinedAt: member.CreatedAt,
@@ -163,7 +284,7 @@ func (s *OrganizationService) GetMembers(ctx context.Context, orgID uint) ([]dto
 func (s *OrganizationService) AddMember(ctx context.Context, orgID uint, username string, role string) error {
 	// 获取用户信息
 	user, err := s.userRepo.GetByUsername(ctx, username)
-	if err != nil {
+	if err != nil || user == nil {
 		return errors.New(404, "用户不存在", err)
 	}
 
@@ -181,7 +302,12 @@ func (s *OrganizationService) AddMember(ctx context.Context, orgID uint, usernam
 		UserID:         user.ID,
 		Role:           role,
 	}
-	return s.orgRepo.AddMember(ctx, member)
+	if err := s.orgRepo.AddMember(ctx, member); err != nil {
+		return err
+	}
+
+	s.audit(ctx, model.AuditOrgMemberAdd, orgID, user.ID, map[string]interface{}{"role": role})
+	return nil
 }
 
 // RemoveMember 从组织中移除成员
@@ -191,9 +317,17 @@ func (s *OrganizationService) RemoveMember(ctx context.Context, orgID uint, user
 	if err != nil {
 		return err
 	}
+	if user == nil {
+		return errors.New(404, "用户不存在", nil)
+	}
 
 	// 从组织成员表中删除记录
-	return s.orgRepo.RemoveMember(ctx, orgID, user.ID)
+	if err := s.orgRepo.RemoveMember(ctx, orgID, user.ID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, model.AuditOrgMemberRemove, orgID, user.ID, nil)
+	return nil
 }
 
 func (s *OrganizationService) ValidateOrgName(name string) error {
@@ -234,10 +368,15 @@ func (s *OrganizationService) CheckMemberRole(ctx context.Context, orgID, userID
 func (s *OrganizationService) UpdateMember(ctx context.Context, orgID uint, username string, req *dto.UpdateMemberRequest) error {
 	// 根据用户名获取用户ID
 	user, err := s.userRepo.GetByUsername(ctx, username)
-	if err != nil {
+	if err != nil || user == nil {
 		return fmt.Errorf("用户不存在")
 	}
 
+	oldRole, err := s.CheckMemberRole(ctx, orgID, user.ID)
+	if err != nil {
+		return fmt.Errorf("用户不是组织成员")
+	}
+
 	// 更新成员信息
 	updates := map[string]interface{}{
 		"role": req.Role,
@@ -246,5 +385,8 @@ func (s *OrganizationService) UpdateMember(ctx context.Context, orgID uint, user
 		return err
 	}
 
+	if oldRole != req.Role {
+		s.audit(ctx, model.AuditOrgMemberRoleChange, orgID, user.ID, map[string]interface{}{"from": oldRole, "to": req.Role})
+	}
 	return nil
 }
@@ -2,88 +2,370 @@ package service
 
 import (
 	"context"
+	"ddup-apis/internal/config"
 	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
 	"ddup-apis/internal/model"
 	"ddup-apis/internal/repository"
+	"ddup-apis/internal/schema"
+	"ddup-apis/internal/storage"
+	"ddup-apis/internal/utils"
 	"encoding/json"
-	"errors"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type ProfileService struct {
-	repo *repository.ProfileRepository
+	repo           *repository.ProfileRepository
+	userRepo       repository.IUserRepository
+	attachmentRepo repository.IAttachmentRepository
+	store          storage.Storage
 }
 
-func NewProfileService(db *gorm.DB) *ProfileService {
+func NewProfileService(db *gorm.DB, store storage.Storage) *ProfileService {
 	return &ProfileService{
-		repo: repository.NewProfileRepository(db),
+		repo:           repository.NewProfileRepository(db),
+		userRepo:       repository.NewUserRepository(db),
+		attachmentRepo: repository.NewAttachmentRepository(db),
+		store:          store,
 	}
 }
 
 func (s *ProfileService) Create(ctx context.Context, userID uint, req *dto.CreateProfileRequest) error {
+	if !slices.Contains(model.ProfileTypes, model.ProfileType(req.Type)) {
+		return errors.Validation("无效的请求参数", []errors.FieldError{
+			{Field: "type", Code: "oneof", Message: "不支持的资料类型"},
+		})
+	}
+	schemaVersion, err := validateMetadata(model.ProfileType(req.Type), req.Metadata)
+	if err != nil {
+		return err
+	}
+
 	profile := &model.Profile{
-		UserID:       userID,
-		Type:         model.ProfileType(req.Type),
-		Title:        req.Title,
-		Year:         req.Year,
-		StartDate:    req.StartDate,
-		EndDate:      req.EndDate,
-		Organization: req.Organization,
-		Location:     req.Location,
-		URL:          req.URL,
-		Description:  req.Description,
-		Metadata:     json.RawMessage(req.Metadata),
-		Visibility:   req.Visibility,
-	}
-	return s.repo.Create(ctx, profile)
+		UserID:        userID,
+		Type:          model.ProfileType(req.Type),
+		Title:         req.Title,
+		Year:          req.Year,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		Organization:  req.Organization,
+		Location:      req.Location,
+		URL:           req.URL,
+		Description:   req.Description,
+		Metadata:      json.RawMessage(req.Metadata),
+		Visibility:    req.Visibility,
+		SchemaVersion: schemaVersion,
+	}
+	if err := s.repo.Create(ctx, profile); err != nil {
+		return errors.Wrap(err, "创建资料失败")
+	}
+	return nil
 }
 
 func (s *ProfileService) GetByID(ctx context.Context, userID, profileID uint) (*dto.ProfileResponse, error) {
-	profile, err := s.repo.GetByID(ctx, profileID)
+	viewer := repository.ProfileViewer{UserID: userID}
+	profile, err := s.repo.GetVisible(ctx, profileID, viewer)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "获取资料失败")
 	}
-
-	if profile.UserID != userID {
-		return nil, errors.New("无权访问此资料")
+	if profile == nil {
+		return nil, errors.New(404, "资料不存在", nil)
+	}
+	attachments, err := s.attachmentsOf(ctx, []model.Profile{*profile}, viewer)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.toProfileResponse(profile), nil
+	return s.toProfileResponse(profile, attachments), nil
 }
 
 func (s *ProfileService) GetByType(ctx context.Context, userID uint, profileType string) ([]dto.ProfileResponse, error) {
-	profiles, err := s.repo.GetByType(ctx, userID, profileType)
+	viewer := repository.ProfileViewer{UserID: userID}
+	profiles, err := s.repo.ListVisible(ctx, userID, profileType, viewer)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachmentsOf(ctx, profiles, viewer)
 	if err != nil {
 		return nil, err
 	}
 
 	var resp []dto.ProfileResponse
 	for _, p := range profiles {
-		resp = append(resp, *s.toProfileResponse(&p))
+		resp = append(resp, *s.toProfileResponse(&p, attachments))
 	}
 	return resp, nil
 }
 
 func (s *ProfileService) Update(ctx context.Context, userID, profileID uint, req *dto.UpdateProfileRequest) error {
-	profile, err := s.repo.GetByID(ctx, profileID)
+	profile, err := s.getOwned(ctx, userID, profileID)
 	if err != nil {
 		return err
 	}
 
-	if profile.UserID != userID {
-		return errors.New("无权修改此资料")
-	}
-
 	if req.Title != "" {
 		profile.Title = req.Title
 	}
 	if req.Year != nil {
 		profile.Year = req.Year
 	}
-	// ... 更新其他字段
+	if req.StartDate != nil {
+		profile.StartDate = req.StartDate
+	}
+	if req.EndDate != nil {
+		profile.EndDate = req.EndDate
+	}
+	if req.Organization != "" {
+		profile.Organization = req.Organization
+	}
+	if req.Location != "" {
+		profile.Location = req.Location
+	}
+	if req.URL != "" {
+		profile.URL = req.URL
+	}
+	if req.Description != "" {
+		profile.Description = req.Description
+	}
+	if len(req.Metadata) > 0 {
+		version, err := validateMetadata(profile.Type, req.Metadata)
+		if err != nil {
+			return err
+		}
+		profile.Metadata = req.Metadata
+		profile.SchemaVersion = version
+	}
+	if req.Visibility != "" {
+		setVisibility(profile, req.Visibility)
+	}
 
-	return s.repo.Update(ctx, profile)
+	if err := s.repo.Update(ctx, profile); err != nil {
+		return errors.Wrap(err, "更新资料失败")
+	}
+	return nil
+}
+
+// Patch 按 RFC 7396 JSON Merge Patch 修改资料项，可以清空字段；metadata 逐层合并而不是整体替换
+func (s *ProfileService) Patch(ctx context.Context, userID, profileID uint, patch json.RawMessage) (*dto.ProfileResponse, error) {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &keys); err != nil || keys == nil {
+		return nil, errors.New(400, "请求体必须是 JSON 对象", nil)
+	}
+
+	current, err := json.Marshal(toPatchDocument(profile))
+	if err != nil {
+		return nil, errors.Wrap(err, "更新资料失败")
+	}
+	merged, err := utils.MergePatch(current, patch)
+	if err != nil {
+		return nil, errors.New(400, "无效的请求参数", nil)
+	}
+	doc, err := decodePatchDocument(merged)
+	if err != nil {
+		return nil, err
+	}
+	// 只有补丁修改了 metadata 时才按最新 schema 校验，未修改的旧数据不影响其他字段的编辑
+	if _, ok := keys["metadata"]; ok {
+		version, err := validateMetadata(profile.Type, doc.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		profile.SchemaVersion = version
+	}
+
+	profile.Title = doc.Title
+	profile.Year = doc.Year
+	profile.StartDate = doc.StartDate
+	profile.EndDate = doc.EndDate
+	profile.Organization = doc.Organization
+	profile.Location = doc.Location
+	profile.URL = doc.URL
+	profile.Description = doc.Description
+	profile.Metadata = doc.Metadata
+	setVisibility(profile, doc.Visibility)
+
+	if err := s.repo.Update(ctx, profile); err != nil {
+		return nil, errors.Wrap(err, "更新资料失败")
+	}
+	attachments, err := s.attachmentsOf(ctx, []model.Profile{*profile}, repository.ProfileViewer{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	return s.toProfileResponse(profile, attachments), nil
+}
+
+// toPatchDocument 资料项当前可修改的字段，作为合并补丁的目标文档；空字段省略，清空与从未设置效果相同
+func toPatchDocument(p *model.Profile) map[string]interface{} {
+	doc := map[string]interface{}{
+		"title":      p.Title,
+		"visibility": p.Visibility,
+	}
+	optional := map[string]interface{}{
+		"organization": p.Organization,
+		"location":     p.Location,
+		"url":          p.URL,
+		"description":  p.Description,
+	}
+	for key, value := range optional {
+		if value != "" {
+			doc[key] = value
+		}
+	}
+	if p.Year != nil {
+		doc["year"] = *p.Year
+	}
+	if p.StartDate != nil {
+		doc["start_date"] = *p.StartDate
+	}
+	if p.EndDate != nil {
+		doc["end_date"] = *p.EndDate
+	}
+	if len(p.Metadata) > 0 && string(p.Metadata) != "null" {
+		doc["metadata"] = p.Metadata
+	}
+	return doc
+}
+
+// decodePatchDocument 解析合并后的文档并逐个字段校验，返回字段级错误
+func decodePatchDocument(merged json.RawMessage) (*dto.PatchProfileRequest, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(merged, &values); err != nil {
+		return nil, errors.New(400, "无效的请求参数", nil)
+	}
+
+	doc := &dto.PatchProfileRequest{}
+	targets := map[string]interface{}{
+		"title":        &doc.Title,
+		"year":         &doc.Year,
+		"start_date":   &doc.StartDate,
+		"end_date":     &doc.EndDate,
+		"organization": &doc.Organization,
+		"location":     &doc.Location,
+		"url":          &doc.URL,
+		"description":  &doc.Description,
+		"metadata":     &doc.Metadata,
+		"visibility":   &doc.Visibility,
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fields []errors.FieldError
+	for _, key := range keys {
+		target, ok := targets[key]
+		if !ok {
+			fields = append(fields, errors.FieldError{Field: key, Code: "unknown_field", Message: "该字段不存在或不能修改"})
+			continue
+		}
+		if err := json.Unmarshal(values[key], target); err != nil {
+			fields = append(fields, errors.FieldError{Field: key, Code: "invalid", Message: "字段类型或格式错误"})
+			continue
+		}
+		switch {
+		case key == "title" && strings.TrimSpace(doc.Title) == "":
+			fields = append(fields, errors.FieldError{Field: key, Code: "invalid", Message: "标题不能为空"})
+		case key == "metadata" && doc.Metadata[0] != '{':
+			fields = append(fields, errors.FieldError{Field: key, Code: "invalid", Message: "元数据必须是 JSON 对象"})
+		case key == "visibility" && !slices.Contains(profileVisibilities, doc.Visibility):
+			fields = append(fields, errors.FieldError{Field: key, Code: "oneof", Message: "可见性只能是 " + strings.Join(profileVisibilities, "、")})
+		}
+	}
+	if _, ok := values["title"]; !ok {
+		fields = append(fields, errors.FieldError{Field: "title", Code: "required", Message: "标题不能清空"})
+	}
+	// 可见性清空后不能回退为 public，否则会把私密资料项公开
+	if _, ok := values["visibility"]; !ok {
+		fields = append(fields, errors.FieldError{Field: "visibility", Code: "required", Message: "可见性不能清空"})
+	}
+	if len(fields) > 0 {
+		return nil, errors.Validation("无效的请求参数", fields)
+	}
+	return doc, nil
+}
+
+// validateMetadata 按资料类型最新版本的 schema 校验元数据，返回该版本号；字段路径以 metadata 开头
+func validateMetadata(profileType model.ProfileType, metadata json.RawMessage) (int, error) {
+	entry := schema.Latest(string(profileType))
+	if entry == nil {
+		return 0, nil
+	}
+	if len(metadata) == 0 || string(metadata) == "null" {
+		return entry.Version, nil
+	}
+	if fields := entry.Schema.Validate(metadata, "metadata"); len(fields) > 0 {
+		return 0, errors.Validation("元数据不符合该资料类型的要求", fields)
+	}
+	return entry.Version, nil
+}
+
+// ListSchemas 获取资料元数据的 schema：不指定类型时返回每种类型的最新版本；
+// 指定类型时返回该类型的所有版本，同时指定版本时只返回该版本
+func (s *ProfileService) ListSchemas(profileType string, version int) ([]dto.ProfileSchemaResponse, error) {
+	toResponse := func(entry *schema.Entry) dto.ProfileSchemaResponse {
+		return dto.ProfileSchemaResponse{
+			Type:    entry.Type,
+			Version: entry.Version,
+			Latest:  entry == schema.Latest(entry.Type),
+			Schema:  entry.Raw,
+		}
+	}
+
+	if profileType == "" {
+		resp := make([]dto.ProfileSchemaResponse, 0, len(model.ProfileTypes))
+		for _, t := range model.ProfileTypes {
+			if entry := schema.Latest(string(t)); entry != nil {
+				resp = append(resp, toResponse(entry))
+			}
+		}
+		return resp, nil
+	}
+
+	if version != 0 {
+		entry := schema.Get(profileType, version)
+		if entry == nil {
+			return nil, errors.New(404, "schema 不存在", nil)
+		}
+		return []dto.ProfileSchemaResponse{toResponse(entry)}, nil
+	}
+	entries := schema.Versions(profileType)
+	if len(entries) == 0 {
+		return nil, errors.New(404, "schema 不存在", nil)
+	}
+	resp := make([]dto.ProfileSchemaResponse, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, toResponse(entry))
+	}
+	return resp, nil
+}
+
+// profileVisibilities 资料项可见性的所有取值
+var profileVisibilities = []string{
+	model.VisibilityPublic, model.VisibilityPrivate, model.VisibilityUnlisted,
+	model.VisibilityOrganization, model.VisibilityAudience,
+}
+
+// setVisibility 修改资料项的可见性，不再是 unlisted 时分享链接随之失效
+func setVisibility(profile *model.Profile, visibility string) {
+	if visibility != model.VisibilityUnlisted {
+		profile.ShareTokenHash = ""
+		profile.ShareExpiresAt = nil
+	}
+	profile.Visibility = visibility
 }
 
 func (s *ProfileService) Delete(ctx context.Context, userID, profileID uint) error {
@@ -93,7 +375,7 @@ func (s *ProfileService) Delete(ctx context.Context, userID, profileID uint) err
 	}
 
 	if profile.UserID != userID {
-		return errors.New("无权删除此资料")
+		return stderrors.New("无权删除此资料")
 	}
 
 	return s.repo.Delete(ctx, profileID)
@@ -107,7 +389,7 @@ func (s *ProfileService) UpdateDisplayOrder(ctx context.Context, userID uint, re
 			return err
 		}
 		if profile.UserID != userID {
-			return errors.New("无权修改此资料")
+			return stderrors.New("无权修改此资料")
 		}
 	}
 
@@ -128,22 +410,232 @@ func (s *ProfileService) UpdateDisplayOrder(ctx context.Context, userID uint, re
 	return s.repo.UpdateDisplayOrder(ctx, items)
 }
 
-func (s *ProfileService) toProfileResponse(p *model.Profile) *dto.ProfileResponse {
+// GetPublicProfile 获取用户的主页，资料项按类型分组。viewerID 为访问者的用户 ID，未登录时为 0；
+// 本人访问时包含所有资料项，其他人只能看到状态正常的用户对其可见的资料项，unlisted 资料项不在主页上展示
+func (s *ProfileService) GetPublicProfile(ctx context.Context, username string, viewerID uint) (*dto.PublicProfileResponse, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询用户失败")
+	}
+	isOwner := user != nil && viewerID != 0 && user.ID == viewerID
+	if user == nil || (!isOwner && user.Status != model.UserStatusActive) {
+		return nil, errors.New(404, "用户不存在", nil)
+	}
+
+	viewer := repository.ProfileViewer{UserID: viewerID}
+	profiles, err := s.repo.ListVisible(ctx, user.ID, "", viewer)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取个人资料失败")
+	}
+	attachments, err := s.attachmentsOf(ctx, profiles, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	convert := func(p *model.Profile) *dto.ProfileResponse { return s.toProfileResponse(p, attachments) }
+	resp := &dto.PublicProfileResponse{
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Avatar:   user.Avatar,
+		Bio:      user.Bio,
+		Sections: groupProfiles(profiles, convert),
+		IsOwner:  isOwner,
+	}
+	// general 类型只展示排在最前的一项，不再作为分组返回
+	for i, section := range resp.Sections {
+		if section.Type == string(model.General) {
+			resp.General = &section.Items[0]
+			resp.Sections = append(resp.Sections[:i], resp.Sections[i+1:]...)
+			break
+		}
+	}
+	return resp, nil
+}
+
+// CreateShareLink 为 unlisted 资料项生成新的分享链接，旧链接随之失效；令牌只返回这一次，数据库只保存摘要
+func (s *ProfileService) CreateShareLink(ctx context.Context, userID, profileID uint, req *dto.CreateProfileShareRequest) (*dto.ProfileShareResponse, error) {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Visibility != model.VisibilityUnlisted {
+		return nil, errors.New(400, "只有不公开列出（unlisted）的资料项可以生成分享链接", nil)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.Validation("无效的请求参数", []errors.FieldError{
+			{Field: "expires_at", Code: "expired", Message: "过期时间必须晚于当前时间"},
+		})
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成分享令牌失败")
+	}
+	if err := s.repo.SetShareToken(ctx, profile.ID, utils.HashToken(token), req.ExpiresAt); err != nil {
+		return nil, errors.Wrap(err, "保存分享令牌失败")
+	}
+
+	return &dto.ProfileShareResponse{
+		Token:     token,
+		URL:       fmt.Sprintf("%s/s/%s", strings.TrimRight(config.GetConfig().App.URL, "/"), token),
+		ExpiresAt: req.ExpiresAt,
+	}, nil
+}
+
+// RevokeShareLink 撤销资料项的分享链接
+func (s *ProfileService) RevokeShareLink(ctx context.Context, userID, profileID uint) error {
+	profile, err := s.getOwned(ctx, userID, profileID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetShareToken(ctx, profile.ID, "", nil); err != nil {
+		return errors.Wrap(err, "撤销分享链接失败")
+	}
+	return nil
+}
+
+// GetShared 通过分享令牌获取资料项，令牌无效、已过期、资料项不再是 unlisted 或用户状态不正常时返回 404
+func (s *ProfileService) GetShared(ctx context.Context, token string) (*dto.SharedProfileResponse, error) {
+	profile, err := s.repo.GetShared(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, errors.Wrap(err, "获取资料失败")
+	}
+	if profile == nil {
+		return nil, errors.New(404, "分享链接无效或已过期", nil)
+	}
+	user, err := s.userRepo.GetByID(ctx, profile.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil || user.Status != model.UserStatusActive {
+		return nil, errors.New(404, "分享链接无效或已过期", nil)
+	}
+	// 附件下载链接绑定当前的分享令牌，分享链接撤销或重新生成后随之失效
+	attachments, err := s.attachmentsOf(ctx, []model.Profile{*profile}, repository.ProfileViewer{ShareTokenHash: profile.ShareTokenHash})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SharedProfileResponse{
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Avatar:   user.Avatar,
+		Item:     *s.toProfileResponse(profile, attachments),
+	}, nil
+}
+
+// GetAudience 获取资料项的可见用户名单
+func (s *ProfileService) GetAudience(ctx context.Context, userID, profileID uint) (*dto.ProfileAudienceResponse, error) {
+	if _, err := s.getOwned(ctx, userID, profileID); err != nil {
+		return nil, err
+	}
+	users, err := s.repo.GetAudience(ctx, profileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取可见用户失败")
+	}
+
+	resp := &dto.ProfileAudienceResponse{Usernames: make([]string, 0, len(users))}
+	for _, u := range users {
+		resp.Usernames = append(resp.Usernames, u.Username)
+	}
+	return resp, nil
+}
+
+// SetAudience 替换资料项的可见用户名单，名单只在可见性为 audience 时生效
+func (s *ProfileService) SetAudience(ctx context.Context, userID, profileID uint, req *dto.ProfileAudienceRequest) (*dto.ProfileAudienceResponse, error) {
+	if _, err := s.getOwned(ctx, userID, profileID); err != nil {
+		return nil, err
+	}
+
+	var userIDs []uint
+	var usernames []string
+	var fields []errors.FieldError
+	for i, username := range req.Usernames {
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			return nil, errors.Wrap(err, "查询用户失败")
+		}
+		if user == nil {
+			fields = append(fields, errors.FieldError{
+				Field: fmt.Sprintf("usernames[%d]", i), Code: "not_found", Message: "用户不存在：" + username,
+			})
+			continue
+		}
+		if user.ID == userID || slices.Contains(userIDs, user.ID) {
+			continue
+		}
+		userIDs = append(userIDs, user.ID)
+		usernames = append(usernames, user.Username)
+	}
+	if len(fields) > 0 {
+		return nil, errors.Validation("无效的请求参数", fields)
+	}
+
+	if err := s.repo.ReplaceAudience(ctx, profileID, userIDs); err != nil {
+		return nil, errors.Wrap(err, "保存可见用户失败")
+	}
+	if usernames == nil {
+		usernames = []string{}
+	}
+	return &dto.ProfileAudienceResponse{Usernames: usernames}, nil
+}
+
+// getOwned 获取当前用户自己的资料项，不存在或属于他人时都返回 404
+func (s *ProfileService) getOwned(ctx context.Context, userID, profileID uint) (*model.Profile, error) {
+	profile, err := s.repo.GetByID(ctx, profileID)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrap(err, "获取资料失败")
+	}
+	if err != nil || profile.UserID != userID {
+		return nil, errors.New(404, "资料不存在", nil)
+	}
+	return profile, nil
+}
+
+// groupProfiles 按 model.ProfileTypes 的顺序将资料项分组，未知类型排在最后；profiles 需已按显示顺序排列
+func groupProfiles(profiles []model.Profile, convert func(*model.Profile) *dto.ProfileResponse) []dto.ProfileSectionResponse {
+	byType := make(map[model.ProfileType][]dto.ProfileResponse)
+	var unknown []model.ProfileType
+	for i := range profiles {
+		t := profiles[i].Type
+		if _, ok := byType[t]; !ok && !slices.Contains(model.ProfileTypes, t) {
+			unknown = append(unknown, t)
+		}
+		byType[t] = append(byType[t], *convert(&profiles[i]))
+	}
+
+	sections := make([]dto.ProfileSectionResponse, 0, len(byType))
+	for _, t := range append(slices.Clone(model.ProfileTypes), unknown...) {
+		if items, ok := byType[t]; ok {
+			sections = append(sections, dto.ProfileSectionResponse{Type: string(t), Items: items})
+		}
+	}
+	return sections
+}
+
+// toProfileResponse attachments 为 attachmentsOf 的结果，没有附件的资料项返回空列表
+func (s *ProfileService) toProfileResponse(p *model.Profile, attachments map[uint][]dto.AttachmentResponse) *dto.ProfileResponse {
+	items := attachments[p.ID]
+	if items == nil {
+		items = []dto.AttachmentResponse{}
+	}
 	return &dto.ProfileResponse{
-		ID:           p.ID,
-		Type:         string(p.Type),
-		Title:        p.Title,
-		Year:         p.Year,
-		StartDate:    p.StartDate,
-		EndDate:      p.EndDate,
-		Organization: p.Organization,
-		Location:     p.Location,
-		URL:          p.URL,
-		Description:  p.Description,
-		Metadata:     json.RawMessage(p.Metadata),
-		DisplayOrder: p.DisplayOrder,
-		Visibility:   p.Visibility,
-		CreatedAt:    p.CreatedAt,
-		UpdatedAt:    p.UpdatedAt,
+		ID:            p.ID,
+		Type:          string(p.Type),
+		Title:         p.Title,
+		Year:          p.Year,
+		StartDate:     p.StartDate,
+		EndDate:       p.EndDate,
+		Organization:  p.Organization,
+		Location:      p.Location,
+		URL:           p.URL,
+		Description:   p.Description,
+		Metadata:      json.RawMessage(p.Metadata),
+		DisplayOrder:  p.DisplayOrder,
+		Visibility:    p.Visibility,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		SchemaVersion: p.SchemaVersion,
+		Attachments:   items,
 	}
 }
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/storage"
+	"ddup-apis/internal/utils"
+	"encoding/json"
+	stderrors "errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newTestProfileService 创建使用内存数据库和本地存储的 ProfileService。
+// organizations 表的 check 约束只适用于 PostgreSQL，这里只迁移成员表
+func newTestProfileService(t *testing.T) (*ProfileService, *gorm.DB) {
+	t.Helper()
+	config.SetConfig(testConfig())
+	db := newTestDB(t, &model.User{}, &model.Profile{}, &model.ProfileAudience{}, &model.OrganizationMember{},
+		&model.ProfileAttachment{}, &model.AttachmentUpload{})
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProfileService(db, store), db
+}
+
+// createTestUser 创建正常状态的用户
+func createTestUser(t *testing.T, db *gorm.DB, username string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Nickname: username, Password: "x", Status: model.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestProfile 创建指定可见性的资料项
+func createTestProfile(t *testing.T, db *gorm.DB, userID uint, visibility string) *model.Profile {
+	t.Helper()
+	profile := &model.Profile{UserID: userID, Type: model.Project, Title: "项目", Visibility: visibility}
+	if err := db.Create(profile).Error; err != nil {
+		t.Fatal(err)
+	}
+	return profile
+}
+
+func TestPatchVisibility(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestProfileService(t)
+	owner := createTestUser(t, db, "owner")
+
+	for _, visibility := range []string{model.VisibilityPrivate, model.VisibilityOrganization, model.VisibilityAudience} {
+		profile := createTestProfile(t, db, owner.ID, visibility)
+
+		// 清空可见性不能把资料项公开
+		for _, patch := range []string{`{"visibility":null}`, `{"visibility":""}`, `{"visibility":"everyone"}`} {
+			_, err := s.Patch(ctx, owner.ID, profile.ID, json.RawMessage(patch))
+			if !hasFieldError(err, "visibility") {
+				t.Errorf("%s 资料项 Patch(%s) error = %v, want visibility 字段错误", visibility, patch, err)
+			}
+		}
+		if _, err := s.Patch(ctx, owner.ID, profile.ID, json.RawMessage(`{"title":"新标题","location":null}`)); err != nil {
+			t.Fatalf("Patch() error = %v", err)
+		}
+
+		var saved model.Profile
+		if err := db.First(&saved, profile.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if saved.Visibility != visibility || saved.Title != "新标题" {
+			t.Errorf("Patch 后 visibility = %s, title = %s, want %s, 新标题", saved.Visibility, saved.Title, visibility)
+		}
+	}
+
+	// 从 unlisted 改为其他可见性时分享链接失效
+	profile := createTestProfile(t, db, owner.ID, model.VisibilityUnlisted)
+	if err := db.Model(profile).Update("share_token_hash", "hash").Error; err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Patch(ctx, owner.ID, profile.ID, json.RawMessage(`{"visibility":"private"}`))
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	var saved model.Profile
+	db.First(&saved, profile.ID)
+	if resp.Visibility != model.VisibilityPrivate || saved.ShareTokenHash != "" {
+		t.Errorf("改为 private 后 visibility = %s, share_token_hash = %q", resp.Visibility, saved.ShareTokenHash)
+	}
+
+	// 不能修改他人的资料项
+	other := createTestUser(t, db, "other")
+	if _, err := s.Patch(ctx, other.ID, profile.ID, json.RawMessage(`{"visibility":"public"}`)); err == nil {
+		t.Error("不应允许修改他人的资料项")
+	}
+}
+
+// hasFieldError 判断 err 是否为包含 field 字段错误的校验错误
+func hasFieldError(err error, field string) bool {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return false
+	}
+	for _, f := range appErr.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetPublicProfile(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestProfileService(t)
+	owner := createTestUser(t, db, "owner")
+	visitor := createTestUser(t, db, "visitor")
+
+	general := &model.Profile{UserID: owner.ID, Type: model.General, Title: "简介", Visibility: model.VisibilityPublic}
+	if err := db.Create(general).Error; err != nil {
+		t.Fatal(err)
+	}
+	public := createTestProfile(t, db, owner.ID, model.VisibilityPublic)
+	private := createTestProfile(t, db, owner.ID, model.VisibilityPrivate)
+	unlisted := createTestProfile(t, db, owner.ID, model.VisibilityUnlisted)
+
+	ids := func(resp *dto.PublicProfileResponse) []uint {
+		var ids []uint
+		for _, section := range resp.Sections {
+			for _, item := range section.Items {
+				ids = append(ids, item.ID)
+			}
+		}
+		return ids
+	}
+
+	// 访客和其他用户只能看到公开的资料项，unlisted 不在主页上展示
+	for _, viewerID := range []uint{0, visitor.ID} {
+		resp, err := s.GetPublicProfile(ctx, "owner", viewerID)
+		if err != nil {
+			t.Fatalf("GetPublicProfile() error = %v", err)
+		}
+		if got := ids(resp); resp.IsOwner || len(got) != 1 || got[0] != public.ID {
+			t.Errorf("访问者 %d 看到的资料项 = %v, want [%d]", viewerID, got, public.ID)
+		}
+		if resp.General == nil || resp.General.ID != general.ID || resp.Username != "owner" {
+			t.Errorf("主页 general = %+v, username = %s", resp.General, resp.Username)
+		}
+	}
+
+	// 本人可以看到全部资料项
+	resp, err := s.GetPublicProfile(ctx, "owner", owner.ID)
+	if err != nil {
+		t.Fatalf("GetPublicProfile() error = %v", err)
+	}
+	if got := ids(resp); !resp.IsOwner || len(got) != 3 {
+		t.Errorf("本人看到的资料项 = %v, want [%d %d %d]", got, public.ID, private.ID, unlisted.ID)
+	}
+
+	// 不存在或状态不正常的用户对其他人返回 404，本人仍可访问
+	if _, err := s.GetPublicProfile(ctx, "nobody", 0); errorCode(err) != 404 {
+		t.Errorf("用户不存在: error = %v, want 404", err)
+	}
+	db.Model(owner).Update("status", model.UserStatusSuspended)
+	if _, err := s.GetPublicProfile(ctx, "owner", visitor.ID); errorCode(err) != 404 {
+		t.Errorf("暂停使用的用户: error = %v, want 404", err)
+	}
+	if _, err := s.GetPublicProfile(ctx, "owner", owner.ID); err != nil {
+		t.Errorf("暂停使用的用户访问自己的主页: error = %v", err)
+	}
+}
+
+func TestProfileVisibility(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestProfileService(t)
+	owner := createTestUser(t, db, "owner")
+	colleague := createTestUser(t, db, "colleague")
+	former := createTestUser(t, db, "former")
+	friend := createTestUser(t, db, "friend")
+	stranger := createTestUser(t, db, "stranger")
+
+	// owner 和 colleague 同属组织 1；former 已离开组织 1
+	members := []*model.OrganizationMember{
+		{OrganizationID: 1, UserID: owner.ID},
+		{OrganizationID: 1, UserID: colleague.ID},
+		{OrganizationID: 1, UserID: former.ID},
+		{OrganizationID: 2, UserID: stranger.ID},
+	}
+	for _, m := range members {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Delete(members[2])
+
+	profiles := map[string]*model.Profile{}
+	for _, visibility := range []string{model.VisibilityPublic, model.VisibilityPrivate, model.VisibilityOrganization, model.VisibilityAudience, model.VisibilityUnlisted} {
+		profiles[visibility] = createTestProfile(t, db, owner.ID, visibility)
+	}
+	if _, err := s.SetAudience(ctx, owner.ID, profiles[model.VisibilityAudience].ID, &dto.ProfileAudienceRequest{Usernames: []string{"friend", "owner", "friend"}}); err != nil {
+		t.Fatal(err)
+	}
+	db.Model(profiles[model.VisibilityUnlisted]).Update("share_token_hash", "share-hash")
+
+	viewers := []struct {
+		name    string
+		viewer  repository.ProfileViewer
+		visible []string
+	}{
+		{"访客", repository.ProfileViewer{}, []string{model.VisibilityPublic}},
+		{"本人", repository.ProfileViewer{UserID: owner.ID}, []string{model.VisibilityPublic, model.VisibilityPrivate, model.VisibilityOrganization, model.VisibilityAudience, model.VisibilityUnlisted}},
+		{"同组织成员", repository.ProfileViewer{UserID: colleague.ID}, []string{model.VisibilityPublic, model.VisibilityOrganization}},
+		{"已离开组织的成员", repository.ProfileViewer{UserID: former.ID}, []string{model.VisibilityPublic}},
+		{"可见名单中的用户", repository.ProfileViewer{UserID: friend.ID}, []string{model.VisibilityPublic, model.VisibilityAudience}},
+		{"其他组织的用户", repository.ProfileViewer{UserID: stranger.ID}, []string{model.VisibilityPublic}},
+		{"持有分享令牌的访客", repository.ProfileViewer{ShareTokenHash: "share-hash"}, []string{model.VisibilityPublic, model.VisibilityUnlisted}},
+		{"错误的分享令牌", repository.ProfileViewer{ShareTokenHash: "other-hash"}, []string{model.VisibilityPublic}},
+	}
+	for _, tt := range viewers {
+		t.Run(tt.name, func(t *testing.T) {
+			for visibility, profile := range profiles {
+				want := slices.Contains(tt.visible, visibility)
+				got, err := s.repo.GetVisible(ctx, profile.ID, tt.viewer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (got != nil) != want {
+					t.Errorf("%s 资料项可见 = %v, want %v", visibility, got != nil, want)
+				}
+			}
+			list, err := s.repo.ListVisible(ctx, owner.ID, "", tt.viewer)
+			if err != nil || len(list) != len(tt.visible) {
+				t.Errorf("ListVisible() = %d 项, %v, want %d", len(list), err, len(tt.visible))
+			}
+		})
+	}
+
+	// 可见名单不存在的用户返回字段错误，名单只在可见性为 audience 时生效
+	if _, err := s.SetAudience(ctx, owner.ID, profiles[model.VisibilityAudience].ID, &dto.ProfileAudienceRequest{Usernames: []string{"friend", "nobody"}}); !hasFieldError(err, "usernames[1]") {
+		t.Errorf("SetAudience(不存在的用户) error = %v, want usernames[1] 字段错误", err)
+	}
+	audience, err := s.GetAudience(ctx, owner.ID, profiles[model.VisibilityAudience].ID)
+	if err != nil || len(audience.Usernames) != 1 || audience.Usernames[0] != "friend" {
+		t.Errorf("GetAudience() = %+v, %v, want [friend]", audience, err)
+	}
+	if _, err := s.SetAudience(ctx, owner.ID, profiles[model.VisibilityPrivate].ID, &dto.ProfileAudienceRequest{Usernames: []string{"friend"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.repo.GetVisible(ctx, profiles[model.VisibilityPrivate].ID, repository.ProfileViewer{UserID: friend.ID}); got != nil {
+		t.Error("private 资料项不应对可见名单中的用户可见")
+	}
+}
+
+func TestProfileShareLink(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestProfileService(t)
+	owner := createTestUser(t, db, "owner")
+	other := createTestUser(t, db, "other")
+	profile := createTestProfile(t, db, owner.ID, model.VisibilityUnlisted)
+
+	// 只有 unlisted 资料项可以生成分享链接，且只能由本人生成
+	public := createTestProfile(t, db, owner.ID, model.VisibilityPublic)
+	if _, err := s.CreateShareLink(ctx, owner.ID, public.ID, &dto.CreateProfileShareRequest{}); errorCode(err) != 400 {
+		t.Errorf("public 资料项生成分享链接: error = %v, want 400", err)
+	}
+	if _, err := s.CreateShareLink(ctx, other.ID, profile.ID, &dto.CreateProfileShareRequest{}); errorCode(err) != 404 {
+		t.Errorf("为他人的资料项生成分享链接: error = %v, want 404", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if _, err := s.CreateShareLink(ctx, owner.ID, profile.ID, &dto.CreateProfileShareRequest{ExpiresAt: &past}); !hasFieldError(err, "expires_at") {
+		t.Errorf("过期时间早于当前时间: error = %v, want expires_at 字段错误", err)
+	}
+
+	first, err := s.CreateShareLink(ctx, owner.ID, profile.ID, &dto.CreateProfileShareRequest{})
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+	shared, err := s.GetShared(ctx, first.Token)
+	if err != nil || shared.Item.ID != profile.ID || shared.Username != "owner" {
+		t.Fatalf("GetShared() = %+v, %v", shared, err)
+	}
+	var saved model.Profile
+	db.First(&saved, profile.ID)
+	if saved.ShareTokenHash != utils.HashToken(first.Token) {
+		t.Error("数据库应只保存分享令牌摘要")
+	}
+
+	// 重新生成后旧链接失效
+	future := time.Now().Add(time.Hour)
+	second, err := s.CreateShareLink(ctx, owner.ID, profile.ID, &dto.CreateProfileShareRequest{ExpiresAt: &future})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetShared(ctx, first.Token); errorCode(err) != 404 {
+		t.Errorf("旧分享链接: error = %v, want 404", err)
+	}
+	if _, err := s.GetShared(ctx, second.Token); err != nil {
+		t.Errorf("新分享链接: error = %v", err)
+	}
+
+	// 用户状态不正常时链接不可用
+	db.Model(owner).Update("status", model.UserStatusSuspended)
+	if _, err := s.GetShared(ctx, second.Token); errorCode(err) != 404 {
+		t.Errorf("暂停使用的用户的分享链接: error = %v, want 404", err)
+	}
+	db.Model(owner).Update("status", model.UserStatusActive)
+
+	// 过期或撤销后链接失效
+	db.Model(profile).Update("share_expires_at", past)
+	if _, err := s.GetShared(ctx, second.Token); errorCode(err) != 404 {
+		t.Errorf("过期的分享链接: error = %v, want 404", err)
+	}
+	third, err := s.CreateShareLink(ctx, owner.ID, profile.ID, &dto.CreateProfileShareRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeShareLink(ctx, owner.ID, profile.ID); err != nil {
+		t.Fatalf("RevokeShareLink() error = %v", err)
+	}
+	if _, err := s.GetShared(ctx, third.Token); errorCode(err) != 404 {
+		t.Errorf("撤销的分享链接: error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PeriodicSessionPurge 定期删除 refresh token 已过期的会话，以及失效超过 retention 的会话
+func PeriodicSessionPurge(db *gorm.DB, interval, retention time.Duration) {
+	sessionRepo := repository.NewSessionRepository(db)
+	purge := func() {
+		purged, err := sessionRepo.PurgeSessions(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			logger.Error("清理过期会话失败", zap.Error(err))
+			return
+		}
+		if purged > 0 {
+			logger.Info("已清理过期会话", zap.Int64("count", purged))
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		purge()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
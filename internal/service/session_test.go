@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"testing"
+	"time"
+)
+
+// sessionIDOf 返回 access token 对应的会话 ID，令牌无效时测试失败
+func sessionIDOf(t *testing.T, s *UserService, token string) uint {
+	t.Helper()
+	result, err := s.ValidateToken(token)
+	if err != nil || !result.Valid {
+		t.Fatalf("ValidateToken() = %+v, %v", result, err)
+	}
+	return result.SessionID
+}
+
+func TestListAndRevokeSessions(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	const macChrome = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	current, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{UserAgent: macChrome, ClientIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{DeviceName: "我的手机", ClientIP: "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{ClientIP: "10.0.0.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := s.GenerateToken(ctx, bob.ID, bob.Username, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentID := sessionIDOf(t, s, current.Token)
+	phoneID := sessionIDOf(t, s, phone.Token)
+
+	sessions, err := s.ListSessions(ctx, alice.ID, currentID)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("ListSessions() = %v, %v", sessions, err)
+	}
+	for _, session := range sessions {
+		switch session.ID {
+		case currentID:
+			if !session.Current || session.UserAgent != macChrome || session.ClientIP != "10.0.0.1" || session.DeviceLabel == "" {
+				t.Errorf("当前会话 = %+v", session)
+			}
+		case phoneID:
+			if session.Current || session.DeviceLabel != "我的手机" {
+				t.Errorf("手机会话 = %+v", session)
+			}
+		}
+	}
+
+	// 记录最后活跃时间，间隔内不重复更新
+	if err := db.Model(&model.Session{}).Where("id = ?", phoneID).Update("last_seen_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TouchSession(ctx, phoneID, "10.0.0.9"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TouchSession(ctx, phoneID, "10.0.0.10"); err != nil {
+		t.Fatal(err)
+	}
+	var touched model.Session
+	db.First(&touched, phoneID)
+	if touched.LastSeenAt == nil || time.Since(*touched.LastSeenAt) > time.Minute || touched.ClientIP != "10.0.0.9" {
+		t.Errorf("TouchSession 后 last_seen_at = %v, client_ip = %s", touched.LastSeenAt, touched.ClientIP)
+	}
+
+	// 只能注销自己的会话，注销后 access token 和 refresh token 都失效
+	if err := s.RevokeSession(ctx, alice.ID, sessionIDOf(t, s, others.Token)); errorCode(err) != 404 {
+		t.Errorf("注销其他用户的会话: error = %v, want 404", err)
+	}
+	if err := s.RevokeSession(ctx, alice.ID, phoneID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if result, _ := s.ValidateToken(phone.Token); result.Valid {
+		t.Error("注销后 access token 应失效")
+	}
+	if _, err := s.RefreshToken(ctx, phone.RefreshToken, &dto.ClientInfo{}); errorCode(err) != 401 {
+		t.Errorf("注销后刷新: error = %v, want 401", err)
+	}
+
+	// 注销当前会话以外的所有会话，其他用户不受影响
+	if err := s.RevokeOtherSessions(ctx, alice.ID, currentID); err != nil {
+		t.Fatalf("RevokeOtherSessions() error = %v", err)
+	}
+	if result, _ := s.ValidateToken(laptop.Token); result.Valid {
+		t.Error("其他会话应失效")
+	}
+	sessions, err = s.ListSessions(ctx, alice.ID, currentID)
+	if err != nil || len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("注销其他会话后 ListSessions() = %v, %v", sessions, err)
+	}
+	if result, _ := s.ValidateToken(others.Token); !result.Valid {
+		t.Error("其他用户的会话不应受影响")
+	}
+
+	var revokes int64
+	db.Model(&model.AuditEvent{}).Where("action = ? AND user_id = ?", model.AuditSessionRevoke, alice.ID).Count(&revokes)
+	if revokes != 2 {
+		t.Errorf("会话注销审计事件数 = %d, want 2", revokes)
+	}
+}
+
+func TestSessionTokensHashedAndPurged(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	alice := createTestUser(t, db, "alice")
+
+	issue := func() (*dto.TokenResponse, uint) {
+		t.Helper()
+		resp, err := s.GenerateToken(ctx, alice.ID, alice.Username, &dto.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, sessionIDOf(t, s, resp.Token)
+	}
+
+	// 数据库只保存令牌摘要
+	active, activeID := issue()
+	var stored model.Session
+	db.First(&stored, activeID)
+	if stored.TokenHash != utils.HashToken(active.Token) || stored.RefreshToken != utils.HashToken(active.RefreshToken) {
+		t.Errorf("token_hash = %s, refresh_token = %s, 应保存令牌的 SHA-256 摘要", stored.TokenHash, stored.RefreshToken)
+	}
+	var plain int64
+	db.Model(&model.Session{}).Where("token_hash = ? OR refresh_token = ?", active.Token, active.RefreshToken).Count(&plain)
+	if plain != 0 {
+		t.Error("数据库不应保存明文令牌")
+	}
+	if _, err := s.sessionRepo.GetSessionByTokenHash(ctx, utils.HashToken(active.Token)); err != nil {
+		t.Errorf("按摘要查找会话失败: %v", err)
+	}
+
+	_, expiredID := issue()
+	_, staleID := issue()
+	_, recentID := issue()
+	db.Model(&model.Session{}).Where("id = ?", expiredID).UpdateColumn("refresh_expired_at", time.Now().Add(-time.Minute))
+	db.Model(&model.Session{}).Where("id = ?", staleID).UpdateColumns(map[string]interface{}{
+		"is_valid": false, "updated_at": time.Now().Add(-48 * time.Hour),
+	})
+	db.Model(&model.Session{}).Where("id = ?", recentID).UpdateColumn("is_valid", false)
+
+	// refresh token 过期和失效超过保留期的会话被删除，刚失效的会话保留用于重用检测
+	purged, err := s.sessionRepo.PurgeSessions(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil || purged != 2 {
+		t.Fatalf("PurgeSessions() = %d, %v, want 2", purged, err)
+	}
+	for id, want := range map[uint]bool{activeID: true, expiredID: false, staleID: false, recentID: true} {
+		var count int64
+		db.Unscoped().Model(&model.Session{}).Where("id = ?", id).Count(&count)
+		if (count == 1) != want {
+			t.Errorf("会话 %d 保留 = %v, want %v", id, count == 1, want)
+		}
+	}
+}
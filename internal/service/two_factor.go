@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/utils"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+const (
+	twoFactorIssuer       = "DDUP"
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorPurpose      = "2fa"
+	recoveryCodeCount     = 10
+)
+
+// twoFactorChallengeClaims 密码验证通过后签发的两步验证挑战令牌，不能作为访问令牌使用
+type twoFactorChallengeClaims struct {
+	UserID  uint   `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// newTwoFactorChallenge 签发两步验证挑战令牌
+func (s *UserService) newTwoFactorChallenge(user *model.User) (*dto.LoginResponse, error) {
+	expiredAt := time.Now().Add(twoFactorChallengeTTL)
+	claims := &twoFactorChallengeClaims{
+		UserID:  user.ID,
+		Purpose: twoFactorPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiredAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token, err := utils.SignToken(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成验证令牌失败")
+	}
+
+	return &dto.LoginResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     token,
+		ChallengeExpiredAt: &expiredAt,
+	}, nil
+}
+
+// parseTwoFactorChallenge 校验挑战令牌并返回其中的用户ID
+func parseTwoFactorChallenge(token string) (uint, error) {
+	claims := &twoFactorChallengeClaims{}
+	err := utils.ParseTokenWithClaims(token, claims)
+	if err != nil || claims.Purpose != twoFactorPurpose {
+		return 0, errors.New(401, "验证已过期，请重新登录", err)
+	}
+	return claims.UserID, nil
+}
+
+// LoginTwoFactor 登录第二步：校验 TOTP 验证码或恢复码后签发会话
+func (s *UserService) LoginTwoFactor(ctx context.Context, req *dto.LoginTwoFactorRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	userID, err := parseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil || !user.TwoFactorEnabled() {
+		return nil, errors.New(401, "验证已过期，请重新登录", err)
+	}
+	if err := checkLocked(user); err != nil {
+		s.loginFailed(ctx, user, client, "2fa", "locked")
+		return nil, err
+	}
+	if !canLoginOrRestore(user) {
+		s.loginFailed(ctx, user, client, "2fa", "inactive")
+		return nil, errUserInactive(user.Status)
+	}
+
+	ok, err := s.verifyTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.loginFailed(ctx, user, client, "2fa", "invalid_code")
+		// 验证码错误同样计入登录失败次数，防止暴力破解
+		if err := s.recordLoginFailure(ctx, user, client); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(401, "验证码错误", nil)
+	}
+
+	return s.completeLogin(ctx, user, client, "2fa")
+}
+
+// verifyTwoFactorCode 校验 6 位 TOTP 验证码，其他格式按恢复码处理
+func (s *UserService) verifyTwoFactorCode(ctx context.Context, user *model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		step, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, time.Now())
+		if !ok || step <= user.TwoFactorLastStep {
+			return false, nil
+		}
+		// 并发请求使用同一验证码时只有一个能更新成功，其余视为重放
+		advanced, err := s.userRepo.AdvanceTwoFactorStep(ctx, user.ID, step)
+		if err != nil {
+			return false, errors.Wrap(err, "更新验证状态失败")
+		}
+		return advanced, nil
+	}
+
+	used, err := s.recoveryCodeRepo.UseCode(ctx, user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, errors.Wrap(err, "校验恢复码失败")
+	}
+	if used {
+		logger.Info("用户使用恢复码登录", zap.Uint("user_id", user.ID))
+	}
+	return used, nil
+}
+
+// SetupTwoFactor 生成新的 TOTP 密钥，需调用 ConfirmTwoFactor 确认后才会开启
+func (s *UserService) SetupTwoFactor(ctx context.Context, id uint) (*dto.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return nil, errors.New(404, "用户不存在", err)
+	}
+	if user.TwoFactorEnabled() {
+		return nil, errors.New(400, "已开启两步验证", nil)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成密钥失败")
+	}
+	if err := s.userRepo.Update(ctx, id, map[string]interface{}{"two_factor_secret": secret}); err != nil {
+		return nil, errors.Wrap(err, "保存密钥失败")
+	}
+
+	uri := utils.TOTPURI(secret, twoFactorIssuer, user.Username)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成二维码失败")
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmTwoFactor 用认证器生成的验证码确认绑定，开启两步验证并返回恢复码（只显示这一次）
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, id uint, req *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return nil, errors.New(404, "用户不存在", err)
+	}
+	if user.TwoFactorEnabled() {
+		return nil, errors.New(400, "已开启两步验证", nil)
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New(400, "请先获取两步验证密钥", nil)
+	}
+
+	step, ok := utils.ValidateTOTP(user.TwoFactorSecret, req.Code, time.Now())
+	if !ok {
+		return nil, errors.New(400, "验证码错误", nil)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成恢复码失败")
+	}
+	if err := s.recoveryCodeRepo.ReplaceUserCodes(ctx, id, hashes); err != nil {
+		return nil, errors.Wrap(err, "保存恢复码失败")
+	}
+
+	if err := s.userRepo.Update(ctx, id, map[string]interface{}{
+		"two_factor_enabled_at": time.Now(),
+		"two_factor_last_step":  step,
+	}); err != nil {
+		return nil, errors.Wrap(err, "开启两步验证失败")
+	}
+
+	s.audit(ctx, model.AuditTwoFactorEnable, id, nil)
+	logger.Info("用户已开启两步验证", zap.Uint("user_id", id))
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor 验证密码后关闭两步验证并删除恢复码，未设置密码的账号改为验证验证码
+func (s *UserService) DisableTwoFactor(ctx context.Context, id uint, req *dto.TwoFactorDisableRequest) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return errors.New(404, "用户不存在", err)
+	}
+	if !user.TwoFactorEnabled() {
+		return errors.New(400, "未开启两步验证", nil)
+	}
+	if user.Password != "" {
+		if ok, _ := utils.VerifyPassword(req.Password, user.Password); !ok {
+			return errors.New(400, "密码错误", nil)
+		}
+	} else {
+		ok, err := s.verifyTwoFactorCode(ctx, user, req.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New(400, "验证码错误", nil)
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, id, map[string]interface{}{
+		"two_factor_secret":     "",
+		"two_factor_enabled_at": nil,
+		"two_factor_last_step":  0,
+	}); err != nil {
+		return errors.Wrap(err, "关闭两步验证失败")
+	}
+	if err := s.recoveryCodeRepo.DeleteUserCodes(ctx, id); err != nil {
+		return errors.Wrap(err, "删除恢复码失败")
+	}
+
+	s.audit(ctx, model.AuditTwoFactorDisable, id, nil)
+	logger.Info("用户已关闭两步验证", zap.Uint("user_id", id))
+	return nil
+}
+
+// generateRecoveryCodes 生成一组 xxxxx-xxxxx 格式的恢复码及其摘要
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.GenerateRandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 忽略用户输入恢复码时的大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
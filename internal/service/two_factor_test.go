@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/repository"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// totpCodeAt 计算时间步 step 的 6 位验证码
+func totpCodeAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestVerifyTwoFactorCodeReplay(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.User{})
+	userRepo := repository.NewUserRepository(db)
+	s := &UserService{userRepo: userRepo}
+
+	user := &model.User{Username: "alice", Password: "x", Status: model.UserStatusActive, TwoFactorSecret: testTOTPSecret}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	reload := func() *model.User {
+		u, err := userRepo.GetByID(ctx, user.ID)
+		if err != nil || u == nil {
+			t.Fatalf("查询用户失败: %v", err)
+		}
+		return u
+	}
+
+	// 避开时间步的边界，否则上一个时间步的验证码可能在测试过程中超出允许的偏差
+	if time.Now().Unix()%30 >= 28 {
+		time.Sleep(3 * time.Second)
+	}
+	step := time.Now().Unix() / 30
+	previous := totpCodeAt(t, testTOTPSecret, step-1)
+	current := totpCodeAt(t, testTOTPSecret, step)
+
+	if ok, err := s.verifyTwoFactorCode(ctx, reload(), previous); err != nil || !ok {
+		t.Fatalf("上一个时间步的验证码应通过: %v, %v", ok, err)
+	}
+	if got := reload().TwoFactorLastStep; got != step-1 {
+		t.Errorf("TwoFactorLastStep = %d, want %d", got, step-1)
+	}
+	if ok, _ := s.verifyTwoFactorCode(ctx, reload(), previous); ok {
+		t.Error("同一验证码不能使用两次")
+	}
+
+	// 模拟并发：两个请求读到相同的用户状态后提交同一验证码，只有一个能通过
+	stale := reload()
+	if ok, err := s.verifyTwoFactorCode(ctx, reload(), current); err != nil || !ok {
+		t.Fatalf("当前时间步的验证码应通过: %v, %v", ok, err)
+	}
+	if ok, err := s.verifyTwoFactorCode(ctx, stale, current); err != nil || ok {
+		t.Errorf("并发提交的同一验证码不应通过: %v, %v", ok, err)
+	}
+	if ok, _ := s.verifyTwoFactorCode(ctx, reload(), current); ok {
+		t.Error("同一验证码不能使用两次")
+	}
+	// 已使用较新的时间步后，较早时间步的验证码同样失效
+	if ok, _ := s.verifyTwoFactorCode(ctx, reload(), previous); ok {
+		t.Error("较早时间步的验证码不应通过")
+	}
+	if got := reload().TwoFactorLastStep; got != step {
+		t.Errorf("TwoFactorLastStep = %d, want %d", got, step)
+	}
+}
@@ -3,40 +3,125 @@ package service
 import (
 	"context"
 	"ddup-apis/internal/config"
-	"ddup-apis/internal/db"
 	"ddup-apis/internal/dto"
 	"ddup-apis/internal/errors"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/mail"
 	"ddup-apis/internal/model"
+	"ddup-apis/internal/notify"
+	"ddup-apis/internal/oauth"
 	"ddup-apis/internal/repository"
 	"ddup-apis/internal/utils"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type IUserService interface {
 	Register(ctx context.Context, req *dto.RegisterRequest) error
-	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
+	Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
 	GetUserByID(ctx context.Context, id uint) (*dto.UserResponse, error)
 	UpdateUser(ctx context.Context, id uint, req *dto.UpdateUserRequest) error
-	DeleteUser(ctx context.Context, id uint) error
+	DeleteUser(ctx context.Context, id uint) (*dto.AccountDeletionResponse, error)
 	ChangePassword(ctx context.Context, id uint, req *dto.ChangePasswordRequest) error
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error
+	SendEmailVerification(ctx context.Context, id uint) error
+	VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error
+	LoginTwoFactor(ctx context.Context, req *dto.LoginTwoFactorRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
+	SetupTwoFactor(ctx context.Context, id uint) (*dto.TwoFactorSetupResponse, error)
+	ConfirmTwoFactor(ctx context.Context, id uint, req *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, id uint, req *dto.TwoFactorDisableRequest) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
 	ValidateToken(token string) (*TokenValidationResult, error)
+	ValidateAccessToken(ctx context.Context, token string, clientIP string) (*TokenValidationResult, error)
+	CreateAccessToken(ctx context.Context, userID uint, req *dto.CreateAccessTokenRequest) (*dto.CreateAccessTokenResponse, error)
+	ListAccessTokens(ctx context.Context, userID uint) ([]dto.AccessTokenResponse, error)
+	DeleteAccessToken(ctx context.Context, userID uint, id uint) error
+	ListOAuthProviders() []dto.OAuthProviderResponse
+	OAuthAuthorize(ctx context.Context, providerName, purpose string, userID uint) (*dto.OAuthAuthorizeResponse, error)
+	OAuthLogin(ctx context.Context, providerName string, req *dto.OAuthCallbackRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
+	LinkIdentity(ctx context.Context, userID uint, providerName string, req *dto.OAuthCallbackRequest) (*dto.IdentityResponse, error)
+	ListIdentities(ctx context.Context, userID uint) ([]dto.IdentityResponse, error)
+	UnlinkIdentity(ctx context.Context, userID uint, id uint) error
+	SendMagicLink(ctx context.Context, req *dto.MagicLinkRequest, client *dto.ClientInfo) error
+	LoginWithMagicLink(ctx context.Context, req *dto.MagicLinkLoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
+	DisablePassword(ctx context.Context, id uint, req *dto.DisablePasswordRequest) error
+	ListLoginHistory(ctx context.Context, userID uint, req *dto.PageRequest) (*dto.LoginHistoryListResponse, error)
+	ListSecurityLog(ctx context.Context, userID uint, req *dto.PageRequest) (*dto.AuditEventListResponse, error)
+	ListAuditEvents(ctx context.Context, req *dto.AuditEventQueryRequest) (*dto.AuditEventListResponse, error)
+	OIDCAuthorize(ctx context.Context, userID uint, req *dto.OIDCAuthorizeRequest) (*dto.OIDCAuthorizeResponse, error)
+	OIDCApprove(ctx context.Context, userID, sessionID uint, req *dto.OIDCApproveRequest) (*dto.OIDCRedirectResponse, error)
+	OIDCToken(ctx context.Context, req *dto.OIDCTokenRequest, clientInfo *dto.ClientInfo) (*dto.OIDCTokenResponse, error)
+	OIDCUserInfo(ctx context.Context, token string) (map[string]interface{}, error)
+	ListOIDCConsents(ctx context.Context, userID uint) ([]dto.OIDCConsentResponse, error)
+	RevokeOIDCConsent(ctx context.Context, userID uint, clientID string) error
+	CreateOIDCClient(ctx context.Context, createdBy uint, req *dto.CreateOIDCClientRequest) (*dto.CreateOIDCClientResponse, error)
+	ListOIDCClients(ctx context.Context) ([]dto.OIDCClientResponse, error)
+	DeleteOIDCClient(ctx context.Context, clientID string) error
 	Logout(ctx context.Context, token string) error
 	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
-	GenerateToken(ctx context.Context, userID uint, username string) (string, time.Time, int64, time.Time, error)
+	GenerateToken(ctx context.Context, userID uint, username string, client *dto.ClientInfo) (*dto.TokenResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string, client *dto.ClientInfo) (*dto.TokenResponse, error)
+	ListSessions(ctx context.Context, userID uint, currentSessionID uint) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID uint) error
+	RevokeOtherSessions(ctx context.Context, userID uint, currentSessionID uint) error
+	TouchSession(ctx context.Context, sessionID uint, clientIP string) error
+	UnlockUser(ctx context.Context, username string) error
+	ChangeUserStatus(ctx context.Context, username string, req *dto.UpdateUserStatusRequest) error
+	ListUsers(ctx context.Context, req *dto.AdminUserQueryRequest) (*dto.AdminUserListResponse, error)
+	GetUserDetail(ctx context.Context, username string) (*dto.AdminUserResponse, error)
+	ChangeUserRole(ctx context.Context, username string, req *dto.UpdateUserRoleRequest) error
+	ForceLogout(ctx context.Context, username string) error
+	ResetTwoFactor(ctx context.Context, username string) error
+	Impersonate(ctx context.Context, adminID uint, username string, req *dto.ImpersonateRequest, client *dto.ClientInfo) (*dto.ImpersonationResponse, error)
+	RecordImpersonatedRequest(ctx context.Context, userID uint, method, path string, status int)
 }
 
 type UserService struct {
-	userRepo    IUserRepository
-	sessionRepo ISessionRepository
+	userRepo              IUserRepository
+	sessionRepo           ISessionRepository
+	passwordResetRepo     repository.IPasswordResetRepository
+	magicLinkRepo         repository.IMagicLinkRepository
+	emailVerificationRepo repository.IEmailVerificationRepository
+	recoveryCodeRepo      repository.IRecoveryCodeRepository
+	accessTokenRepo       repository.IAccessTokenRepository
+	identityRepo          repository.IUserIdentityRepository
+	oidcRepo              repository.IOIDCRepository
+	profileRepo           *repository.ProfileRepository
+	auditRepo             repository.IAuditRepository
+	loginHistoryRepo      repository.ILoginHistoryRepository
+	mailer                mail.Mailer
+	notifier              notify.Notifier
+	oauthProviders        map[string]oauth.Provider
+	magicLinkEmailLimiter *utils.RateLimiter
+	magicLinkIPLimiter    *utils.RateLimiter
 }
 
-func NewUserService(db *gorm.DB) *UserService {
+func NewUserService(db *gorm.DB, mailer mail.Mailer, notifier notify.Notifier, oauthProviders map[string]oauth.Provider) *UserService {
+	cfg := config.GetConfig()
 	return &UserService{
-		userRepo:    repository.NewUserRepository(db),
-		sessionRepo: repository.NewSessionRepository(db),
+		userRepo:              repository.NewUserRepository(db),
+		sessionRepo:           repository.NewSessionRepository(db),
+		passwordResetRepo:     repository.NewPasswordResetRepository(db),
+		magicLinkRepo:         repository.NewMagicLinkRepository(db),
+		emailVerificationRepo: repository.NewEmailVerificationRepository(db),
+		recoveryCodeRepo:      repository.NewRecoveryCodeRepository(db),
+		accessTokenRepo:       repository.NewAccessTokenRepository(db),
+		identityRepo:          repository.NewUserIdentityRepository(db),
+		oidcRepo:              repository.NewOIDCRepository(db),
+		profileRepo:           repository.NewProfileRepository(db),
+		auditRepo:             repository.NewAuditRepository(db),
+		loginHistoryRepo:      repository.NewLoginHistoryRepository(db),
+		mailer:                mailer,
+		notifier:              notifier,
+		oauthProviders:        oauthProviders,
+		magicLinkEmailLimiter: utils.NewRateLimiter(cfg.MagicLink.EmailLimit, cfg.MagicLink.RateWindow),
+		magicLinkIPLimiter:    utils.NewRateLimiter(cfg.MagicLink.IPLimit, cfg.MagicLink.RateWindow),
 	}
 }
 
@@ -45,32 +130,60 @@ type IUserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, id uint, updates map[string]interface{}) error
 	UpdatePassword(ctx context.Context, id uint, hashedPassword string) error
 	UpdateLastLogin(ctx context.Context, id uint) error
-	Delete(ctx context.Context, id uint) error
+	IncrementLoginAttempts(ctx context.Context, id uint) (int, error)
+	AdvanceTwoFactorStep(ctx context.Context, id uint, step int64) (bool, error)
+	Purge(ctx context.Context, id uint) error
+	List(ctx context.Context, filter *repository.UserFilter) ([]model.User, int64, error)
+	CountByRole(ctx context.Context, role model.UserRole) (int64, error)
+	ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]model.User, error)
+	ScheduleLegacyDeletions(ctx context.Context, at time.Time) (int64, error)
 }
 
 type ISessionRepository interface {
 	CreateSession(ctx context.Context, session *model.Session) error
-	GetSessionByToken(ctx context.Context, token string) (*model.Session, error)
-	InvalidateSession(ctx context.Context, token string) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	GetSessionByID(ctx context.Context, id uint) (*model.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*model.Session, error)
+	GetUserSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	RotateSession(ctx context.Context, id uint) (bool, error)
+	TouchSession(ctx context.Context, id uint, clientIP string, interval time.Duration) error
+	InvalidateSession(ctx context.Context, tokenHash string) error
+	InvalidateSessionFamily(ctx context.Context, familyID string) error
 	InvalidateUserSessions(ctx context.Context, userID uint) error
+	InvalidateUserSessionsExcept(ctx context.Context, userID uint, keepID uint) error
+	InvalidateExcessSessions(ctx context.Context, userID uint, clientID string, keep int) error
+	InvalidateClientSessions(ctx context.Context, clientID string, userID uint) error
+	PurgeSessions(ctx context.Context, invalidBefore time.Time) (int64, error)
 }
 
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Scope    string    `json:"scope,omitempty"` // 授权给 OIDC 应用的 scope
+	Act      *ActClaim `json:"act,omitempty"`   // 管理员代登录时为管理员的身份
 	jwt.RegisteredClaims
 }
 
 // 2. 将 TokenValidationResult 移到更合适的位置（比如 dto 包）
 type TokenValidationResult struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Valid    bool   `json:"valid"`
+	UserID         uint               `json:"user_id"`
+	Username       string             `json:"username"`
+	SessionID      uint               `json:"session_id"`      // 使用个人访问令牌时为 0
+	AccessTokenID  uint               `json:"access_token_id"` // 使用 JWT 时为 0
+	Scopes         []model.TokenScope `json:"scopes"`          // 个人访问令牌的权限范围
+	Status         model.UserStatus   `json:"status"`
+	Role           model.UserRole     `json:"role"`
+	ImpersonatorID uint               `json:"impersonator_id"` // 管理员代登录时为管理员的用户 ID
+	Valid          bool               `json:"valid"`
 }
 
+// sessionTouchInterval 会话最后活跃时间的最小更新间隔
+const sessionTouchInterval = time.Minute
+
 // 3. 统一错误处理
 func (s *UserService) Register(ctx context.Context, req *dto.RegisterRequest) error {
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
@@ -81,36 +194,142 @@ func (s *UserService) Register(ctx context.Context, req *dto.RegisterRequest) er
 		return errors.New(400, "用户名已存在", nil)
 	}
 
+	email := normalizeEmail(req.Email)
+	if err := s.checkEmailAvailable(ctx, email, 0); err != nil {
+		return err
+	}
+
+	if err := checkPasswordPolicy("password", req.Password, req.Username); err != nil {
+		return err
+	}
+
 	// 密码加密
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return errors.Wrap(err, "密码加密失败")
 	}
 
-	// 创建用户
+	// 创建用户，验证邮箱前账号处于待验证状态
 	user = &model.User{
 		Username: req.Username,
 		Password: hashedPassword,
 		Nickname: req.Username,
+		Email:    &email,
+		Status:   model.UserStatusPending,
 	}
 
-	return s.userRepo.Create(ctx, user)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return errors.Wrap(err, "创建用户失败")
+	}
+
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		logger.Warn("发送验证邮件失败", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+	return nil
 }
 
-func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
-	// 获取用户
-	user, err := s.userRepo.GetByUsername(ctx, req.Username)
-	if err != nil {
+func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	// 获取用户，支持用户名或已验证的邮箱登录
+	var user *model.User
+	var err error
+	if strings.Contains(req.Username, "@") {
+		user, err = s.userRepo.GetByEmail(ctx, normalizeEmail(req.Username))
+		if user != nil && !user.EmailVerified() {
+			user = nil
+		}
+	} else {
+		user, err = s.userRepo.GetByUsername(ctx, req.Username)
+	}
+	if err != nil || user == nil {
+		s.audit(ctx, model.AuditLoginFailure, 0, map[string]interface{}{"username": req.Username, "reason": "unknown_user"})
 		return nil, errors.New(401, "用户名或密码错误", nil)
 	}
 
+	// 检查账号是否处于锁定期
+	if err := checkLocked(user); err != nil {
+		s.loginFailed(ctx, user, client, "password", "locked")
+		return nil, err
+	}
+
 	// 验证密码
-	if !utils.CheckPassword(req.Password, user.Password) {
+	ok, needsRehash := utils.VerifyPassword(req.Password, user.Password)
+	if !ok {
+		s.loginFailed(ctx, user, client, "password", "invalid_password")
+		if err := s.recordLoginFailure(ctx, user, client); err != nil {
+			return nil, err
+		}
 		return nil, errors.New(401, "用户名或密码错误", nil)
 	}
 
+	// 旧算法或旧参数生成的哈希在登录成功后透明升级
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, req.Password)
+	}
+
+	// 检查账号状态
+	if !canLoginOrRestore(user) {
+		s.loginFailed(ctx, user, client, "password", "inactive")
+		return nil, errUserInactive(user.Status)
+	}
+
+	// 开启两步验证的账号需要再提交一次验证码
+	if user.TwoFactorEnabled() {
+		return s.newTwoFactorChallenge(user)
+	}
+
+	return s.completeLogin(ctx, user, client, "password")
+}
+
+// checkPasswordPolicy 按密码策略检查新密码，不符合时返回带字段级错误的 400
+func checkPasswordPolicy(field, password, username string) error {
+	violations, err := utils.CheckPasswordPolicy(password, username)
+	if err != nil {
+		return errors.Wrap(err, "检查密码失败")
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fields := make([]errors.FieldError, 0, len(violations))
+	for _, v := range violations {
+		fields = append(fields, errors.FieldError{Field: field, Code: v.Code, Message: v.Message})
+	}
+	return errors.Validation("密码不符合安全要求", fields)
+}
+
+// rehashPassword 用当前的哈希算法和参数重新保存密码，失败不影响本次登录
+func (s *UserService) rehashPassword(ctx context.Context, userID uint, password string) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err == nil {
+		err = s.userRepo.UpdatePassword(ctx, userID, hashedPassword)
+	}
+	if err != nil {
+		logger.Warn("升级密码哈希失败", zap.Uint("user_id", userID), zap.Error(err))
+		return
+	}
+	logger.Info("已升级密码哈希", zap.Uint("user_id", userID))
+}
+
+// completeLogin 身份验证全部通过后清除失败记录并签发会话，method 为审计日志中记录的登录方式
+func (s *UserService) completeLogin(ctx context.Context, user *model.User, client *dto.ClientInfo, method string) (*dto.LoginResponse, error) {
+	if user.Status == model.UserStatusDeleted {
+		if err := s.restoreAccount(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.LoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{
+			"login_attempts": 0,
+			"locked_until":   nil,
+		}); err != nil {
+			return nil, errors.Wrap(err, "重置登录失败次数失败")
+		}
+	}
+
 	// 生成token
-	token, createdAt, expiresIn, expiredAt, err := s.GenerateToken(ctx, user.ID, user.Username)
+	tokens, err := s.GenerateToken(ctx, user.ID, user.Username, client)
 	if err != nil {
 		return nil, errors.Wrap(err, "生成token失败")
 	}
@@ -120,44 +339,192 @@ func (s *UserService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 		return nil, errors.Wrap(err, "更新登录时间失败")
 	}
 
+	recordAudit(ctx, s.auditRepo, &model.AuditEvent{
+		Action:  model.AuditLoginSuccess,
+		ActorID: user.ID,
+		UserID:  user.ID,
+	}, map[string]interface{}{"method": method})
+	s.loginSucceeded(ctx, user, client, method)
+
 	return &dto.LoginResponse{
-		Token:     token,
-		CreatedAt: createdAt,
-		ExpiresIn: expiresIn,
-		ExpiredAt: expiredAt,
-		User: dto.UserResponse{
-			Username:  user.Username,
-			Email:     user.Email,
-			Mobile:    user.Mobile,
-			Location:  user.Location,
-			Nickname:  user.Nickname,
-			Bio:       user.Bio,
-			Gender:    user.Gender,
-			Birthday:  user.Birthday,
-			Avatar:    user.Avatar,
-			LastLogin: user.LastLogin,
-		},
+		TokenResponse: tokens,
+		User:          toUserResponse(user),
 	}, nil
 }
 
+// checkLocked 账号处于锁定期时返回错误
+func checkLocked(user *model.User) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return errors.New(http.StatusLocked,
+			fmt.Sprintf("登录失败次数过多，账号已锁定，请于 %s 后重试", user.LockedUntil.Format(time.DateTime)), nil)
+	}
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败，连续失败达到上限后按指数退避锁定账号
+func (s *UserService) recordLoginFailure(ctx context.Context, user *model.User, client *dto.ClientInfo) error {
+	attempts, err := s.userRepo.IncrementLoginAttempts(ctx, user.ID)
+	if err != nil {
+		return errors.Wrap(err, "记录登录失败次数失败")
+	}
+
+	cfg := config.GetConfig()
+	if attempts < cfg.Security.LoginMaxAttempts {
+		return nil
+	}
+
+	duration := lockoutDuration(attempts-cfg.Security.LoginMaxAttempts, cfg.Security.LockoutDuration, cfg.Security.MaxLockoutDuration)
+	lockedUntil := time.Now().Add(duration)
+	if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{"locked_until": lockedUntil}); err != nil {
+		return errors.Wrap(err, "锁定账号失败")
+	}
+
+	logger.Warn("账号因多次登录失败被锁定",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+		zap.Int("attempts", attempts),
+		zap.Duration("duration", duration),
+		zap.String("ip", client.ClientIP),
+	)
+	return nil
+}
+
+// lockoutDuration 计算锁定时长：超出上限后每多失败一次时长翻倍，不超过 max
+func lockoutDuration(excess int, base, max time.Duration) time.Duration {
+	duration := base
+	for i := 0; i < excess && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		duration = max
+	}
+	return duration
+}
+
+// UnlockUser 管理员手动解锁账号
+func (s *UserService) UnlockUser(ctx context.Context, username string) error {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil {
+		return errors.New(404, "用户不存在", nil)
+	}
+
+	if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{
+		"login_attempts": 0,
+		"locked_until":   nil,
+	}); err != nil {
+		return errors.Wrap(err, "解锁账号失败")
+	}
+	s.audit(ctx, model.AuditUserUnlock, user.ID, nil)
+
+	logger.Info("账号已被管理员解锁", zap.Uint("user_id", user.ID), zap.String("username", user.Username))
+	return nil
+}
+
+// ChangeUserStatus 管理员变更用户状态，变更为不可登录的状态时立即结束该用户的所有会话
+func (s *UserService) ChangeUserStatus(ctx context.Context, username string, req *dto.UpdateUserStatusRequest) error {
+	status, ok := model.ParseUserStatus(req.Status)
+	if !ok {
+		return errors.New(400, "无效的用户状态", nil)
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil {
+		return errors.New(404, "用户不存在", nil)
+	}
+
+	if !user.Status.CanTransitionTo(status) {
+		return errors.New(400, fmt.Sprintf("不能将用户状态从 %s 变更为 %s", user.Status, status), nil)
+	}
+
+	// 暂停、停用或注销后无法登录，不能对最后一个管理员执行，包括管理员修改自己的状态
+	if !status.CanLogin() {
+		if err := s.checkLastAdmin(ctx, user, "不能暂停、停用或注销最后一个平台管理员"); err != nil {
+			return err
+		}
+	}
+
+	// 注销与用户自行注销相同，宽限期后彻底删除；从注销状态恢复时取消删除计划
+	if status == model.UserStatusDeleted {
+		if _, err := s.scheduleDeletion(ctx, user); err != nil {
+			return err
+		}
+	} else {
+		updates := map[string]interface{}{"status": status}
+		if user.Status == model.UserStatusDeleted {
+			updates["deletion_scheduled_at"] = nil
+		}
+		if err := s.userRepo.Update(ctx, user.ID, updates); err != nil {
+			return errors.Wrap(err, "更新用户状态失败")
+		}
+		if !status.CanLogin() {
+			if err := s.sessionRepo.InvalidateUserSessions(ctx, user.ID); err != nil {
+				return errors.Wrap(err, "结束用户会话失败")
+			}
+		}
+	}
+
+	s.audit(ctx, model.AuditUserStatusChange, user.ID, map[string]interface{}{
+		"from":   user.Status.String(),
+		"to":     status.String(),
+		"reason": req.Reason,
+	})
+
+	logger.Info("用户状态已变更",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+		zap.Stringer("from", user.Status),
+		zap.Stringer("to", status),
+		zap.String("reason", req.Reason),
+	)
+	return nil
+}
+
 func (s *UserService) GetUserByID(ctx context.Context, id uint) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByID(ctx, id)
-	if err != nil {
+	if err != nil || user == nil {
 		return nil, errors.New(404, "用户不存在", err)
 	}
 
+	return toUserResponse(user), nil
+}
+
+func toUserResponse(user *model.User) *dto.UserResponse {
 	return &dto.UserResponse{
-		Username:  user.Username,
-		Email:     user.Email,
-		Mobile:    user.Mobile,
-		Location:  user.Location,
-		Nickname:  user.Nickname,
-		Bio:       user.Bio,
-		Gender:    user.Gender,
-		Birthday:  user.Birthday,
-		Avatar:    user.Avatar,
-		LastLogin: user.LastLogin,
-	}, nil
+		Username:         user.Username,
+		Email:            user.EmailAddress(),
+		EmailVerified:    user.EmailVerified(),
+		Mobile:           user.Mobile,
+		Location:         user.Location,
+		Nickname:         user.Nickname,
+		Bio:              user.Bio,
+		Gender:           user.Gender,
+		Birthday:         user.Birthday,
+		Avatar:           user.Avatar,
+		LastLogin:        user.LastLogin,
+		Language:         user.Language,
+		Status:           user.Status.String(),
+		Role:             string(user.Role),
+		TwoFactorEnabled: user.TwoFactorEnabled(),
+		PasswordEnabled:  user.Password != "",
+	}
+}
+
+// errUserInactive 账号状态不允许登录时返回的错误
+func errUserInactive(status model.UserStatus) error {
+	switch status {
+	case model.UserStatusSuspended:
+		return errors.New(http.StatusForbidden, "账号已被暂停使用", nil)
+	case model.UserStatusDeleted:
+		return errors.New(http.StatusForbidden, "账号已注销", nil)
+	default:
+		return errors.New(http.StatusForbidden, "账号已被停用", nil)
+	}
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, id uint, req *dto.UpdateUserRequest) error {
@@ -166,9 +533,6 @@ func (s *UserService) UpdateUser(ctx context.Context, id uint, req *dto.UpdateUs
 	if req.Nickname != "" {
 		updates["nickname"] = req.Nickname
 	}
-	if req.Email != "" {
-		updates["email"] = req.Email
-	}
 	if req.Mobile != "" {
 		updates["mobile"] = req.Mobile
 	}
@@ -188,7 +552,38 @@ func (s *UserService) UpdateUser(ctx context.Context, id uint, req *dto.UpdateUs
 		updates["language"] = req.Language
 	}
 
-	return s.userRepo.Update(ctx, id, updates)
+	// 修改邮箱后需要重新验证，验证前账号处于待验证状态
+	var emailChanged bool
+	if req.Email != "" {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil || user == nil {
+			return errors.New(404, "用户不存在", err)
+		}
+
+		email := normalizeEmail(req.Email)
+		if email != user.EmailAddress() {
+			if err := s.checkEmailAvailable(ctx, email, id); err != nil {
+				return err
+			}
+			updates["email"] = email
+			updates["email_verified_at"] = nil
+			if user.Status == model.UserStatusActive {
+				updates["status"] = model.UserStatusPending
+			}
+			emailChanged = true
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, id, updates); err != nil {
+		return errors.Wrap(err, "更新用户信息失败")
+	}
+
+	if emailChanged {
+		if err := s.SendEmailVerification(ctx, id); err != nil {
+			logger.Warn("发送验证邮件失败", zap.Uint("user_id", id), zap.Error(err))
+		}
+	}
+	return nil
 }
 
 func (s *UserService) ChangePassword(ctx context.Context, id uint, req *dto.ChangePasswordRequest) error {
@@ -197,9 +592,15 @@ func (s *UserService) ChangePassword(ctx context.Context, id uint, req *dto.Chan
 		return errors.New(404, "用户不存在", err)
 	}
 
-	// 验证旧密码
-	if !utils.CheckPassword(req.OldPassword, user.Password) {
-		return errors.New(400, "旧密码错误", nil)
+	// 验证旧密码，通过第三方登录创建的账号首次设置密码时无需旧密码
+	if user.Password != "" {
+		if ok, _ := utils.VerifyPassword(req.OldPassword, user.Password); !ok {
+			return errors.New(400, "旧密码错误", nil)
+		}
+	}
+
+	if err := checkPasswordPolicy("newPassword", req.NewPassword, user.Username); err != nil {
+		return err
 	}
 
 	// 加密新密码
@@ -208,15 +609,128 @@ func (s *UserService) ChangePassword(ctx context.Context, id uint, req *dto.Chan
 		return errors.Wrap(err, "密码加密失败")
 	}
 
-	return s.userRepo.UpdatePassword(ctx, id, hashedPassword)
+	if err := s.userRepo.UpdatePassword(ctx, id, hashedPassword); err != nil {
+		return err
+	}
+
+	// 首次设置密码与修改密码都记为 password.change，通过 metadata 区分
+	s.audit(ctx, model.AuditPasswordChange, id, map[string]interface{}{"initial": user.Password == ""})
+	return nil
+}
+
+// ForgotPassword 向用户邮箱发送密码重置链接。为避免泄露邮箱是否注册，邮箱不存在时也视为成功
+func (s *UserService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		return errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil || !user.Status.CanLogin() {
+		logger.Info("密码重置请求的邮箱无对应可用账号", zap.String("email", req.Email))
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.Wrap(err, "生成重置令牌失败")
+	}
+
+	// 新令牌生成后，之前发出的令牌全部作废
+	if err := s.passwordResetRepo.InvalidateUserTokens(ctx, user.ID); err != nil {
+		return errors.Wrap(err, "作废旧令牌失败")
+	}
+
+	cfg := config.GetConfig()
+	if err := s.passwordResetRepo.Create(ctx, &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiredAt: time.Now().Add(cfg.Security.PasswordResetTTL),
+	}); err != nil {
+		return errors.Wrap(err, "保存重置令牌失败")
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(cfg.App.URL, "/"), token)
+	if err := s.mailer.Send(ctx, &mail.Message{
+		To:      user.EmailAddress(),
+		Subject: "重置您的 DDUP 密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置您账号密码的请求。请在 %d 分钟内打开以下链接设置新密码：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。\n",
+			user.Nickname, int(cfg.Security.PasswordResetTTL.Minutes()), link),
+	}); err != nil {
+		return errors.Wrap(err, "发送重置邮件失败")
+	}
+
+	return nil
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
-	return s.userRepo.Delete(ctx, id)
+// ResetPassword 使用重置令牌设置新密码，令牌只能使用一次；成功后结束该用户的所有会话
+func (s *UserService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	resetToken, err := s.passwordResetRepo.GetByTokenHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return errors.Wrap(err, "查询重置令牌失败")
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiredAt) {
+		return errors.New(400, "重置链接无效或已过期", nil)
+	}
+
+	// 新密码不符合策略时不消耗重置令牌，用户可以换一个密码重试
+	user, err := s.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil || user == nil {
+		return errors.New(400, "重置链接无效或已过期", err)
+	}
+	if err := checkPasswordPolicy("newPassword", req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	used, err := s.passwordResetRepo.MarkUsed(ctx, resetToken.ID)
+	if err != nil {
+		return errors.Wrap(err, "更新重置令牌失败")
+	}
+	if !used {
+		return errors.New(400, "重置链接无效或已过期", nil)
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return errors.Wrap(err, "密码加密失败")
+	}
+
+	if err := s.userRepo.Update(ctx, resetToken.UserID, map[string]interface{}{
+		"password":       hashedPassword,
+		"login_attempts": 0,
+		"locked_until":   nil,
+	}); err != nil {
+		return errors.Wrap(err, "更新密码失败")
+	}
+
+	if err := s.sessionRepo.InvalidateUserSessions(ctx, resetToken.UserID); err != nil {
+		return errors.Wrap(err, "结束用户会话失败")
+	}
+
+	s.audit(ctx, model.AuditPasswordReset, resetToken.UserID, nil)
+	logger.Info("用户已通过邮件重置密码", zap.Uint("user_id", resetToken.UserID))
+	return nil
 }
 
 func (s *UserService) Logout(ctx context.Context, token string) error {
-	return s.sessionRepo.InvalidateSession(ctx, token)
+	tokenHash := utils.HashToken(token)
+	session, err := s.sessionRepo.GetSessionByTokenHash(ctx, tokenHash)
+	if err != nil || !session.IsValid {
+		// 令牌不对应有效会话时无需退出，也不记录审计事件
+		return nil
+	}
+
+	if err := s.sessionRepo.InvalidateSession(ctx, tokenHash); err != nil {
+		return err
+	}
+	event := &model.AuditEvent{
+		Action:  model.AuditLogout,
+		ActorID: session.UserID,
+		UserID:  session.UserID,
+	}
+	if session.ImpersonatorID != nil {
+		event.ImpersonatorID = *session.ImpersonatorID
+	}
+	recordAudit(ctx, s.auditRepo, event, map[string]interface{}{"sessionId": session.ID})
+	return nil
 }
 
 // 4. 优化 ValidateToken 方法
@@ -231,66 +745,258 @@ func (s *UserService) ValidateToken(token string) (*TokenValidationResult, error
 	}
 
 	ctx := context.Background()
-	session, err := s.sessionRepo.GetSessionByToken(ctx, token)
+	session, err := s.sessionRepo.GetSessionByTokenHash(ctx, utils.HashToken(token))
 	if err != nil {
 		return &TokenValidationResult{Valid: false}, errors.Wrap(err, "会话不存在")
 	}
 
-	if !session.IsValid || time.Now().After(session.ExpiredAt) {
+	// 授权给 OIDC 应用的令牌只能访问 userinfo，不能调用 ddup 自身的接口
+	if !session.IsValid || session.ClientID != "" || time.Now().After(session.ExpiredAt) {
 		return &TokenValidationResult{Valid: false}, nil
 	}
 
-	return &TokenValidationResult{
-		UserID:   claims.UserID,
-		Username: claims.Username,
-		Valid:    true,
-	}, nil
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return &TokenValidationResult{Valid: false}, errors.Wrap(err, "查询用户失败")
+	}
+	if user == nil {
+		return &TokenValidationResult{Valid: false}, nil
+	}
+	if !user.Status.CanLogin() {
+		return &TokenValidationResult{Valid: false}, errUserInactive(user.Status)
+	}
+
+	result := &TokenValidationResult{
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		SessionID: session.ID,
+		Status:    user.Status,
+		Role:      user.Role,
+		Valid:     true,
+	}
+	if session.ImpersonatorID != nil {
+		ok, err := s.checkImpersonator(ctx, *session.ImpersonatorID)
+		if err != nil || !ok {
+			return &TokenValidationResult{Valid: false}, err
+		}
+		result.ImpersonatorID = *session.ImpersonatorID
+	}
+	return result, nil
 }
 
 func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
-// GenerateToken 生成 JWT Token 并保存到数据库
-func (s *UserService) GenerateToken(ctx context.Context, userID uint, username string) (string, time.Time, int64, time.Time, error) {
+// GenerateToken 为一次新的登录生成 access token 和 refresh token，并开启新的 token family
+func (s *UserService) GenerateToken(ctx context.Context, userID uint, username string, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceLabel := client.DeviceName
+	if deviceLabel == "" {
+		deviceLabel = utils.DeviceLabel(client.UserAgent)
+	}
+
+	return s.issueTokens(ctx, userID, username, &model.Session{
+		FamilyID:    familyID,
+		UserAgent:   client.UserAgent,
+		ClientIP:    client.ClientIP,
+		DeviceLabel: deviceLabel,
+		SignedInAt:  time.Now(),
+	})
+}
+
+// RefreshToken 使用 refresh token 换取新的 token 对，旧的 refresh token 随即失效。
+// 已轮换的 refresh token 再次出现说明可能已泄露，此时撤销整个 token family。
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	return s.rotateRefreshToken(ctx, refreshToken, "", client)
+}
+
+// rotateRefreshToken 轮换 refresh token，clientID 必须与会话所属的应用一致，ddup 自身的会话为空
+func (s *UserService) rotateRefreshToken(ctx context.Context, refreshToken string, clientID string, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	refreshHash := utils.HashToken(refreshToken)
+	session, err := s.sessionRepo.GetSessionByRefreshToken(ctx, refreshHash)
+	if err != nil || session.ClientID != clientID {
+		return nil, errors.New(401, "无效的 refresh token", err)
+	}
+
+	if !session.IsValid {
+		return nil, s.rejectInvalidSession(ctx, session)
+	}
+
+	if time.Now().After(session.RefreshExpiredAt) {
+		return nil, errors.New(401, "refresh token 已过期", nil)
+	}
+
+	rotated, err := s.sessionRepo.RotateSession(ctx, session.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "刷新token失败")
+	}
+	if !rotated {
+		// 会话已被并发请求轮换或撤销，重新读取以区分重用和普通失效
+		session, err = s.sessionRepo.GetSessionByRefreshToken(ctx, refreshHash)
+		if err != nil {
+			return nil, errors.New(401, "无效的 refresh token", err)
+		}
+		return nil, s.rejectInvalidSession(ctx, session)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(401, "用户不存在", err)
+	}
+	if !user.Status.CanLogin() {
+		return nil, errUserInactive(user.Status)
+	}
+
+	tokens, err := s.issueTokens(ctx, user.ID, user.Username, &model.Session{
+		FamilyID:    session.FamilyID,
+		UserAgent:   client.UserAgent,
+		ClientIP:    client.ClientIP,
+		DeviceLabel: session.DeviceLabel,
+		ClientID:    session.ClientID,
+		Scope:       session.Scope,
+		SignedInAt:  session.SignedInAt,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "生成token失败")
+	}
+	return tokens, nil
+}
+
+// rejectInvalidSession 拒绝已失效会话的 refresh token。只有已轮换的 refresh token 再次出现才视为重用并撤销整个 family，
+// 退出登录、强制下线或超出会话数上限失效的会话只返回 401
+func (s *UserService) rejectInvalidSession(ctx context.Context, session *model.Session) error {
+	if session.RotatedAt != nil {
+		return s.revokeTokenFamily(ctx, session)
+	}
+	return errors.New(401, "refresh token 已失效，请重新登录", nil)
+}
+
+// revokeTokenFamily 处理 refresh token 重用：撤销该 family 下的所有会话
+func (s *UserService) revokeTokenFamily(ctx context.Context, session *model.Session) error {
+	logger.Warn("检测到 refresh token 重用，撤销 token family",
+		zap.Uint("user_id", session.UserID),
+		zap.String("family_id", session.FamilyID),
+	)
+
+	if err := s.sessionRepo.InvalidateSessionFamily(ctx, session.FamilyID); err != nil {
+		return errors.Wrap(err, "撤销会话失败")
+	}
+	return errors.New(401, "refresh token 已失效，请重新登录", nil)
+}
+
+// issueTokens 签发 token 对并以 session 为模板保存会话，超出会话数上限时挤掉最早的会话
+func (s *UserService) issueTokens(ctx context.Context, userID uint, username string, session *model.Session) (*dto.TokenResponse, error) {
 	cfg := config.GetConfig()
 	now := time.Now()
-	expiresIn := int64(cfg.JWT.ExpiresIn.Seconds())
 	expiredAt := now.Add(cfg.JWT.ExpiresIn)
-	createdAt := now
+	refreshExpiredAt := now.Add(cfg.JWT.RefreshExpiresIn)
+
+	tokenID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
 
-	// 生成新 Token
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expiredAt),
-			IssuedAt:  jwt.NewNumericDate(createdAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+	if session.ClientID != "" {
+		claims.Scope = session.Scope
+		claims.Audience = jwt.ClaimStrings{session.ClientID}
+	}
+
+	token, err := utils.SignToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UserID = userID
+	session.TokenHash = utils.HashToken(token)
+	session.RefreshToken = utils.HashToken(refreshToken)
+	session.IsValid = true
+	session.ExpiredAt = expiredAt
+	session.RefreshExpiredAt = refreshExpiredAt
+	session.LastSeenAt = &now
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := jwtToken.SignedString([]byte(cfg.JWT.Secret))
+	if err := s.sessionRepo.InvalidateExcessSessions(ctx, userID, session.ClientID, cfg.JWT.MaxSessions); err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		Token:            token,
+		RefreshToken:     refreshToken,
+		CreatedAt:        now,
+		ExpiresIn:        int64(cfg.JWT.ExpiresIn.Seconds()),
+		ExpiredAt:        expiredAt,
+		RefreshExpiredAt: refreshExpiredAt,
+	}, nil
+}
+
+// ListSessions 列出用户当前登录的设备
+func (s *UserService) ListSessions(ctx context.Context, userID uint, currentSessionID uint) ([]dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.GetUserSessions(ctx, userID)
 	if err != nil {
-		return "", time.Time{}, 0, time.Time{}, err
+		return nil, errors.Wrap(err, "获取会话列表失败")
+	}
+
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, dto.SessionResponse{
+			ID:          session.ID,
+			DeviceLabel: session.DeviceLabel,
+			UserAgent:   session.UserAgent,
+			ClientIP:    session.ClientIP,
+			SignedInAt:  session.SignedInAt,
+			LastSeenAt:  session.LastSeenAt,
+			ExpiredAt:   session.RefreshExpiredAt,
+			Current:     session.ID == currentSessionID,
+		})
 	}
+	return resp, nil
+}
 
-	// 将用户现有的 token 标记为无效并软删除
-	if err := s.sessionRepo.InvalidateUserSessions(ctx, userID); err != nil {
-		return "", time.Time{}, 0, time.Time{}, err
+// RevokeSession 注销用户的某个会话，连同其 refresh token 一起失效
+func (s *UserService) RevokeSession(ctx context.Context, userID uint, sessionID uint) error {
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New(404, "会话不存在", err)
 	}
 
-	// 保存新 Token 到数据库
-	newSession := model.Session{
-		UserID:    userID,
-		Token:     token,
-		IsValid:   true,
-		ExpiredAt: expiredAt,
+	if err := s.sessionRepo.InvalidateSessionFamily(ctx, session.FamilyID); err != nil {
+		return err
 	}
+	s.audit(ctx, model.AuditSessionRevoke, userID, map[string]interface{}{"sessionId": session.ID})
+	return nil
+}
 
-	if err = db.DB.Create(&newSession).Error; err != nil {
-		return "", time.Time{}, 0, time.Time{}, err
+// RevokeOtherSessions 注销当前会话以外的所有会话
+func (s *UserService) RevokeOtherSessions(ctx context.Context, userID uint, currentSessionID uint) error {
+	if err := s.sessionRepo.InvalidateUserSessionsExcept(ctx, userID, currentSessionID); err != nil {
+		return err
 	}
+	s.audit(ctx, model.AuditSessionRevoke, userID, map[string]interface{}{"exceptSessionId": currentSessionID})
+	return nil
+}
 
-	return token, createdAt, expiresIn, expiredAt, nil
+// TouchSession 记录会话的最后活跃时间
+func (s *UserService) TouchSession(ctx context.Context, sessionID uint, clientIP string) error {
+	return s.sessionRepo.TouchSession(ctx, sessionID, clientIP, sessionTouchInterval)
 }
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"ddup-apis/internal/config"
+	"ddup-apis/internal/dto"
+	"ddup-apis/internal/logger"
+	"ddup-apis/internal/mail"
+	"ddup-apis/internal/model"
+	"ddup-apis/internal/notify"
+	"ddup-apis/internal/repository"
+	"ddup-apis/internal/utils"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// recordingMailer 保存发送的邮件，便于从正文中取出令牌
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []*mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last 返回最后一封邮件，没有邮件时返回 nil
+func (m *recordingMailer) last() *mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return nil
+	}
+	return m.messages[len(m.messages)-1]
+}
+
+// lastToken 从最后一封邮件的链接中取出令牌
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	msg := m.last()
+	if msg == nil {
+		t.Fatal("没有发送邮件")
+	}
+	match := regexp.MustCompile(`token=([0-9a-f]+)`).FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("邮件中没有令牌: %s", msg.Body)
+	}
+	return match[1]
+}
+
+// count 返回已发送的邮件数
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.messages)
+}
+
+// newTestAccountService 创建迁移了账号相关表的完整 UserService。configure 不为 nil 时用于修改测试配置，
+// organizations 表的 check 约束只适用于 PostgreSQL，不迁移
+func newTestAccountService(t *testing.T, configure func(cfg *config.Config)) (*UserService, *gorm.DB, *recordingMailer) {
+	t.Helper()
+	cfg := testConfig()
+	cfg.JWT.Secret = "test-jwt-secret-0123456789abcdef"
+	cfg.JWT.ExpiresIn = 15 * time.Minute
+	cfg.JWT.RefreshExpiresIn = 24 * time.Hour
+	cfg.JWT.MaxSessions = 10
+	// 测试使用最低的 bcrypt cost，避免哈希过慢
+	cfg.Password.Hasher = "bcrypt"
+	cfg.Password.BcryptCost = bcrypt.MinCost
+	if configure != nil {
+		configure(&cfg)
+	}
+	config.SetConfig(cfg)
+	if err := utils.InitKeySet(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.InitPasswordHasher(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t,
+		&model.User{},
+		&model.Session{},
+		&model.PasswordResetToken{},
+		&model.MagicLinkToken{},
+		&model.EmailVerificationToken{},
+		&model.RecoveryCode{},
+		&model.PersonalAccessToken{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.OIDCClient{},
+		&model.OIDCConsent{},
+		&model.OIDCAuthorizationCode{},
+		&model.Profile{},
+		&model.ProfileAudience{},
+		&model.ProfileAttachment{},
+		&model.AttachmentUpload{},
+		&model.OrganizationMember{},
+		&model.AuditEvent{},
+		&model.LoginHistory{},
+	)
+	mailer := &recordingMailer{}
+	return NewUserService(db, mailer, notify.NopNotifier{}, nil), db, mailer
+}
+
+// newTestUserService 创建只使用用户和会话仓储的 UserService，并以 HS256 签发 token
+func newTestUserService(t *testing.T) (*UserService, *gorm.DB, *model.User) {
+	t.Helper()
+	cfg := testConfig()
+	cfg.JWT.Secret = "test-jwt-secret-0123456789abcdef"
+	cfg.JWT.ExpiresIn = 15 * time.Minute
+	cfg.JWT.RefreshExpiresIn = 24 * time.Hour
+	cfg.JWT.MaxSessions = 10
+	config.SetConfig(cfg)
+	if err := utils.InitKeySet(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t, &model.User{}, &model.Session{})
+	s := &UserService{
+		userRepo:    repository.NewUserRepository(db),
+		sessionRepo: repository.NewSessionRepository(db),
+	}
+	user := &model.User{Username: "alice", Password: "x", Status: model.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return s, db, user
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	s, db, user := newTestUserService(t)
+	client := &dto.ClientInfo{UserAgent: "test", ClientIP: "127.0.0.1"}
+
+	first, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	// 另一次登录属于不同的 token family，不受影响
+	other, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.RefreshToken(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.Token == first.Token {
+		t.Fatal("刷新后应签发新的 token 对")
+	}
+
+	// 重用已轮换的 refresh token
+	if _, err := s.RefreshToken(ctx, first.RefreshToken, client); errorCode(err) != 401 {
+		t.Fatalf("重用 refresh token: error = %v, want 401", err)
+	}
+	// 整个 family 被撤销，包括刚签发的 token 对，只剩另一次登录的会话
+	if _, err := s.RefreshToken(ctx, second.RefreshToken, client); errorCode(err) != 401 {
+		t.Errorf("family 撤销后刷新: error = %v, want 401", err)
+	}
+	var valid []model.Session
+	if err := db.Where("is_valid = ?", true).Find(&valid).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(valid) != 1 || valid[0].RefreshToken != utils.HashToken(other.RefreshToken) {
+		t.Errorf("family 撤销后有效会话数 = %d, want 1", len(valid))
+	}
+
+	if _, err := s.RefreshToken(ctx, other.RefreshToken, client); err != nil {
+		t.Errorf("其他 family 的 refresh token 应不受影响: %v", err)
+	}
+}
+
+func TestRefreshTokenInvalidatedWithoutRotation(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	user := createTestUser(t, db, "alice")
+	client := &dto.ClientInfo{UserAgent: "test", ClientIP: "127.0.0.1"}
+
+	core, logs := observer.New(zap.WarnLevel)
+	logger.Log = zap.New(core)
+
+	first, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RefreshToken(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 退出登录的会话没有被轮换，再次刷新只返回 401，不视为重用
+	if err := s.Logout(ctx, second.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshToken(ctx, second.RefreshToken, client); errorCode(err) != 401 {
+		t.Errorf("退出登录后刷新: error = %v, want 401", err)
+	}
+	if logs.FilterMessage("检测到 refresh token 重用，撤销 token family").Len() != 0 {
+		t.Error("退出登录的会话不应视为 refresh token 重用")
+	}
+
+	// 已轮换的 refresh token 再次出现才视为重用
+	if _, err := s.RefreshToken(ctx, first.RefreshToken, client); errorCode(err) != 401 {
+		t.Errorf("重用 refresh token: error = %v, want 401", err)
+	}
+	if logs.FilterMessage("检测到 refresh token 重用，撤销 token family").Len() != 1 {
+		t.Error("重用已轮换的 refresh token 应撤销 token family")
+	}
+}
+
+func TestInvalidateExcessSessionsSkipsExpired(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, func(cfg *config.Config) { cfg.JWT.MaxSessions = 2 })
+	user := createTestUser(t, db, "alice")
+	client := &dto.ClientInfo{}
+
+	older, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&model.Session{}).Where("refresh_token = ?", utils.HashToken(expired.RefreshToken)).
+		Update("refresh_expired_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// refresh token 已过期的会话不占用会话数，较早的有效会话不应被挤掉
+	if _, err := s.GenerateToken(ctx, user.ID, user.Username, client); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshToken(ctx, older.RefreshToken, client); err != nil {
+		t.Errorf("较早的会话不应被挤掉: %v", err)
+	}
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	ctx := context.Background()
+	s, db, user := newTestUserService(t)
+	client := &dto.ClientInfo{}
+
+	tokens, err := s.GenerateToken(ctx, user.ID, user.Username, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := s.sessionRepo.GetSessionByRefreshToken(ctx, utils.HashToken(tokens.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.RefreshExpiredAt.After(session.ExpiredAt) {
+		t.Errorf("refresh token 有效期 %v 应晚于 access token %v", session.RefreshExpiredAt, session.ExpiredAt)
+	}
+
+	if err := db.Model(&model.Session{}).
+		Where("id = ?", session.ID).Update("refresh_expired_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshToken(ctx, tokens.RefreshToken, client); errorCode(err) != 401 {
+		t.Errorf("过期的 refresh token: error = %v, want 401", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	base, max := time.Minute, time.Hour
+	tests := []struct {
+		excess int
+		want   time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{5, 32 * time.Minute},
+		{6, time.Hour}, // 64 分钟超过上限
+		{7, time.Hour},
+		{1000, time.Hour}, // 不会溢出
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.excess, base, max); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.excess, got, tt.want)
+		}
+	}
+	if got := lockoutDuration(3, time.Hour, time.Hour); got != time.Hour {
+		t.Errorf("base 等于 max 时 lockoutDuration() = %v, want %v", got, time.Hour)
+	}
+}
+
+func TestRecordLoginFailureLockout(t *testing.T) {
+	ctx := context.Background()
+	s, _, user := newTestUserService(t)
+	cfg := *config.GetConfig()
+	cfg.Security.LoginMaxAttempts = 3
+	cfg.Security.LockoutDuration = time.Minute
+	cfg.Security.MaxLockoutDuration = 4 * time.Minute
+	config.SetConfig(cfg)
+	client := &dto.ClientInfo{ClientIP: "127.0.0.1"}
+
+	// 达到上限前不锁定，之后每多失败一次锁定时长翻倍，直到上限
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, duration := range want {
+		start := time.Now()
+		if err := s.recordLoginFailure(ctx, user, client); err != nil {
+			t.Fatalf("recordLoginFailure() error = %v", err)
+		}
+		current, err := s.userRepo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current.LoginAttempts != i+1 {
+			t.Errorf("第 %d 次失败后 LoginAttempts = %d", i+1, current.LoginAttempts)
+		}
+		if duration == 0 {
+			if current.LockedUntil != nil {
+				t.Errorf("第 %d 次失败后不应锁定", i+1)
+			}
+			continue
+		}
+		if current.LockedUntil == nil {
+			t.Fatalf("第 %d 次失败后应锁定", i+1)
+		}
+		if got := current.LockedUntil.Sub(start); got < duration || got > duration+5*time.Second {
+			t.Errorf("第 %d 次失败后锁定 %v, want %v", i+1, got, duration)
+		}
+		if err := checkLocked(current); errorCode(err) != 423 {
+			t.Errorf("锁定期内 checkLocked() = %v, want 423", err)
+		}
+	}
+}
+
+// setTestPassword 为用户设置密码
+func setTestPassword(t *testing.T, db *gorm.DB, user *model.User, password string) {
+	t.Helper()
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(user).Update("password", hashed).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangeUserStatus(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newTestAccountService(t, nil)
+	user := createTestUser(t, db, "alice")
+	setTestPassword(t, db, user, "Xq7#Str0ng!pass")
+	login := func() error {
+		_, err := s.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "Xq7#Str0ng!pass"}, &dto.ClientInfo{})
+		return err
+	}
+	changeStatus := func(status string) error {
+		return s.ChangeUserStatus(ctx, "alice", &dto.UpdateUserStatusRequest{Status: status, Reason: "测试"})
+	}
+
+	if err := changeStatus("unknown"); errorCode(err) != 400 {
+		t.Errorf("无效的状态: error = %v, want 400", err)
+	}
+	if err := s.ChangeUserStatus(ctx, "nobody", &dto.UpdateUserStatusRequest{Status: "active"}); errorCode(err) != 404 {
+		t.Errorf("用户不存在: error = %v, want 404", err)
+	}
+
+	tokens, err := s.GenerateToken(ctx, user.ID, user.Username, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 暂停后立即结束会话，不能再登录
+	if err := changeStatus("suspended"); err != nil {
+		t.Fatalf("暂停: error = %v", err)
+	}
+	if result, err := s.ValidateToken(tokens.Token); result.Valid || err == nil {
+		t.Errorf("暂停后 ValidateToken() = %+v, %v", result, err)
+	}
+	if err := login(); errorCode(err) != 403 {
+		t.Errorf("暂停后登录: error = %v, want 403", err)
+	}
+	// 暂停状态不能直接变为待验证
+	if err := changeStatus("pending"); errorCode(err) != 400 {
+		t.Errorf("suspended -> pending: error = %v, want 400", err)
+	}
+
+	if err := changeStatus("active"); err != nil {
+		t.Fatalf("恢复: error = %v", err)
+	}
+	if err := login(); err != nil {
+		t.Errorf("恢复后登录: error = %v", err)
+	}
+	// 待验证的账号可以登录
+	if err := changeStatus("pending"); err != nil {
+		t.Fatalf("pending: error = %v", err)
+	}
+	if err := login(); err != nil {
+		t.Errorf("待验证时登录: error = %v", err)
+	}
+
+	// 停用后只能恢复或注销
+	if err := changeStatus("disabled"); err != nil {
+		t.Fatalf("停用: error = %v", err)
+	}
+	if err := login(); errorCode(err) != 403 {
+		t.Errorf("停用后登录: error = %v, want 403", err)
+	}
+	if err := changeStatus("suspended"); errorCode(err) != 400 {
+		t.Errorf("disabled -> suspended: error = %v, want 400", err)
+	}
+
+	// 注销后计划删除，恢复时取消
+	if err := changeStatus("deleted"); err != nil {
+		t.Fatalf("注销: error = %v", err)
+	}
+	var saved model.User
+	db.First(&saved, user.ID)
+	if saved.Status != model.UserStatusDeleted || saved.DeletionScheduledAt == nil {
+		t.Errorf("注销后 status = %s, deletion_scheduled_at = %v", saved.Status, saved.DeletionScheduledAt)
+	}
+	if err := changeStatus("active"); err != nil {
+		t.Fatalf("从注销恢复: error = %v", err)
+	}
+	saved = model.User{}
+	db.First(&saved, user.ID)
+	if saved.Status != model.UserStatusActive || saved.DeletionScheduledAt != nil {
+		t.Errorf("恢复后 status = %s, deletion_scheduled_at = %v", saved.Status, saved.DeletionScheduledAt)
+	}
+
+	var changes int64
+	db.Model(&model.AuditEvent{}).Where("action = ? AND user_id = ?", model.AuditUserStatusChange, user.ID).Count(&changes)
+	if changes != 6 {
+		t.Errorf("状态变更审计事件数 = %d, want 6", changes)
+	}
+}
+
+// createTestUserWithEmail 创建带邮箱的用户，verified 为 false 时邮箱未验证、账号为待验证状态
+func createTestUserWithEmail(t *testing.T, db *gorm.DB, username, email string, verified bool) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Nickname: username, Password: "x", Email: &email, Status: model.UserStatusPending}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		user.Status = model.UserStatusActive
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	s, db, mailer := newTestAccountService(t, func(cfg *config.Config) {
+		cfg.App.URL = "https://ddup.test"
+		cfg.Security.PasswordResetTTL = 30 * time.Minute
+	})
+	user := createTestUserWithEmail(t, db, "alice", "alice@example.com", true)
+	tokens, err := s.GenerateToken(ctx, user.ID, user.Username, &dto.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset := func(token, password string) error {
+		return s.ResetPassword(ctx, &dto.ResetPasswordRequest{Token: token, NewPassword: password})
+	}
+
+	// 不存在的邮箱同样返回成功，但不发送邮件
+	if err := s.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "nobody@example.com"}); err != nil || mailer.count() != 0 {
+		t.Errorf("不存在的邮箱: error = %v, 邮件数 = %d", err, mailer.count())
+	}
+
+	// 邮箱不区分大小写；重新申请后之前的令牌作废
+	if err := s.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "Alice@Example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	first := mailer.lastToken(t)
+	if msg := mailer.last(); msg.To != "alice@example.com" || !strings.Contains(msg.Body, "https://ddup.test/reset-password?token=") {
+		t.Errorf("重置邮件 = %+v", msg)
+	}
+	if err := s.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	second := mailer.lastToken(t)
+	if err := reset(first, "Xq7#Str0ng!pass"); errorCode(err) != 400 {
+		t.Errorf("旧令牌: error = %v, want 400", err)
+	}
+
+	// 不符合密码策略时不消耗令牌
+	if err := reset(second, "short"); !hasFieldError(err, "newPassword") {
+		t.Errorf("弱密码: error = %v, want newPassword 字段错误", err)
+	}
+	if err := reset(second, "Xq7#Str0ng!pass"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if err := reset(second, "An0ther#Str0ng!pass"); errorCode(err) != 400 {
+		t.Errorf("令牌只能使用一次: error = %v, want 400", err)
+	}
+
+	// 重置后结束所有会话，使用新密码登录
+	if result, _ := s.ValidateToken(tokens.Token); result.Valid {
+		t.Error("重置密码后会话应失效")
+	}
+	if _, err := s.Login(ctx, &dto.LoginRequest{Username: "alice@example.com", Password: "Xq7#Str0ng!pass"}, &dto.ClientInfo{}); err != nil {
+		t.Errorf("使用新密码登录: error = %v", err)
+	}
+
+	// 过期的令牌
+	if err := s.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	expired := mailer.lastToken(t)
+	if err := db.Model(&model.PasswordResetToken{}).Where("token_hash = ?", utils.HashToken(expired)).
+		Update("expired_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := reset(expired, "An0ther#Str0ng!pass"); errorCode(err) != 400 {
+		t.Errorf("过期的令牌: error = %v, want 400", err)
+	}
+	if err := reset("0123456789abcdef", "An0ther#Str0ng!pass"); errorCode(err) != 400 {
+		t.Errorf("不存在的令牌: error = %v, want 400", err)
+	}
+
+	// 令牌只保存摘要
+	var stored int64
+	db.Model(&model.PasswordResetToken{}).Where("token_hash IN ?", []string{first, second, expired}).Count(&stored)
+	if stored != 0 {
+		t.Error("数据库中不应保存令牌原文")
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	root := t.TempDir()
+	s := &LocalStorage{root: root}
+
+	for _, key := range []string{"1/2/abc", "a/./b", "a/../b"} {
+		path, err := s.path(key)
+		if err != nil {
+			t.Errorf("path(%q) error = %v", key, err)
+			continue
+		}
+		if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			t.Errorf("path(%q) = %s, 不在存储目录下", key, path)
+		}
+	}
+
+	for _, key := range []string{"", "..", "../x", "a/../../x", "/etc/passwd", "../" + filepath.Base(root) + "x/y"} {
+		if path, err := s.path(key); err == nil {
+			t.Errorf("path(%q) = %s, 应拒绝跳出存储目录的 key", key, path)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("hello")
+	if err := s.Put(ctx, "1/2/abc", bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	r, err := s.Get(ctx, "1/2/abc")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Get() = %q, want %q", got, content)
+	}
+
+	if err := s.Put(ctx, "../escape", bytes.NewReader(content), int64(len(content)), "text/plain"); err == nil {
+		t.Error("Put() 应拒绝跳出存储目录的 key")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(s.root), "escape")); err == nil {
+		t.Error("不应在存储目录外创建文件")
+	}
+
+	if err := s.Delete(ctx, "1/2/abc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(ctx, "1/2/abc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("删除后 Get() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"ddup-apis/internal/config"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("对象不存在")
+
+// Storage 附件存储接口，key 由调用方生成，使用 / 分隔的相对路径
+type Storage interface {
+	// Put 写入对象，size 为内容长度，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，不存在时返回 ErrNotFound，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage 根据配置创建存储后端
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case "local", "":
+		return NewLocalStorage(cfg.Storage.LocalDir)
+	case "s3":
+		s3 := cfg.Storage.S3
+		return NewS3Storage(s3.Endpoint, s3.Region, s3.Bucket, s3.AccessKey, s3.SecretKey, s3.PathStyle)
+	default:
+		return nil, fmt.Errorf("存储驱动 %s 不支持", cfg.Storage.Driver)
+	}
+}
@@ -1,7 +1,20 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
 
 	"ddup-apis/internal/config"
 
@@ -14,18 +27,256 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// jwtKey 一个签名/验证密钥，kid 为公钥的 RFC 7638 指纹
+type jwtKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{} // 只有当前签名密钥才有
+	verifyKey interface{}
+}
+
+// KeySet 当前签名密钥和所有可用于验证的密钥，轮换时旧公钥继续保留直到其签发的令牌全部过期
+type KeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+	legacy  *jwtKey // 配置了 JWT_SECRET 时，HS256 令牌（包括没有 kid 的旧令牌）继续可以验证
+	secret  []byte  // 签名密钥的原始内容，用于 DeriveKey
+}
+
+// JSONWebKey JWKS 中的一个公钥
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var keySet *KeySet
+
+// InitKeySet 按配置加载 JWT 密钥，需在 config.SetConfig 之后调用
+func InitKeySet(cfg *config.Config) error {
+	ks, err := LoadKeySet(cfg)
+	if err != nil {
+		return err
+	}
+	keySet = ks
+	return nil
+}
+
+// LoadKeySet 配置了 JWT_SIGNING_KEY_FILE 时使用其中的 RSA/Ed25519 私钥签名，否则使用 JWT_SECRET 以 HS256 签名
+func LoadKeySet(cfg *config.Config) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*jwtKey)}
+
+	if cfg.JWT.Secret != "" {
+		secret := []byte(cfg.JWT.Secret)
+		sum := sha256.Sum256(secret)
+		ks.legacy = &jwtKey{
+			id:        "hs-" + base64.RawURLEncoding.EncodeToString(sum[:8]),
+			method:    jwt.SigningMethodHS256,
+			signKey:   secret,
+			verifyKey: secret,
+		}
+		ks.keys[ks.legacy.id] = ks.legacy
+	}
+
+	if cfg.JWT.SigningKeyFile == "" {
+		if ks.legacy == nil {
+			return nil, errors.New("未配置 JWT 密钥")
+		}
+		ks.signing = ks.legacy
+		ks.secret = ks.legacy.signKey.([]byte)
+		return ks, nil
+	}
+
+	signing, err := loadKeyFile(cfg.JWT.SigningKeyFile, true)
+	if err != nil {
+		return nil, err
+	}
+	ks.signing = signing
+	ks.keys[signing.id] = signing
+	if ks.secret, err = x509.MarshalPKCS8PrivateKey(signing.signKey); err != nil {
+		return nil, fmt.Errorf("读取 JWT 签名密钥失败: %w", err)
+	}
+
+	for _, file := range cfg.JWT.VerificationKeyFiles {
+		key, err := loadKeyFile(file, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ks.keys[key.id]; !ok {
+			ks.keys[key.id] = key
+		}
+	}
+
+	return ks, nil
+}
+
+// loadKeyFile 读取 PEM 格式的 RSA 或 Ed25519 密钥，签名密钥必须是私钥，验证密钥可以是公钥或私钥
+func loadKeyFile(path string, requirePrivate bool) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 JWT 密钥文件失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT 密钥文件不是有效的 PEM 格式: %s", path)
+	}
+
+	var private, public interface{}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		private = key
+	} else if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		private = key
+	} else if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		public = key
+	} else if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		public = key
+	} else {
+		return nil, fmt.Errorf("无法解析 JWT 密钥文件: %s", path)
+	}
+
+	if private != nil {
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("不支持的 JWT 密钥类型: %s", path)
+		}
+		public = signer.Public()
+	} else if requirePrivate {
+		return nil, fmt.Errorf("JWT 签名密钥必须是私钥: %s", path)
+	}
+
+	key := &jwtKey{verifyKey: public}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("JWT 密钥只支持 RSA 和 Ed25519: %s", path)
+	}
+	if private != nil {
+		key.signKey = private
+	}
+	key.id = toJWK(key).thumbprint()
+	return key, nil
+}
+
+// SignToken 使用当前签名密钥签发 JWT，并在 header 中写入 kid
+func SignToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(keySet.signing.method, claims)
+	token.Header["kid"] = keySet.signing.id
+	return token.SignedString(keySet.signing.signKey)
+}
+
+// DeriveKey 由当前 JWT 签名密钥派生其他用途的 HMAC 密钥，purpose 区分用途，
+// 用于未单独配置密钥的场景；签名密钥轮换后派生出的密钥随之改变
+func DeriveKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, keySet.secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// SigningAlgorithm 当前签名密钥的算法
+func SigningAlgorithm() string {
+	return keySet.signing.method.Alg()
+}
+
 // ParseToken 解析 JWT Token
 func ParseToken(tokenString string) (*Claims, error) {
-	cfg := config.GetConfig()
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWT.Secret), nil
-	})
-	if err != nil {
+	claims := &Claims{}
+	if err := ParseTokenWithClaims(tokenString, claims); err != nil {
 		return nil, err
 	}
+	return claims, nil
+}
+
+// ParseTokenWithClaims 按 kid 选择验证密钥解析 JWT，签名算法必须与密钥匹配
+func ParseTokenWithClaims(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		key := keySet.legacy
+		if kid, ok := token.Header["kid"].(string); ok {
+			key = keySet.keys[kid]
+		}
+		if key == nil {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods([]string{
+		jwt.SigningMethodHS256.Alg(),
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+// JWKS 返回所有非对称验证公钥，供其他服务验证 ddup 签发的令牌
+func JWKS() *JSONWebKeySet {
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range keySet.keys {
+		if key.method == jwt.SigningMethodHS256 {
+			continue
+		}
+		set.Keys = append(set.Keys, *toJWK(key))
+	}
+	// 当前签名公钥排在最前，其余按 kid 排序，保证响应稳定
+	sort.Slice(set.Keys, func(i, j int) bool {
+		if (set.Keys[i].Kid == keySet.signing.id) != (set.Keys[j].Kid == keySet.signing.id) {
+			return set.Keys[i].Kid == keySet.signing.id
+		}
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
+
+func toJWK(key *jwtKey) *JSONWebKey {
+	jwk := &JSONWebKey{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+	switch pub := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+// thumbprint 计算 RFC 7638 JWK 指纹，作为密钥的 kid
+func (k *JSONWebKey) thumbprint() string {
+	var members interface{}
+	if k.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
 	}
-	return nil, errors.New("invalid token")
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"ddup-apis/internal/config"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useKeySet 按 cfg 加载密钥，测试结束后恢复原来的密钥
+func useKeySet(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	previous := keySet
+	if err := InitKeySet(cfg); err != nil {
+		t.Fatalf("InitKeySet() error = %v", err)
+	}
+	t.Cleanup(func() { keySet = previous })
+}
+
+// writePEM 将密钥以 PEM 格式写入临时文件，private 为 false 时只写入公钥
+func writePEM(t *testing.T, key crypto.Signer, private bool) string {
+	t.Helper()
+	var block *pem.Block
+	if private {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testClaims(userID uint) *Claims {
+	return &Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func TestHS256Token(t *testing.T) {
+	var cfg config.Config
+	cfg.JWT.Secret = "test-jwt-secret"
+	useKeySet(t, &cfg)
+
+	token, err := SignToken(testClaims(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SigningAlgorithm() != "HS256" {
+		t.Errorf("SigningAlgorithm() = %s, want HS256", SigningAlgorithm())
+	}
+	claims, err := ParseToken(token)
+	if err != nil || claims.UserID != 1 {
+		t.Fatalf("ParseToken() = %v, %v", claims, err)
+	}
+
+	// 引入 kid 之前签发的令牌没有 kid，继续用 JWT_SECRET 验证
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(2)).SignedString([]byte(cfg.JWT.Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := ParseToken(legacy); err != nil || claims.UserID != 2 {
+		t.Errorf("没有 kid 的旧令牌: ParseToken() = %v, %v", claims, err)
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(3)).SignedString([]byte("other-secret"))
+	if _, err := ParseToken(forged); err == nil {
+		t.Error("其他密钥签发的令牌不应通过")
+	}
+	if len(JWKS().Keys) != 0 {
+		t.Error("JWKS 不应包含 HS256 密钥")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	cfg.JWT.Secret = "test-jwt-secret"
+	cfg.JWT.SigningKeyFile = writePEM(t, oldKey, true)
+	useKeySet(t, &cfg)
+	oldToken, err := SignToken(testClaims(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SigningAlgorithm() != "EdDSA" {
+		t.Errorf("SigningAlgorithm() = %s, want EdDSA", SigningAlgorithm())
+	}
+	hsToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(2)).SignedString([]byte(cfg.JWT.Secret))
+	oldDerived := DeriveKey("test")
+
+	// 轮换：新私钥签名，旧公钥只用于验证
+	cfg.JWT.SigningKeyFile = writePEM(t, newKey, true)
+	cfg.JWT.VerificationKeyFiles = []string{writePEM(t, oldKey, false)}
+	useKeySet(t, &cfg)
+	newToken, err := SignToken(testClaims(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SigningAlgorithm() != "RS256" {
+		t.Errorf("SigningAlgorithm() = %s, want RS256", SigningAlgorithm())
+	}
+	for _, token := range []string{oldToken, hsToken, newToken} {
+		if _, err := ParseToken(token); err != nil {
+			t.Errorf("轮换后 ParseToken() error = %v", err)
+		}
+	}
+	if bytes.Equal(DeriveKey("test"), oldDerived) {
+		t.Error("签名密钥轮换后派生密钥应改变")
+	}
+
+	jwks := JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "RSA" || jwks.Keys[1].Kty != "OKP" {
+		t.Fatalf("JWKS() = %+v, 应包含新旧两个公钥且当前签名公钥在前", jwks.Keys)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if err != nil || parsed.Header["kid"] != jwks.Keys[0].Kid {
+		t.Errorf("新令牌的 kid = %v, want %s", parsed.Header["kid"], jwks.Keys[0].Kid)
+	}
+
+	// 不在密钥集中的旧密钥签发的令牌、伪造 kid 和算法混淆都应拒绝
+	cfg.JWT.VerificationKeyFiles = nil
+	useKeySet(t, &cfg)
+	if _, err := ParseToken(oldToken); err == nil {
+		t.Error("移除旧公钥后旧令牌不应通过")
+	}
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(4))
+	confused.Header["kid"] = jwks.Keys[0].Kid
+	der, _ := x509.MarshalPKIXPublicKey(&newKey.PublicKey)
+	confusedToken, _ := confused.SignedString(der)
+	if _, err := ParseToken(confusedToken); err == nil {
+		t.Error("用 HS256 冒充 RSA 密钥的令牌不应通过")
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	if _, err := LoadKeySet(&config.Config{}); err == nil {
+		t.Error("未配置密钥时应返回错误")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	cfg.JWT.SigningKeyFile = writePEM(t, key, false)
+	if _, err := LoadKeySet(&cfg); err == nil {
+		t.Error("签名密钥为公钥时应返回错误")
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	os.WriteFile(garbage, []byte("not a key"), 0o600)
+	cfg.JWT.SigningKeyFile = garbage
+	if _, err := LoadKeySet(&cfg); err == nil {
+		t.Error("无效的 PEM 文件应返回错误")
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 第 3.1 节和 RFC 8037 附录 A.3 的示例
+	rsaKey := &JSONWebKey{
+		Kty: "RSA",
+		E:   "AQAB",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	if got := rsaKey.thumbprint(); got != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("RSA thumbprint() = %s", got)
+	}
+	okpKey := &JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	if got := okpKey.thumbprint(); got != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("Ed25519 thumbprint() = %s", got)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MergePatch 按 RFC 7396 将 patch 合并到 target 上并返回结果：
+// patch 中值为 null 的字段被删除，对象递归合并，其他值（包括数组）直接替换
+func MergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	var t, p interface{}
+	if len(target) > 0 {
+		if err := decodeJSON(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(t, p))
+}
+
+// decodeJSON 解码时保留数字原文，避免大整数转成 float64 后丢失精度
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
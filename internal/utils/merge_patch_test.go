@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"新增字段", `{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		{"替换字段", `{"a":1}`, `{"a":"x"}`, `{"a":"x"}`},
+		{"null 删除字段", `{"a":1,"b":2}`, `{"a":null}`, `{"b":2}`},
+		{"删除不存在的字段", `{"a":1}`, `{"b":null}`, `{"a":1}`},
+		{"对象递归合并", `{"a":{"b":1,"c":2}}`, `{"a":{"c":3,"d":4}}`, `{"a":{"b":1,"c":3,"d":4}}`},
+		{"嵌套 null 删除", `{"a":{"b":1,"c":2}}`, `{"a":{"b":null}}`, `{"a":{"c":2}}`},
+		{"数组整体替换", `{"a":[1,2,3]}`, `{"a":[4]}`, `{"a":[4]}`},
+		{"数组中的对象不合并", `{"a":[{"b":1}]}`, `{"a":[{"c":2}]}`, `{"a":[{"c":2}]}`},
+		{"对象替换非对象", `{"a":1}`, `{"a":{"b":null,"c":2}}`, `{"a":{"c":2}}`},
+		{"非对象 patch 替换整个文档", `{"a":1}`, `[1,2]`, `[1,2]`},
+		{"null patch", `{"a":1}`, `null`, `null`},
+		{"空 target", ``, `{"a":1,"b":null}`, `{"a":1}`},
+		{"非对象 target", `"x"`, `{"a":1}`, `{"a":1}`},
+		{"保留大整数精度", `{"a":1}`, `{"b":12345678901234567890}`, `{"a":1,"b":12345678901234567890}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch(json.RawMessage(tt.target), json.RawMessage(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	if _, err := MergePatch(json.RawMessage(`{"a":1}`), json.RawMessage(`{`)); err == nil {
+		t.Error("无效的 patch 应返回错误")
+	}
+	if _, err := MergePatch(json.RawMessage(`{`), json.RawMessage(`{}`)); err == nil {
+		t.Error("无效的 target 应返回错误")
+	}
+}
+
+// jsonEqual 按语义比较两个 JSON 文档，忽略字段顺序
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := decodeJSON(a, &x); err != nil {
+		t.Fatalf("解析 %s 失败: %v", a, err)
+	}
+	if err := decodeJSON(b, &y); err != nil {
+		t.Fatalf("解析 %s 失败: %v", b, err)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return string(xs) == string(ys)
+}
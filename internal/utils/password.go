@@ -1,15 +1,42 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
+	"ddup-apis/internal/config"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func ComparePasswords(hashedPassword string, plainPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
-	return err == nil
+// PasswordHasher 密码哈希算法，哈希以 PHC 字符串格式保存（bcrypt 使用其自身的 $2a$ 格式）
+type PasswordHasher interface {
+	// Hash 使用当前参数生成密码哈希
+	Hash(password string) (string, error)
+	// Verify 校验密码，needsRehash 表示哈希不是用当前参数生成的，应在登录成功后更新
+	Verify(password, encoded string) (ok bool, needsRehash bool)
+	// Match 判断哈希是否由该算法生成
+	Match(encoded string) bool
+}
+
+var passwordHasher PasswordHasher = NewBcryptHasher(bcrypt.DefaultCost)
+
+// InitPasswordHasher 按配置选择密码哈希算法，需在 config.SetConfig 之后调用
+func InitPasswordHasher(cfg *config.Config) error {
+	switch cfg.Password.Hasher {
+	case "argon2id":
+		passwordHasher = NewArgon2idHasher(cfg.Password.Argon2Memory, cfg.Password.Argon2Iterations, cfg.Password.Argon2Parallelism)
+	case "bcrypt":
+		passwordHasher = NewBcryptHasher(cfg.Password.BcryptCost)
+	default:
+		return fmt.Errorf("不支持的密码哈希算法: %s", cfg.Password.Hasher)
+	}
+	return nil
 }
 
 func HashPassword(password string) (string, error) {
@@ -17,15 +44,128 @@ func HashPassword(password string) (string, error) {
 		return "", errors.New("密码不能为空")
 	}
 
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := passwordHasher.Hash(password)
 	if err != nil {
 		return "", fmt.Errorf("生成密码哈希失败: %w", err)
 	}
 
-	return string(hashedBytes), nil
+	return hashed, nil
+}
+
+// VerifyPassword 校验密码，旧算法生成的哈希同样可以校验，needsRehash 为 true 时调用方应重新哈希并保存
+func VerifyPassword(password, hashedPassword string) (ok bool, needsRehash bool) {
+	if passwordHasher.Match(hashedPassword) {
+		return passwordHasher.Verify(password, hashedPassword)
+	}
+
+	// 其他算法生成的旧哈希校验通过后一律需要重新哈希
+	for _, hasher := range []PasswordHasher{&Argon2idHasher{}, &BcryptHasher{}} {
+		if hasher.Match(hashedPassword) {
+			ok, _ = hasher.Verify(password, hashedPassword)
+			return ok, ok
+		}
+	}
+	return false, false
+}
+
+// Argon2idHasher argon2id 哈希，格式为 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, bool) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, false
+	}
+
+	needsRehash := memory != h.Memory || iterations != h.Iterations || parallelism != h.Parallelism ||
+		len(salt) != h.SaltLength || uint32(len(expected)) != h.KeyLength
+	return true, needsRehash
+}
+
+// BcryptHasher bcrypt 哈希，只使用密码的前 72 字节，仅为兼容旧数据保留
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, bool) {
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return true, err != nil || cost != h.Cost
 }
 
-func CheckPassword(password, hashedPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+func (h *BcryptHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
 }
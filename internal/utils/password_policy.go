@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ddup-apis/internal/config"
+)
+
+// PasswordPolicy 密码策略，在注册、修改密码和重置密码时检查
+type PasswordPolicy struct {
+	MinLength        int    // 最少字符数
+	MinCharClasses   int    // 至少包含几类字符：小写字母、大写字母、数字、符号
+	DisallowUsername bool   // 禁止包含用户名
+	BreachedDir      string // 泄露密码库目录，为空时不检查
+}
+
+// PasswordViolation 密码不符合策略的原因
+type PasswordViolation struct {
+	Code    string
+	Message string
+}
+
+var passwordPolicy = &PasswordPolicy{MinLength: 8}
+
+// InitPasswordPolicy 按配置加载密码策略，需在 config.SetConfig 之后调用
+func InitPasswordPolicy(cfg *config.Config) error {
+	policy := &PasswordPolicy{
+		MinLength:        cfg.Password.MinLength,
+		MinCharClasses:   cfg.Password.MinCharClasses,
+		DisallowUsername: cfg.Password.DisallowUsername,
+		BreachedDir:      cfg.Password.BreachedDir,
+	}
+
+	if policy.BreachedDir != "" {
+		info, err := os.Stat(policy.BreachedDir)
+		if err != nil {
+			return fmt.Errorf("读取泄露密码库失败: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("泄露密码库必须是目录: %s", policy.BreachedDir)
+		}
+	}
+
+	passwordPolicy = policy
+	return nil
+}
+
+// CheckPasswordPolicy 按当前策略检查密码，返回所有不符合的项；error 只表示读取泄露密码库失败
+func CheckPasswordPolicy(password, username string) ([]PasswordViolation, error) {
+	return passwordPolicy.Check(password, username)
+}
+
+func (p *PasswordPolicy) Check(password, username string) ([]PasswordViolation, error) {
+	var violations []PasswordViolation
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    "too_short",
+			Message: fmt.Sprintf("密码至少需要 %d 个字符", p.MinLength),
+		})
+	}
+
+	if countCharClasses(password) < p.MinCharClasses {
+		violations = append(violations, PasswordViolation{
+			Code:    "char_classes",
+			Message: fmt.Sprintf("密码需要包含小写字母、大写字母、数字、符号中的至少 %d 类", p.MinCharClasses),
+		})
+	}
+
+	if p.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, PasswordViolation{
+			Code:    "contains_username",
+			Message: "密码不能包含用户名",
+		})
+	}
+
+	if p.BreachedDir != "" {
+		breached, err := p.isBreached(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			violations = append(violations, PasswordViolation{
+				Code:    "breached",
+				Message: "该密码已出现在公开泄露的密码库中，请更换",
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+func countCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+// isBreached 在本地泄露密码库中查找密码。库按 SHA-1 前 5 位分文件存放（<PREFIX>.txt），
+// 每行为剩余 35 位和出现次数（SUFFIX:COUNT），与 Pwned Passwords range 接口的格式一致
+func (p *PasswordPolicy) isBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	file, err := os.Open(filepath.Join(p.BreachedDir, prefix+".txt"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取泄露密码库失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(strings.TrimSpace(line), suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"ddup-apis/internal/config"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeBreachedFixture 按 Pwned Passwords range 格式生成只包含 passwords 的泄露密码库
+func writeBreachedFixture(t *testing.T, passwords ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string][]string{}
+	for _, p := range passwords {
+		sum := sha1.Sum([]byte(p))
+		digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+		files[digest[:5]] = append(files[digest[:5]], digest[5:]+":42")
+	}
+	for prefix, lines := range files {
+		// 同一前缀下放一条无关记录，确认按整行匹配
+		lines = append([]string{strings.Repeat("0", 35) + ":1"}, lines...)
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	var cfg config.Config
+	cfg.Password.MinLength = 10
+	cfg.Password.MinCharClasses = 3
+	cfg.Password.DisallowUsername = true
+	cfg.Password.BreachedDir = writeBreachedFixture(t, "Passw0rd!23456")
+	if err := InitPasswordPolicy(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { passwordPolicy = &PasswordPolicy{MinLength: 8} })
+
+	tests := []struct {
+		password, username string
+		want               []string
+	}{
+		{"Xq7#Str0ng!pass", "alice", nil},
+		{"Xq7#short", "alice", []string{"too_short"}},
+		{"abcdefghijkl", "alice", []string{"char_classes"}},
+		{"短密码", "alice", []string{"too_short", "char_classes"}},
+		{"Xq7#ALICE!pass", "alice", []string{"contains_username"}},
+		{"Xq7#Str0ng!pass", "", nil},
+		{"Passw0rd!23456", "alice", []string{"breached"}},
+		{"Passw0rd!234567", "alice", nil},
+	}
+	for _, tt := range tests {
+		violations, err := CheckPasswordPolicy(tt.password, tt.username)
+		if err != nil {
+			t.Fatalf("CheckPasswordPolicy(%q) error = %v", tt.password, err)
+		}
+		var got []string
+		for _, v := range violations {
+			got = append(got, v.Code)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CheckPasswordPolicy(%q, %q) = %v, want %v", tt.password, tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestInitPasswordPolicyBreachedDir(t *testing.T) {
+	var cfg config.Config
+	cfg.Password.BreachedDir = filepath.Join(t.TempDir(), "missing")
+	if err := InitPasswordPolicy(&cfg); err == nil {
+		t.Error("泄露密码库目录不存在时应返回错误")
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Password.BreachedDir = file
+	if err := InitPasswordPolicy(&cfg); err == nil {
+		t.Error("泄露密码库不是目录时应返回错误")
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 测试使用较小的参数，避免哈希过慢
+func testArgon2id() *Argon2idHasher { return NewArgon2idHasher(64, 1, 1) }
+
+func useHasher(t *testing.T, h PasswordHasher) {
+	t.Helper()
+	previous := passwordHasher
+	passwordHasher = h
+	t.Cleanup(func() { passwordHasher = previous })
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := testArgon2id()
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %s", encoded)
+	}
+	if other, _ := h.Hash("secret"); other == encoded {
+		t.Error("每次哈希应使用不同的盐")
+	}
+
+	if ok, rehash := h.Verify("secret", encoded); !ok || rehash {
+		t.Errorf("Verify(正确密码) = %v, %v, want true, false", ok, rehash)
+	}
+	if ok, _ := h.Verify("wrong", encoded); ok {
+		t.Error("Verify(错误密码) 应返回 false")
+	}
+
+	// 参数调整后旧哈希仍可校验，但需要重新哈希
+	stronger := NewArgon2idHasher(128, 2, 1)
+	if ok, rehash := stronger.Verify("secret", encoded); !ok || !rehash {
+		t.Errorf("参数变化后 Verify() = %v, %v, want true, true", ok, rehash)
+	}
+
+	for _, bad := range []string{
+		"",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=18" + encoded[len("$argon2id$v=19"):],
+		"$argon2i$" + encoded[len("$argon2id$"):],
+		strings.Replace(encoded, "m=64", "m=x", 1),
+	} {
+		if ok, _ := h.Verify("secret", bad); ok {
+			t.Errorf("Verify(%q) 应返回 false", bad)
+		}
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	h := NewBcryptHasher(bcrypt.MinCost)
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Match(encoded) {
+		t.Errorf("Match(%s) = false", encoded)
+	}
+	if ok, rehash := h.Verify("secret", encoded); !ok || rehash {
+		t.Errorf("Verify(正确密码) = %v, %v, want true, false", ok, rehash)
+	}
+	if ok, _ := h.Verify("wrong", encoded); ok {
+		t.Error("Verify(错误密码) 应返回 false")
+	}
+	if ok, rehash := NewBcryptHasher(bcrypt.MinCost+1).Verify("secret", encoded); !ok || !rehash {
+		t.Errorf("cost 变化后 Verify() = %v, %v, want true, true", ok, rehash)
+	}
+}
+
+func TestVerifyPasswordRehash(t *testing.T) {
+	bcryptHash, err := NewBcryptHasher(bcrypt.MinCost).Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argonHash, err := testArgon2id().Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("当前算法为 argon2id", func(t *testing.T) {
+		useHasher(t, testArgon2id())
+		tests := []struct {
+			name               string
+			password, hash     string
+			wantOK, wantRehash bool
+		}{
+			{"argon2id 哈希", "secret", argonHash, true, false},
+			{"bcrypt 旧哈希需要重新哈希", "secret", bcryptHash, true, true},
+			{"bcrypt 旧哈希密码错误", "wrong", bcryptHash, false, false},
+			{"未知格式", "secret", "plain", false, false},
+		}
+		for _, tt := range tests {
+			ok, rehash := VerifyPassword(tt.password, tt.hash)
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("%s: VerifyPassword() = %v, %v, want %v, %v", tt.name, ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		}
+
+		// 重新哈希后使用当前算法
+		rehashed, err := HashPassword("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, rehash := VerifyPassword("secret", rehashed); !strings.HasPrefix(rehashed, "$argon2id$") || !ok || rehash {
+			t.Errorf("重新哈希后 VerifyPassword() = %v, %v, hash = %s", ok, rehash, rehashed)
+		}
+	})
+
+	t.Run("当前算法为 bcrypt", func(t *testing.T) {
+		useHasher(t, NewBcryptHasher(bcrypt.MinCost))
+		if ok, rehash := VerifyPassword("secret", argonHash); !ok || !rehash {
+			t.Errorf("argon2id 哈希 VerifyPassword() = %v, %v, want true, true", ok, rehash)
+		}
+		if ok, rehash := VerifyPassword("secret", bcryptHash); !ok || rehash {
+			t.Errorf("bcrypt 哈希 VerifyPassword() = %v, %v, want true, false", ok, rehash)
+		}
+	})
+
+	if _, err := HashPassword(""); err == nil {
+		t.Error("HashPassword(\"\") 应返回错误")
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 按 key 固定窗口计数的内存限流器，多实例部署时各实例分别计数
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu          sync.Mutex
+	buckets     map[string]*rateBucket
+	nextCleanup time.Time
+}
+
+type rateBucket struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// Allow 记录一次请求，当前窗口内超出限制时返回 false
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理已过窗口的计数，避免 map 无限增长
+	if now.After(l.nextCleanup) {
+		for k, b := range l.buckets {
+			if now.After(b.resetAt) {
+				delete(l.buckets, k)
+			}
+		}
+		l.nextCleanup = now.Add(l.window)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok || now.After(b.resetAt) {
+		b = &rateBucket{resetAt: now.Add(l.window)}
+		l.buckets[key] = b
+	}
+	if b.count >= l.limit {
+		return false
+	}
+	b.count++
+	return true
+}
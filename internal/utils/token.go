@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateRandomToken 生成 n 字节的随机 token，以十六进制字符串返回
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机 token 失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken 计算 token 的 SHA-256 摘要，用于数据库中存储和查找
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	// RFC 6238 附录 B 的测试向量，取 8 位验证码的后 6 位
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, v.code, time.Unix(v.unix, 0))
+		if !ok || step != v.unix/totpPeriod {
+			t.Errorf("ValidateTOTP(%s, %d) = %d, %v, want %d, true", v.code, v.unix, step, ok, v.unix/totpPeriod)
+		}
+	}
+
+	// 允许前后各一个时间步的偏差，返回的是验证码所属的时间步
+	at := time.Unix(1234567890, 0)
+	for _, offset := range []int64{-1, 1} {
+		step, ok := ValidateTOTP(rfc6238Secret, "005924", at.Add(time.Duration(offset*totpPeriod)*time.Second))
+		if !ok || step != 1234567890/totpPeriod {
+			t.Errorf("偏差 %d 个时间步: ValidateTOTP() = %d, %v", offset, step, ok)
+		}
+	}
+	for _, offset := range []int64{-2, 2} {
+		if _, ok := ValidateTOTP(rfc6238Secret, "005924", at.Add(time.Duration(offset*totpPeriod)*time.Second)); ok {
+			t.Errorf("偏差 %d 个时间步的验证码不应通过", offset)
+		}
+	}
+
+	for _, code := range []string{"005925", "05924", "0059240", ""} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, at); ok {
+			t.Errorf("ValidateTOTP(%q) 不应通过", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "005924", at); ok {
+		t.Error("无效的密钥不应通过")
+	}
+	// 密钥大小写不敏感
+	if _, ok := ValidateTOTP("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "005924", at); !ok {
+		t.Error("小写密钥应可校验")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("GenerateTOTPSecret() = %s, 应为 160 位 base32 密钥", secret)
+	}
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("生成的密钥无法校验当前验证码")
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import "strings"
+
+// DeviceLabel 根据 User-Agent 生成简短的设备描述，如 "Chrome on macOS"
+func DeviceLabel(userAgent string) string {
+	if userAgent == "" {
+		return "未知设备"
+	}
+	ua := strings.ToLower(userAgent)
+
+	browser := "未知浏览器"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(ua, "curl/"):
+		browser = "curl"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		os = "iOS"
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+// DeviceFingerprint 根据 User-Agent 生成设备指纹，用于判断是否为曾经登录过的设备
+func DeviceFingerprint(userAgent string) string {
+	return HashToken(strings.ToLower(strings.TrimSpace(userAgent)))
+}
@@ -39,6 +39,9 @@ main() {
     # 运行注册测试
     run_register_test
 
+    # 运行邮箱验证测试（组织等功能需要已验证邮箱的账号）
+    run_email_verification_test
+
     # 运行认证测试（登录）
     run_auth_tests
     
@@ -12,6 +12,27 @@ run_register_test() {
         "注册成功" || true  # 即使注册失败也继续执行
 }
 
+# 邮箱验证测试函数：从 log 邮件驱动保存的邮件中读取验证令牌，新注册的账号验证邮箱后才能使用组织等功能
+run_email_verification_test() {
+    log_info "运行邮箱验证测试..."
+    
+    # 文件名以发送时间开头，取最新的一封
+    local mail_file=$(ls "$MAIL_DIR" 2>/dev/null | grep -- "-$TEST_USER@example.com.txt$" | sort | tail -n 1)
+    if [ -z "$mail_file" ]; then
+        TOTAL_TESTS=$((TOTAL_TESTS + 1))
+        log_error "未找到验证邮件，请使用 MAIL_DRIVER=log 启动服务并将 MAIL_DIR 设为 $MAIL_DIR"
+        return 1
+    fi
+    local verify_token=$(grep -o 'token=[0-9a-f]*' "$MAIL_DIR/$mail_file" | head -n 1 | cut -d= -f2)
+    
+    test_api "验证邮箱" \
+        "POST" \
+        "/auth/email/verify" \
+        "{\"token\":\"$verify_token\"}" \
+        200 \
+        "邮箱验证成功" || true  # 重复运行时账号已验证，令牌已使用，继续执行
+}
+
 # 登录测试函数
 run_auth_tests() {
     log_info "运行登录测试..."
@@ -3,7 +3,9 @@
 # API 配置
 API_URL="http://localhost:8080/api/v1"
 TEST_USER="testuser"
-TEST_PASSWORD="password123"
+TEST_PASSWORD="Passw0rd!23"
+# 服务使用 log 邮件驱动时保存邮件的目录，需与服务的 MAIL_DIR 一致，用于读取验证邮件
+MAIL_DIR="${MAIL_DIR:-./data/mail}"
 
 # 测试结果统计
 TOTAL_TESTS=0
@@ -74,6 +74,12 @@ run_profile_tests() {
         "删除成功"
 }
 
+# 按类型和标题查询资料项 ID
+profile_id() {
+    curl -s -H "Authorization: $TOKEN" "$API_URL/profiles?type=$1" | \
+        jq -r --arg title "$2" '[.data[]? | select(.title == $title) | .id][0] // empty'
+}
+
 run_profile_metadata_tests() {
     log_info "运行个人资料元数据测试..."
     
@@ -135,7 +141,7 @@ run_profile_metadata_tests() {
     # 更新元数据
     test_api "更新教育经历元数据" \
         "PUT" \
-        "/profiles/3" \
+        "/profiles/$(profile_id education 计算机科学与技术)" \
         "{\"metadata\":{\"degree\":\"硕士\",\"title\":\"研究生\",\"coworkers\":[\"张三\",\"李四\",\"王五\"]}}" \
         200 \
         "更新成功"
@@ -143,7 +149,7 @@ run_profile_metadata_tests() {
     # 验证复杂元数据更新
     test_api "更新项目元数据和附件" \
         "PUT" \
-        "/profiles/2" \
+        "/profiles/$(profile_id project 电商平台)" \
         "{\"metadata\":{\"client\":\"某科技公司\",\"collaborators\":[\"李四\",\"王五\",\"赵六\"],\"attachments\":[{\"type\":\"media\",\"url\":\"https://example.com/demo-v2.mp4\",\"mime_type\":\"video/mp4\",\"size\":2048000,\"name\":\"项目演示-新版.mp4\"}]}}" \
         200 \
         "更新成功"